@@ -0,0 +1,142 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package admin
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type Status struct {
+	Name          string
+	Paused        bool
+	LastRun       int64    `json:",omitempty"`
+	LastError     string   `json:",omitempty"`
+	Height        uint64   `json:",omitempty"`
+	LatestHeight  uint64   `json:",omitempty"`
+	PendingHeight uint64   `json:",omitempty"`
+	Skips         []uint64 `json:",omitempty"`
+}
+
+// Service is a loop of the server which can be paused and resumed at runtime
+type Service interface {
+	Pause()
+	Resume()
+	Status() *Status
+}
+
+// ChainService is a service which handles the blocks of a chain one height after another
+type ChainService interface {
+	Service
+	SetHeight(height uint64)
+	SkipBlock(height uint64) error
+}
+
+// Switch pauses a loop and records its last run, the loops embed it to become a Service
+type Switch struct {
+	lock      sync.Mutex
+	paused    bool
+	lastRun   int64
+	lastError string
+}
+
+func (s *Switch) Pause() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.paused = true
+}
+
+func (s *Switch) Resume() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.paused = false
+}
+
+func (s *Switch) Paused() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.paused
+}
+
+func (s *Switch) Ran(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.lastRun = time.Now().Unix()
+	s.lastError = ""
+	if err != nil {
+		s.lastError = err.Error()
+	}
+}
+
+func (s *Switch) Status() *Status {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return &Status{Paused: s.paused, LastRun: s.lastRun, LastError: s.lastError}
+}
+
+var (
+	services = make(map[string]Service)
+	lock     sync.Mutex
+)
+
+func Register(name string, service Service) {
+	lock.Lock()
+	defer lock.Unlock()
+	services[name] = service
+}
+
+func Unregister(name string) {
+	lock.Lock()
+	defer lock.Unlock()
+	delete(services, name)
+}
+
+func GetService(name string) Service {
+	lock.Lock()
+	defer lock.Unlock()
+	return services[name]
+}
+
+// Statuses returns the status of all registered services ordered by name
+func Statuses() []*Status {
+	lock.Lock()
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	lock.Unlock()
+	sort.Strings(names)
+	statuses := make([]*Status, 0, len(names))
+	for _, name := range names {
+		if status := ServiceStatus(name); status != nil {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+func ServiceStatus(name string) *Status {
+	service := GetService(name)
+	if service == nil {
+		return nil
+	}
+	status := service.Status()
+	status.Name = name
+	return status
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testChainService struct {
+	Switch
+	height uint64
+	skips  []uint64
+}
+
+func (service *testChainService) SetHeight(height uint64) {
+	service.height = height
+}
+
+func (service *testChainService) SkipBlock(height uint64) error {
+	if height <= service.height {
+		return fmt.Errorf("block %d is handled already", height)
+	}
+	service.skips = append(service.skips, height)
+	return nil
+}
+
+func request(handler http.Handler, method string, target string, token string) (int, []byte) {
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code, rec.Body.Bytes()
+}
+
+func TestHandler(t *testing.T) {
+	chain := &testChainService{}
+	Register("listen_6", chain)
+	Register("price", &Switch{})
+	defer Unregister("listen_6")
+	defer Unregister("price")
+	handler := NewHandler("secret")
+
+	code, _ := request(handler, http.MethodGet, "/status", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, body := request(handler, http.MethodGet, "/status", "secret")
+	assert.Equal(t, http.StatusOK, code)
+	statuses := make([]*Status, 0)
+	assert.NoError(t, json.Unmarshal(body, &statuses))
+	assert.Equal(t, 2, len(statuses))
+	assert.Equal(t, "listen_6", statuses[0].Name)
+	assert.Equal(t, "price", statuses[1].Name)
+
+	code, _ = request(handler, http.MethodGet, "/pause?name=price", "secret")
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+	code, body = request(handler, http.MethodPost, "/pause?name=price", "secret")
+	assert.Equal(t, http.StatusOK, code)
+	status := &Status{}
+	assert.NoError(t, json.Unmarshal(body, status))
+	assert.True(t, status.Paused)
+	assert.True(t, GetService("price").(*Switch).Paused())
+	code, _ = request(handler, http.MethodPost, "/resume?name=price", "secret")
+	assert.Equal(t, http.StatusOK, code)
+	assert.False(t, GetService("price").(*Switch).Paused())
+
+	code, _ = request(handler, http.MethodPost, "/height?name=price&height=100", "secret")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = request(handler, http.MethodPost, "/height?name=listen_6&height=x", "secret")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = request(handler, http.MethodPost, "/height?name=listen_6&height=100", "secret")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, uint64(100), chain.height)
+	code, _ = request(handler, http.MethodPost, "/skip?name=listen_6&height=90", "secret")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = request(handler, http.MethodPost, "/skip?name=listen_6&height=101", "secret")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []uint64{101}, chain.skips)
+
+	code, _ = request(handler, http.MethodPost, "/pause?name=fee", "secret")
+	assert.Equal(t, http.StatusNotFound, code)
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"poly-bridge/conf"
+	"strconv"
+	"strings"
+
+	"github.com/astaxie/beego/logs"
+)
+
+const (
+	ACTION_STATUS = "status"
+	ACTION_PAUSE  = "pause"
+	ACTION_RESUME = "resume"
+	ACTION_HEIGHT = "height"
+	ACTION_SKIP   = "skip"
+)
+
+type errorRsp struct {
+	Error string
+}
+
+var server *http.Server
+
+// StartAdmin serves the admin requests on 127.0.0.1, it does nothing without a port or a token.
+func StartAdmin(cfg *conf.AdminConfig) {
+	if cfg == nil || cfg.Port == 0 {
+		return
+	}
+	if cfg.Token == "" {
+		logs.Error("admin server is not started, the token is empty")
+		return
+	}
+	server = &http.Server{Addr: fmt.Sprintf("127.0.0.1:%d", cfg.Port), Handler: NewHandler(cfg.Token)}
+	go func(server *http.Server) {
+		logs.Info("start admin server at %s", server.Addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logs.Error("admin server err: %v", err)
+		}
+	}(server)
+}
+
+func StopAdmin() {
+	if server != nil {
+		server.Close()
+		server = nil
+		logs.Info("stop admin server.")
+	}
+}
+
+// NewHandler serves the status of the services by GET, and the controls of a service by POST with the
+// service name and the height in the query.
+func NewHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+ACTION_STATUS, handleStatus)
+	mux.HandleFunc("/"+ACTION_PAUSE, handleControl(func(service Service, height uint64) error {
+		service.Pause()
+		return nil
+	}))
+	mux.HandleFunc("/"+ACTION_RESUME, handleControl(func(service Service, height uint64) error {
+		service.Resume()
+		return nil
+	}))
+	mux.HandleFunc("/"+ACTION_HEIGHT, handleControl(func(service Service, height uint64) error {
+		chainService, ok := service.(ChainService)
+		if !ok {
+			return fmt.Errorf("service has no height")
+		}
+		chainService.SetHeight(height)
+		return nil
+	}))
+	mux.HandleFunc("/"+ACTION_SKIP, handleControl(func(service Service, height uint64) error {
+		chainService, ok := service.(ChainService)
+		if !ok {
+			return fmt.Errorf("service has no blocks")
+		}
+		return chainService.SkipBlock(height)
+	}))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 {
+			writeJson(w, http.StatusUnauthorized, &errorRsp{Error: "unauthorized"})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func handleStatus(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		writeJson(w, http.StatusOK, Statuses())
+		return
+	}
+	status := ServiceStatus(name)
+	if status == nil {
+		writeJson(w, http.StatusNotFound, &errorRsp{Error: fmt.Sprintf("service %s is not found", name)})
+		return
+	}
+	writeJson(w, http.StatusOK, status)
+}
+
+func handleControl(control func(service Service, height uint64) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJson(w, http.StatusMethodNotAllowed, &errorRsp{Error: "method not allowed"})
+			return
+		}
+		name := r.URL.Query().Get("name")
+		service := GetService(name)
+		if service == nil {
+			writeJson(w, http.StatusNotFound, &errorRsp{Error: fmt.Sprintf("service %s is not found", name)})
+			return
+		}
+		height := uint64(0)
+		if value := r.URL.Query().Get("height"); value != "" {
+			var err error
+			height, err = strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				writeJson(w, http.StatusBadRequest, &errorRsp{Error: fmt.Sprintf("invalid height %s", value)})
+				return
+			}
+		}
+		if err := control(service, height); err != nil {
+			writeJson(w, http.StatusBadRequest, &errorRsp{Error: err.Error()})
+			return
+		}
+		logs.Info("admin %s service %s, height: %d", strings.TrimPrefix(r.URL.Path, "/"), name, height)
+		writeJson(w, http.StatusOK, ServiceStatus(name))
+	}
+}
+
+func writeJson(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
+
+// Request sends an admin request to the local server of the config and returns the response
+func Request(cfg *conf.AdminConfig, action string, name string, height uint64) ([]byte, error) {
+	if cfg == nil || cfg.Port == 0 {
+		return nil, fmt.Errorf("admin server is not configured")
+	}
+	query := url.Values{}
+	if name != "" {
+		query.Set("name", name)
+	}
+	if height != 0 {
+		query.Set("height", strconv.FormatUint(height, 10))
+	}
+	method := http.MethodPost
+	if action == ACTION_STATUS {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequest(method, fmt.Sprintf("http://127.0.0.1:%d/%s?%s", cfg.Port, action, query.Encode()), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+cfg.Token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("response status code: %d, %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
@@ -0,0 +1,191 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package alert
+
+import (
+	"fmt"
+	"github.com/astaxie/beego/logs"
+	"poly-bridge/conf"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SEVERITY_INFO     = "info"
+	SEVERITY_WARNING  = "warning"
+	SEVERITY_CRITICAL = "critical"
+)
+
+var severityLevels = map[string]int{
+	SEVERITY_INFO:     0,
+	SEVERITY_WARNING:  1,
+	SEVERITY_CRITICAL: 2,
+}
+
+type Alert struct {
+	Key      string            `json:"key"`
+	Severity string            `json:"severity"`
+	Source   string            `json:"source"`
+	Title    string            `json:"title"`
+	Message  string            `json:"message"`
+	Fields   map[string]string `json:"fields,omitempty"`
+	Time     int64             `json:"time"`
+	Resolved bool              `json:"resolved"`
+}
+
+func (alert *Alert) Text() string {
+	status := strings.ToUpper(alert.Severity)
+	if alert.Resolved {
+		status = "RESOLVED"
+	}
+	text := fmt.Sprintf("[%s] %s: %s", status, alert.Source, alert.Title)
+	if alert.Message != "" {
+		text += "\n" + alert.Message
+	}
+	keys := make([]string, 0, len(alert.Fields))
+	for k := range alert.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		text += fmt.Sprintf("\n%s: %s", k, alert.Fields[k])
+	}
+	return text
+}
+
+type Notifier interface {
+	Notify(alert *Alert) error
+	Name() string
+}
+
+func NewNotifier(cfg *conf.NotifierConfig) Notifier {
+	if cfg.Type == "webhook" {
+		return NewWebhookNotifier(cfg)
+	} else if cfg.Type == "slack" {
+		return NewSlackNotifier(cfg)
+	} else if cfg.Type == "telegram" {
+		return NewTelegramNotifier(cfg)
+	} else if cfg.Type == "smtp" {
+		return NewSmtpNotifier(cfg)
+	} else {
+		return nil
+	}
+}
+
+type firing struct {
+	alert *Alert
+	sent  int64
+}
+
+// Alerter sends alerts to all notifiers, drops repeats of an active alert inside the dedup window
+// and sends a recovery message once an active alert is resolved.
+type Alerter struct {
+	notifiers   []Notifier
+	severities  []string
+	dedupWindow int64
+	active      map[string]*firing
+	lock        sync.Mutex
+}
+
+func NewAlerter(cfg *conf.AlertConfig) *Alerter {
+	alerter := &Alerter{
+		notifiers:  make([]Notifier, 0),
+		severities: make([]string, 0),
+		active:     make(map[string]*firing),
+	}
+	if cfg == nil {
+		return alerter
+	}
+	alerter.dedupWindow = cfg.DedupWindow
+	for _, notifierCfg := range cfg.Notifiers {
+		notifier := NewNotifier(notifierCfg)
+		if notifier == nil {
+			panic(fmt.Sprintf("notifier %s is invalid", notifierCfg.Type))
+		}
+		alerter.notifiers = append(alerter.notifiers, notifier)
+		alerter.severities = append(alerter.severities, notifierCfg.MinSeverity)
+	}
+	return alerter
+}
+
+func (alerter *Alerter) Fire(alert *Alert) {
+	if alert.Time == 0 {
+		alert.Time = time.Now().Unix()
+	}
+	if alert.Severity == SEVERITY_INFO {
+		logs.Info("alert %s [%s] %s: %s %s", alert.Key, alert.Severity, alert.Source, alert.Title, alert.Message)
+	} else {
+		logs.Error("alert %s [%s] %s: %s %s", alert.Key, alert.Severity, alert.Source, alert.Title, alert.Message)
+	}
+	alerter.lock.Lock()
+	last, ok := alerter.active[alert.Key]
+	if ok && alert.Time-last.sent < alerter.dedupWindow && severityLevels[alert.Severity] <= severityLevels[last.alert.Severity] {
+		alerter.lock.Unlock()
+		return
+	}
+	alerter.active[alert.Key] = &firing{alert: alert, sent: alert.Time}
+	alerter.lock.Unlock()
+	alerter.send(alert)
+}
+
+func (alerter *Alerter) Resolve(key string, message string) {
+	alerter.lock.Lock()
+	last, ok := alerter.active[key]
+	if !ok {
+		alerter.lock.Unlock()
+		return
+	}
+	delete(alerter.active, key)
+	alerter.lock.Unlock()
+	resolved := *last.alert
+	resolved.Resolved = true
+	resolved.Message = message
+	resolved.Time = time.Now().Unix()
+	logs.Info("alert %s resolved: %s", key, message)
+	alerter.send(&resolved)
+}
+
+func (alerter *Alerter) send(alert *Alert) {
+	for i, notifier := range alerter.notifiers {
+		if severityLevels[alert.Severity] < severityLevels[alerter.severities[i]] {
+			continue
+		}
+		go func(notifier Notifier) {
+			err := notifier.Notify(alert)
+			if err != nil {
+				logs.Error("notify alert %s by %s err: %v", alert.Key, notifier.Name(), err)
+			}
+		}(notifier)
+	}
+}
+
+var alerter = NewAlerter(nil)
+
+func SetupAlert(cfg *conf.AlertConfig) {
+	alerter = NewAlerter(cfg)
+}
+
+func Fire(alert *Alert) {
+	alerter.Fire(alert)
+}
+
+func Resolve(key string, message string) {
+	alerter.Resolve(key, message)
+}
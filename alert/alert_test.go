@@ -0,0 +1,86 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testNotifier struct {
+	alerts chan *Alert
+}
+
+func (notifier *testNotifier) Notify(alert *Alert) error {
+	notifier.alerts <- alert
+	return nil
+}
+
+func (notifier *testNotifier) Name() string {
+	return "test"
+}
+
+func (notifier *testNotifier) next() *Alert {
+	select {
+	case alert := <-notifier.alerts:
+		return alert
+	case <-time.After(time.Millisecond * 100):
+		return nil
+	}
+}
+
+func TestAlerter(t *testing.T) {
+	all := &testNotifier{alerts: make(chan *Alert, 10)}
+	critical := &testNotifier{alerts: make(chan *Alert, 10)}
+	alerter := NewAlerter(nil)
+	alerter.dedupWindow = 600
+	alerter.notifiers = []Notifier{all, critical}
+	alerter.severities = []string{"", SEVERITY_CRITICAL}
+
+	alerter.Fire(&Alert{Key: "a", Severity: SEVERITY_WARNING, Title: "first"})
+	assert.Equal(t, "first", all.next().Title)
+	assert.Nil(t, critical.next())
+
+	alerter.Fire(&Alert{Key: "a", Severity: SEVERITY_WARNING, Title: "repeat"})
+	assert.Nil(t, all.next())
+
+	alerter.Fire(&Alert{Key: "a", Severity: SEVERITY_CRITICAL, Title: "escalate"})
+	assert.Equal(t, "escalate", all.next().Title)
+	assert.Equal(t, "escalate", critical.next().Title)
+
+	alerter.Resolve("a", "ok")
+	resolved := all.next()
+	assert.True(t, resolved.Resolved)
+	assert.Equal(t, "ok", resolved.Message)
+	assert.True(t, critical.next().Resolved)
+
+	alerter.Resolve("a", "ok")
+	assert.Nil(t, all.next())
+
+	alerter.Fire(&Alert{Key: "a", Severity: SEVERITY_WARNING, Title: "again"})
+	assert.Equal(t, "again", all.next().Title)
+}
+
+func TestAlert_Text(t *testing.T) {
+	alert := &Alert{Severity: SEVERITY_WARNING, Source: "test", Title: "title", Fields: map[string]string{"b": "2", "a": "1"}}
+	assert.Equal(t, "[WARNING] test: title\na: 1\nb: 2", alert.Text())
+	alert.Resolved = true
+	assert.Equal(t, "[RESOLVED] test: title\na: 1\nb: 2", alert.Text())
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package alert
+
+import (
+	"fmt"
+	"net/smtp"
+	"poly-bridge/conf"
+	"strings"
+)
+
+// SmtpNotifier mails the alert text to the configured receivers
+type SmtpNotifier struct {
+	cfg *conf.NotifierConfig
+}
+
+func NewSmtpNotifier(cfg *conf.NotifierConfig) *SmtpNotifier {
+	return &SmtpNotifier{
+		cfg: cfg,
+	}
+}
+
+func (notifier *SmtpNotifier) Notify(alert *Alert) error {
+	status := strings.ToUpper(alert.Severity)
+	if alert.Resolved {
+		status = "RESOLVED"
+	}
+	subject := fmt.Sprintf("[%s] %s: %s", status, alert.Source, alert.Title)
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		notifier.cfg.From, strings.Join(notifier.cfg.To, ","), subject, alert.Text())
+	var auth smtp.Auth
+	if notifier.cfg.User != "" {
+		auth = smtp.PlainAuth("", notifier.cfg.User, notifier.cfg.Password, notifier.cfg.SmtpHost)
+	}
+	addr := fmt.Sprintf("%s:%d", notifier.cfg.SmtpHost, notifier.cfg.SmtpPort)
+	return smtp.SendMail(addr, auth, notifier.cfg.From, notifier.cfg.To, []byte(msg))
+}
+
+func (notifier *SmtpNotifier) Name() string {
+	return "smtp"
+}
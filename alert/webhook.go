@@ -0,0 +1,122 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"poly-bridge/conf"
+	"time"
+)
+
+func postJson(client *http.Client, url string, body interface{}) error {
+	requestJson, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(requestJson))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("response status: %d, body: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// WebhookNotifier posts the alert as json to the url
+type WebhookNotifier struct {
+	cfg    *conf.NotifierConfig
+	client *http.Client
+}
+
+func NewWebhookNotifier(cfg *conf.NotifierConfig) *WebhookNotifier {
+	return &WebhookNotifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: time.Second * 10},
+	}
+}
+
+func (notifier *WebhookNotifier) Notify(alert *Alert) error {
+	return postJson(notifier.client, notifier.cfg.Url, alert)
+}
+
+func (notifier *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+// SlackNotifier posts the alert text to a slack compatible incoming webhook
+type SlackNotifier struct {
+	cfg    *conf.NotifierConfig
+	client *http.Client
+}
+
+func NewSlackNotifier(cfg *conf.NotifierConfig) *SlackNotifier {
+	return &SlackNotifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: time.Second * 10},
+	}
+}
+
+func (notifier *SlackNotifier) Notify(alert *Alert) error {
+	return postJson(notifier.client, notifier.cfg.Url, map[string]string{
+		"text": alert.Text(),
+	})
+}
+
+func (notifier *SlackNotifier) Name() string {
+	return "slack"
+}
+
+// TelegramNotifier sends the alert text by the sendMessage method of a telegram compatible bot api
+type TelegramNotifier struct {
+	cfg    *conf.NotifierConfig
+	client *http.Client
+}
+
+func NewTelegramNotifier(cfg *conf.NotifierConfig) *TelegramNotifier {
+	return &TelegramNotifier{
+		cfg:    cfg,
+		client: &http.Client{Timeout: time.Second * 10},
+	}
+}
+
+func (notifier *TelegramNotifier) Notify(alert *Alert) error {
+	url := notifier.cfg.Url
+	if url == "" {
+		url = "https://api.telegram.org"
+	}
+	return postJson(notifier.client, fmt.Sprintf("%s/bot%s/sendMessage", url, notifier.cfg.Token), map[string]string{
+		"chat_id": notifier.cfg.ChatId,
+		"text":    alert.Text(),
+	})
+}
+
+func (notifier *TelegramNotifier) Name() string {
+	return "telegram"
+}
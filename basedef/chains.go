@@ -0,0 +1,146 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package basedef
+
+import (
+	"sort"
+	"sync"
+)
+
+const (
+	CHAIN_FAMILY_EVM      = "evm"
+	CHAIN_FAMILY_NEO      = "neo"
+	CHAIN_FAMILY_ONTOLOGY = "ontology"
+	CHAIN_FAMILY_POLY     = "poly"
+)
+
+const (
+	ADDRESS_CODEC_HEX      = "hex"
+	ADDRESS_CODEC_EVM      = "evm"
+	ADDRESS_CODEC_NEO      = "neo"
+	ADDRESS_CODEC_ONTOLOGY = "ontology"
+)
+
+// byte order of the chain's transaction hashes inside poly cross chain messages
+const (
+	BYTE_ORDER_BIG    = "big"
+	BYTE_ORDER_LITTLE = "little"
+)
+
+type ChainInfo struct {
+	ChainId      uint64
+	ChainName    string
+	Family       string
+	AddressCodec string
+	ByteOrder    string
+}
+
+var (
+	chainInfos    = make(map[uint64]*ChainInfo)
+	chainInfoLock sync.RWMutex
+)
+
+func init() {
+	RegisterChain(&ChainInfo{ChainId: POLY_CROSSCHAIN_ID, ChainName: "Poly", Family: CHAIN_FAMILY_POLY})
+	RegisterChain(&ChainInfo{ChainId: ETHEREUM_CROSSCHAIN_ID, ChainName: "Ethereum", Family: CHAIN_FAMILY_EVM})
+	RegisterChain(&ChainInfo{ChainId: ONT_CROSSCHAIN_ID, ChainName: "Ontology", Family: CHAIN_FAMILY_ONTOLOGY})
+	RegisterChain(&ChainInfo{ChainId: NEO_CROSSCHAIN_ID, ChainName: "NEO", Family: CHAIN_FAMILY_NEO})
+	RegisterChain(&ChainInfo{ChainId: BSC_CROSSCHAIN_ID, ChainName: "BSC", Family: CHAIN_FAMILY_EVM})
+	RegisterChain(&ChainInfo{ChainId: HECO_CROSSCHAIN_ID, ChainName: "HECO", Family: CHAIN_FAMILY_EVM})
+	RegisterChain(&ChainInfo{ChainId: O3_CROSSCHAIN_ID, ChainName: "O3", Family: CHAIN_FAMILY_EVM, AddressCodec: ADDRESS_CODEC_HEX})
+	RegisterChain(&ChainInfo{ChainId: OK_CROSSCHAIN_ID, ChainName: "Ok", Family: CHAIN_FAMILY_EVM})
+}
+
+// RegisterChain adds or replaces a chain in the registry. Empty fields are taken from the
+// chain already registered with the same id, and then from the defaults of the chain family.
+func RegisterChain(info *ChainInfo) {
+	chainInfoLock.Lock()
+	defer chainInfoLock.Unlock()
+	chain := *info
+	if old, ok := chainInfos[chain.ChainId]; ok {
+		if chain.ChainName == "" {
+			chain.ChainName = old.ChainName
+		}
+		if chain.Family == "" {
+			chain.Family = old.Family
+		}
+		if chain.AddressCodec == "" && chain.Family == old.Family {
+			chain.AddressCodec = old.AddressCodec
+		}
+		if chain.ByteOrder == "" && chain.Family == old.Family {
+			chain.ByteOrder = old.ByteOrder
+		}
+	}
+	if chain.AddressCodec == "" {
+		switch chain.Family {
+		case CHAIN_FAMILY_EVM:
+			chain.AddressCodec = ADDRESS_CODEC_EVM
+		case CHAIN_FAMILY_NEO:
+			chain.AddressCodec = ADDRESS_CODEC_NEO
+		case CHAIN_FAMILY_ONTOLOGY:
+			chain.AddressCodec = ADDRESS_CODEC_ONTOLOGY
+		default:
+			chain.AddressCodec = ADDRESS_CODEC_HEX
+		}
+	}
+	if chain.ByteOrder == "" {
+		if chain.Family == CHAIN_FAMILY_EVM {
+			chain.ByteOrder = BYTE_ORDER_BIG
+		} else {
+			chain.ByteOrder = BYTE_ORDER_LITTLE
+		}
+	}
+	chainInfos[chain.ChainId] = &chain
+}
+
+func GetChain(chainId uint64) *ChainInfo {
+	chainInfoLock.RLock()
+	defer chainInfoLock.RUnlock()
+	return chainInfos[chainId]
+}
+
+func GetChains() []*ChainInfo {
+	chainInfoLock.RLock()
+	defer chainInfoLock.RUnlock()
+	chains := make([]*ChainInfo, 0, len(chainInfos))
+	for _, chain := range chainInfos {
+		chains = append(chains, chain)
+	}
+	sort.Slice(chains, func(i, j int) bool {
+		return chains[i].ChainId < chains[j].ChainId
+	})
+	return chains
+}
+
+func GetChainFamily(chainId uint64) string {
+	chain := GetChain(chainId)
+	if chain == nil {
+		return ""
+	}
+	return chain.Family
+}
+
+// IsLittleEndianChain tells whether hashes of the chain are reversed in poly cross chain messages.
+// Unknown chains are treated as little endian, as poly does for non-evm chains.
+func IsLittleEndianChain(chainId uint64) bool {
+	chain := GetChain(chainId)
+	if chain == nil {
+		return true
+	}
+	return chain.ByteOrder != BYTE_ORDER_BIG
+}
@@ -0,0 +1,42 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package basedef
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterChain(t *testing.T) {
+	RegisterChain(&ChainInfo{ChainId: 10001, ChainName: "Polygon", Family: CHAIN_FAMILY_EVM})
+	chain := GetChain(10001)
+	assert.Equal(t, ADDRESS_CODEC_EVM, chain.AddressCodec)
+	assert.Equal(t, BYTE_ORDER_BIG, chain.ByteOrder)
+	assert.False(t, IsLittleEndianChain(10001))
+	assert.Equal(t, "3d4b9c4a0e9bd4a1d4ddf7fba8b8f7a1a5ebd3f1", Hash2Address(10001, "0x3D4B9c4a0E9bD4a1D4ddF7fBa8b8F7a1a5eBd3F1"))
+
+	RegisterChain(&ChainInfo{ChainId: 10001, ChainName: "Polygon Mainnet"})
+	chain = GetChain(10001)
+	assert.Equal(t, CHAIN_FAMILY_EVM, chain.Family)
+	assert.Equal(t, ADDRESS_CODEC_EVM, chain.AddressCodec)
+
+	assert.True(t, IsLittleEndianChain(NEO_CROSSCHAIN_ID))
+	assert.True(t, IsLittleEndianChain(10002))
+	assert.Equal(t, "abcd", Hash2Address(10002, "abcd"))
+}
@@ -19,6 +19,7 @@ const (
 	STATE_SOURCE_CONFIRMED
 	STATE_POLY_CONFIRMED
 	STATE_DESTINATION_DONE
+	STATE_DESTINATION_REVERTED
 )
 
 const (
@@ -27,8 +28,13 @@ const (
 	SERVER_EXPLORER    = "explorer"
 	SERVER_ADDRESS     = "address"
 	SERVER_STAKE       = "stake"
+	SERVER_MEMORY      = "memory"
 )
 
 const (
 	ADDRESS_LENGTH = 64
 )
+
+const (
+	PROXY_LABEL_POLY = "poly"
+)
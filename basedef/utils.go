@@ -49,26 +49,22 @@ func ReadFile(fileName string) ([]byte, error) {
 }
 
 func Hash2Address(chainId uint64, value string) string {
-	if chainId == ETHEREUM_CROSSCHAIN_ID {
+	chain := GetChain(chainId)
+	if chain == nil {
+		return value
+	}
+	switch chain.AddressCodec {
+	case ADDRESS_CODEC_EVM:
 		addr := common.HexToAddress(value)
 		return strings.ToLower(addr.String()[2:])
-	} else if chainId == NEO_CROSSCHAIN_ID {
+	case ADDRESS_CODEC_NEO:
 		addrHex, _ := hex.DecodeString(value)
 		addr, _ := helper.UInt160FromBytes(addrHex)
 		return helper.ScriptHashToAddress(addr)
-	} else if chainId == BSC_CROSSCHAIN_ID {
-		addr := common.HexToAddress(value)
-		return strings.ToLower(addr.String()[2:])
-	} else if chainId == HECO_CROSSCHAIN_ID {
-		addr := common.HexToAddress(value)
-		return strings.ToLower(addr.String()[2:])
-	} else if chainId == ONT_CROSSCHAIN_ID {
+	case ADDRESS_CODEC_ONTOLOGY:
 		value = HexStringReverse(value)
 		addr, _ := ontcommon.AddressFromHexString(value)
 		return addr.ToBase58()
-	} else if chainId == OK_CROSSCHAIN_ID {
-		addr := common.HexToAddress(value)
-		return strings.ToLower(addr.String()[2:])
 	}
 	return value
 }
@@ -0,0 +1,443 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"poly-bridge/conf"
+	"poly-bridge/crosschaindao"
+	"poly-bridge/crosschainlisten"
+	"poly-bridge/models"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/urfave/cli"
+)
+
+const BACKFILL_RETRY = 3
+
+var backfillCommand = cli.Command{
+	Name:   "backfill",
+	Usage:  "Re-scan a height range of a chain and write the events idempotently",
+	Action: backfill,
+	Flags: []cli.Flag{
+		cli.Uint64Flag{
+			Name:  "chain",
+			Usage: "Chain id to re-scan",
+		},
+		cli.Uint64Flag{
+			Name:  "start",
+			Usage: "First block height of the range",
+		},
+		cli.Uint64Flag{
+			Name:  "end",
+			Usage: "Last block height of the range",
+		},
+		cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "How many block ranges are fetched at the same time",
+			Value: 4,
+		},
+		cli.Uint64Flag{
+			Name:  "batch",
+			Usage: "Blocks fetched in one HandleNewBlocks call, default to the BatchSize of the chain",
+		},
+		cli.StringFlag{
+			Name:  "progress",
+			Usage: "Progress file `<path>` used to resume, default to backfill_<chain>_<start>_<end>.json",
+		},
+		cli.BoolFlag{
+			Name:  "dryrun",
+			Usage: "Only print the rows which would be inserted or changed",
+		},
+	},
+}
+
+type backfillProgress struct {
+	ChainId uint64
+	Start   uint64
+	End     uint64
+	Next    uint64 // all blocks below Next are written
+}
+
+func loadBackfillProgress(path string, chainId uint64, start uint64, end uint64) (*backfillProgress, error) {
+	progress := &backfillProgress{ChainId: chainId, Start: start, End: end, Next: start}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return progress, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, progress); err != nil {
+		return nil, fmt.Errorf("invalid progress file %s: %v", path, err)
+	}
+	if progress.ChainId != chainId || progress.Start != start || progress.End != end {
+		return nil, fmt.Errorf("progress file %s belongs to chain %d blocks %d-%d", path, progress.ChainId, progress.Start, progress.End)
+	}
+	return progress, nil
+}
+
+func (progress *backfillProgress) save(path string) error {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+type backfillBlocks struct {
+	start                 uint64
+	end                   uint64
+	wrapperTransactions   []*models.WrapperTransaction
+	srcTransactions       []*models.SrcTransaction
+	polyTransactions      []*models.PolyTransaction
+	dstTransactions       []*models.DstTransaction
+	failedDstTransactions []*models.FailedDstTransaction
+	err                   error
+}
+
+// fetchBackfillBlocks handles the blocks, the reverted executions of poly transactions are found too when
+// failedUnlockHandle is not nil
+func fetchBackfillBlocks(handle crosschainlisten.ChainHandle, failedUnlockHandle crosschainlisten.FailedUnlockHandle, start uint64, end uint64) *backfillBlocks {
+	blocks := &backfillBlocks{start: start, end: end}
+	for i := 0; i < BACKFILL_RETRY; i++ {
+		if i > 0 {
+			time.Sleep(time.Second * time.Duration(5*i))
+		}
+		blocks.wrapperTransactions, blocks.srcTransactions, blocks.polyTransactions, blocks.dstTransactions, blocks.err = handle.HandleNewBlocks(start, end)
+		if blocks.err != nil {
+			logs.Error("HandleNewBlocks %d-%d err: %v", start, end, blocks.err)
+			continue
+		}
+		if failedUnlockHandle == nil {
+			return blocks
+		}
+		blocks.failedDstTransactions, blocks.err = failedUnlockHandle.HandleFailedUnlocks(start, end)
+		if blocks.err == nil {
+			return blocks
+		}
+		logs.Error("HandleFailedUnlocks %d-%d err: %v", start, end, blocks.err)
+	}
+	return blocks
+}
+
+func backfill(ctx *cli.Context) error {
+	config := conf.NewConfig(ctx.GlobalString(getFlagName(configPathFlag)))
+	if config == nil {
+		return fmt.Errorf("read config failed")
+	}
+	chainId, start, end := ctx.Uint64("chain"), ctx.Uint64("start"), ctx.Uint64("end")
+	if end == 0 || start > end {
+		return fmt.Errorf("invalid range %d-%d", start, end)
+	}
+	chainConfig := config.GetChainListenConfig(chainId)
+	if chainConfig == nil {
+		return fmt.Errorf("chain %d is not configured", chainId)
+	}
+	handle := crosschainlisten.NewChainHandle(chainConfig)
+	if handle == nil {
+		return fmt.Errorf("chain %d handler is invalid", chainId)
+	}
+	dao := crosschaindao.NewCrossChainDao(config.Server, false, config.DBConfig)
+	if dao == nil {
+		return fmt.Errorf("server %s is not valid", config.Server)
+	}
+	batch := ctx.Uint64("batch")
+	if batch == 0 {
+		batch = handle.GetBatchSize()
+	}
+	if batch == 0 {
+		batch = 1
+	}
+	concurrency := ctx.Int("concurrency")
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	dryRun := ctx.Bool("dryrun")
+	// the reverted executions are written with the events when the dao stores them
+	failedDstDao, _ := dao.(crosschaindao.FailedDstDao)
+	failedUnlockHandle, _ := handle.(crosschainlisten.FailedUnlockHandle)
+	if failedDstDao == nil {
+		failedUnlockHandle = nil
+	}
+	var query crosschaindao.EventsQuery
+	progress := &backfillProgress{ChainId: chainId, Start: start, End: end, Next: start}
+	progressFile := ctx.String("progress")
+	if progressFile == "" {
+		progressFile = fmt.Sprintf("backfill_%d_%d_%d.json", chainId, start, end)
+	}
+	if dryRun {
+		eventsQuery, ok := dao.(crosschaindao.EventsQuery)
+		if !ok {
+			return fmt.Errorf("dao %s can not read back events for dry run", dao.Name())
+		}
+		query = eventsQuery
+	} else {
+		var err error
+		progress, err = loadBackfillProgress(progressFile, chainId, start, end)
+		if err != nil {
+			return err
+		}
+		if progress.Next > end {
+			logs.Info("backfill chain %d blocks %d-%d is already done", chainId, start, end)
+			return nil
+		}
+	}
+	logs.Info("backfill chain %d blocks %d-%d from %d, batch: %d, concurrency: %d, dry run: %v", chainId, start, end, progress.Next, batch, concurrency, dryRun)
+
+	exit := make(chan struct{})
+	var once sync.Once
+	stop := func() {
+		once.Do(func() { close(exit) })
+	}
+	defer stop()
+	sc := make(chan os.Signal, 1)
+	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sc)
+	go func() {
+		select {
+		case sig := <-sc:
+			logs.Info("backfill received signal:(%s).", sig.String())
+			stop()
+		case <-exit:
+		}
+	}()
+
+	// results are written in height order so that the progress file never skips a range
+	futures := make(chan chan *backfillBlocks, concurrency)
+	go func() {
+		defer close(futures)
+		for next := progress.Next; next <= end; next += batch {
+			last := next + batch - 1
+			if last > end {
+				last = end
+			}
+			future := make(chan *backfillBlocks, 1)
+			select {
+			case futures <- future:
+			case <-exit:
+				return
+			}
+			go func(start uint64, end uint64) {
+				future <- fetchBackfillBlocks(handle, failedUnlockHandle, start, end)
+			}(next, last)
+		}
+	}()
+	summary := newBackfillSummary()
+	for future := range futures {
+		var blocks *backfillBlocks
+		select {
+		case blocks = <-future:
+		case <-exit:
+			return fmt.Errorf("backfill chain %d interrupted before block %d, run again to resume", chainId, progress.Next)
+		}
+		if blocks.err != nil {
+			return fmt.Errorf("backfill chain %d stopped at block %d: %v", chainId, blocks.start, blocks.err)
+		}
+		if dryRun {
+			if err := previewBackfillBlocks(query, blocks, summary); err != nil {
+				return err
+			}
+		} else {
+			var err error
+			if failedDstDao != nil {
+				err = failedDstDao.UpdateEventsWithFailedDst(nil, blocks.wrapperTransactions, blocks.srcTransactions, blocks.polyTransactions, blocks.dstTransactions, blocks.failedDstTransactions)
+			} else {
+				err = dao.UpdateEvents(nil, blocks.wrapperTransactions, blocks.srcTransactions, blocks.polyTransactions, blocks.dstTransactions)
+			}
+			if err != nil {
+				return fmt.Errorf("backfill chain %d UpdateEvents on blocks %d-%d err: %v", chainId, blocks.start, blocks.end, err)
+			}
+			progress.Next = blocks.end + 1
+			if err := progress.save(progressFile); err != nil {
+				return err
+			}
+		}
+		logs.Info("backfill chain %d blocks %d-%d, wrapper %d src %d poly %d dst %d failed dst %d", chainId, blocks.start, blocks.end,
+			len(blocks.wrapperTransactions), len(blocks.srcTransactions), len(blocks.polyTransactions), len(blocks.dstTransactions), len(blocks.failedDstTransactions))
+	}
+	select {
+	case <-exit:
+		return fmt.Errorf("backfill chain %d interrupted before block %d, run again to resume", chainId, progress.Next)
+	default:
+	}
+	if dryRun {
+		summary.print()
+	}
+	logs.Info("backfill chain %d blocks %d-%d done", chainId, start, end)
+	return nil
+}
+
+type backfillSummary struct {
+	kinds     []string
+	inserted  map[string]int
+	changed   map[string]int
+	unchanged map[string]int
+	failed    int
+}
+
+func newBackfillSummary() *backfillSummary {
+	return &backfillSummary{
+		kinds:     []string{"wrapper", "src", "poly", "dst"},
+		inserted:  make(map[string]int),
+		changed:   make(map[string]int),
+		unchanged: make(map[string]int),
+	}
+}
+
+func (summary *backfillSummary) print() {
+	for _, kind := range summary.kinds {
+		fmt.Printf("%s: %d to insert, %d to change, %d unchanged\n", kind, summary.inserted[kind], summary.changed[kind], summary.unchanged[kind])
+	}
+	fmt.Printf("failed dst: %d to upsert\n", summary.failed)
+}
+
+// add prints the row if re-indexing would insert or change it.
+func (summary *backfillSummary) add(kind string, hash string, old interface{}, new interface{}, ignore ...string) {
+	if reflect.ValueOf(old).IsNil() {
+		summary.inserted[kind]++
+		fmt.Printf("insert %s %s\n", kind, hash)
+		return
+	}
+	ignored := make(map[string]bool)
+	for _, name := range ignore {
+		ignored[name] = true
+	}
+	changes := diffFields("", reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem(), ignored)
+	if len(changes) == 0 {
+		summary.unchanged[kind]++
+		return
+	}
+	summary.changed[kind]++
+	fmt.Printf("change %s %s\n", kind, hash)
+	for _, change := range changes {
+		fmt.Printf("    %s\n", change)
+	}
+}
+
+var bigIntType = reflect.TypeOf(&models.BigInt{})
+
+func bigIntString(value reflect.Value) string {
+	if value.IsNil() {
+		return "null"
+	}
+	return value.Interface().(*models.BigInt).String()
+}
+
+func diffFields(prefix string, old reflect.Value, new reflect.Value, ignored map[string]bool) []string {
+	changes := make([]string, 0)
+	for i := 0; i < new.NumField(); i++ {
+		field := new.Type().Field(i)
+		if ignored[field.Name] {
+			continue
+		}
+		name := prefix + field.Name
+		oldValue, newValue := old.Field(i), new.Field(i)
+		if field.Type == bigIntType {
+			if oldAmount, newAmount := bigIntString(oldValue), bigIntString(newValue); oldAmount != newAmount {
+				changes = append(changes, fmt.Sprintf("%s: %s -> %s", name, oldAmount, newAmount))
+			}
+			continue
+		}
+		if field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct {
+			// associations are only saved when present
+			if newValue.IsNil() {
+				continue
+			}
+			if oldValue.IsNil() {
+				changes = append(changes, fmt.Sprintf("%s: inserted", name))
+				continue
+			}
+			changes = append(changes, diffFields(name+".", oldValue.Elem(), newValue.Elem(), ignored)...)
+			continue
+		}
+		if !reflect.DeepEqual(oldValue.Interface(), newValue.Interface()) {
+			changes = append(changes, fmt.Sprintf("%s: %v -> %v", name, reflect.Indirect(oldValue).Interface(), reflect.Indirect(newValue).Interface()))
+		}
+	}
+	return changes
+}
+
+func previewBackfillBlocks(query crosschaindao.EventsQuery, blocks *backfillBlocks, summary *backfillSummary) error {
+	wrapperHashes := make([]string, 0, len(blocks.wrapperTransactions))
+	for _, tx := range blocks.wrapperTransactions {
+		wrapperHashes = append(wrapperHashes, tx.Hash)
+	}
+	srcHashes := make([]string, 0, len(blocks.srcTransactions))
+	for _, tx := range blocks.srcTransactions {
+		srcHashes = append(srcHashes, tx.Hash)
+	}
+	polyHashes := make([]string, 0, len(blocks.polyTransactions))
+	for _, tx := range blocks.polyTransactions {
+		polyHashes = append(polyHashes, tx.Hash)
+	}
+	dstHashes := make([]string, 0, len(blocks.dstTransactions))
+	for _, tx := range blocks.dstTransactions {
+		dstHashes = append(dstHashes, tx.Hash)
+	}
+	oldWrapperTransactions, oldSrcTransactions, oldPolyTransactions, oldDstTransactions, err := query.GetEvents(wrapperHashes, srcHashes, polyHashes, dstHashes)
+	if err != nil {
+		return fmt.Errorf("get events of blocks %d-%d err: %v", blocks.start, blocks.end, err)
+	}
+	wrappers := make(map[string]*models.WrapperTransaction)
+	for _, tx := range oldWrapperTransactions {
+		wrappers[tx.Hash] = tx
+	}
+	for _, tx := range blocks.wrapperTransactions {
+		// the status is kept by the upsert
+		summary.add("wrapper", tx.Hash, wrappers[tx.Hash], tx, "Status")
+	}
+	srcs := make(map[string]*models.SrcTransaction)
+	for _, tx := range oldSrcTransactions {
+		srcs[tx.Hash] = tx
+	}
+	for _, tx := range blocks.srcTransactions {
+		summary.add("src", tx.Hash, srcs[tx.Hash], tx)
+	}
+	polys := make(map[string]*models.PolyTransaction)
+	for _, tx := range oldPolyTransactions {
+		polys[tx.Hash] = tx
+	}
+	for _, tx := range blocks.polyTransactions {
+		summary.add("poly", tx.Hash, polys[tx.Hash], tx)
+	}
+	dsts := make(map[string]*models.DstTransaction)
+	for _, tx := range oldDstTransactions {
+		dsts[tx.Hash] = tx
+	}
+	for _, tx := range blocks.dstTransactions {
+		summary.add("dst", tx.Hash, dsts[tx.Hash], tx)
+	}
+	// the reverted executions are upserted as a whole
+	for _, tx := range blocks.failedDstTransactions {
+		summary.failed++
+		fmt.Printf("upsert failed dst %s\n", tx.Hash)
+	}
+	return nil
+}
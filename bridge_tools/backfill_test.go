@@ -0,0 +1,72 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"poly-bridge/models"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackfillProgress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backfill")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "progress.json")
+
+	progress, err := loadBackfillProgress(path, 2, 100, 200)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(100), progress.Next)
+	progress.Next = 150
+	assert.Nil(t, progress.save(path))
+
+	progress, err = loadBackfillProgress(path, 2, 100, 200)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(150), progress.Next)
+
+	_, err = loadBackfillProgress(path, 2, 100, 300)
+	assert.NotNil(t, err)
+}
+
+func TestDiffFields(t *testing.T) {
+	old := &models.SrcTransaction{Hash: "a", Height: 1, Fee: models.NewBigIntFromInt(10)}
+	new := &models.SrcTransaction{Hash: "a", Height: 2, Fee: models.NewBigIntFromInt(10), SrcTransfer: &models.SrcTransfer{TxHash: "a"}}
+	changes := diffFields("", reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem(), nil)
+	assert.Equal(t, []string{"Height: 1 -> 2", "SrcTransfer: inserted"}, changes)
+
+	old.SrcTransfer = &models.SrcTransfer{TxHash: "a", Amount: models.NewBigIntFromInt(1)}
+	new.SrcTransfer.Amount = models.NewBigIntFromInt(2)
+	changes = diffFields("", reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem(), map[string]bool{"Height": true})
+	assert.Equal(t, []string{"SrcTransfer.Amount: 1 -> 2"}, changes)
+}
+
+func TestBackfillSummary(t *testing.T) {
+	summary := newBackfillSummary()
+	var missing *models.WrapperTransaction
+	summary.add("wrapper", "a", missing, &models.WrapperTransaction{Hash: "a"})
+	summary.add("wrapper", "b", &models.WrapperTransaction{Hash: "b", Status: 1}, &models.WrapperTransaction{Hash: "b"}, "Status")
+	summary.add("wrapper", "c", &models.WrapperTransaction{Hash: "c", Time: 1}, &models.WrapperTransaction{Hash: "c", Time: 2})
+	assert.Equal(t, 1, summary.inserted["wrapper"])
+	assert.Equal(t, 1, summary.unchanged["wrapper"])
+	assert.Equal(t, 1, summary.changed["wrapper"])
+}
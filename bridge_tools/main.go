@@ -80,7 +80,9 @@ func setupApp() *cli.App {
 		cmdFlag,
 		methodFlag,
 	}
-	app.Commands = []cli.Command{}
+	app.Commands = []cli.Command{
+		backfillCommand,
+	}
 	app.Before = func(context *cli.Context) error {
 		runtime.GOMAXPROCS(runtime.NumCPU())
 		return nil
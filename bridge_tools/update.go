@@ -39,8 +39,8 @@ func startUpdate(cfg *conf.UpdateConfig) {
 	if err != nil {
 		panic(err)
 	}
-	err = db.Debug().AutoMigrate(&models.Chain{}, &models.WrapperTransaction{}, &models.ChainFee{}, &models.TokenBasic{}, &models.Token{}, &models.PriceMarket{},
-		&models.TokenMap{}, &models.SrcTransaction{}, &models.SrcTransfer{}, &models.PolyTransaction{}, &models.DstTransaction{}, &models.DstTransfer{},
+	err = db.Debug().AutoMigrate(&models.Chain{}, &models.WrapperTransaction{}, &models.ChainFee{}, &models.AssetGasLimit{}, &models.AssetGasLimitHistory{}, &models.ChainFeeHistory{}, &models.FeePolicy{}, &models.FeeQuote{}, &models.TokenBasic{}, &models.Token{}, &models.TokenProxyAmount{}, &models.PriceMarket{},
+		&models.TokenMap{}, &models.SrcTransaction{}, &models.SrcTransfer{}, &models.MakeTxParam{}, &models.PolyTransaction{}, &models.DstTransaction{}, &models.DstTransfer{}, &models.FailedDstTransaction{},
 		&models.NFTProfile{}, &models.TimeStatistic{})
 	if err != nil {
 		panic(err)
@@ -38,8 +38,8 @@ func startUpdateToken(cfg *conf.DeployConfig) {
 	if err != nil {
 		panic(err)
 	}
-	err = db.AutoMigrate(&models.Chain{}, &models.WrapperTransaction{}, &models.ChainFee{}, &models.TokenBasic{}, &models.Token{}, &models.PriceMarket{},
-		&models.TokenMap{}, &models.SrcTransaction{}, &models.SrcTransfer{}, &models.PolyTransaction{}, &models.DstTransaction{}, &models.DstTransfer{})
+	err = db.AutoMigrate(&models.Chain{}, &models.WrapperTransaction{}, &models.ChainFee{}, &models.AssetGasLimit{}, &models.AssetGasLimitHistory{}, &models.ChainFeeHistory{}, &models.FeePolicy{}, &models.FeeQuote{}, &models.TokenBasic{}, &models.Token{}, &models.TokenProxyAmount{}, &models.PriceMarket{},
+		&models.TokenMap{}, &models.SrcTransaction{}, &models.SrcTransfer{}, &models.MakeTxParam{}, &models.PolyTransaction{}, &models.DstTransaction{}, &models.DstTransfer{}, &models.FailedDstTransaction{})
 	if err != nil {
 		panic(err)
 	}
@@ -51,6 +51,7 @@ type CheckFeeRsp struct {
 	PayState    int    `json:"PayState"`
 	Amount      string `json:"Amount"`
 	MinProxyFee string `json:"MinProxyFee"`
+	QuoteId     string `json:"QuoteId"`
 	Error       string `json:"Error"`
 }
 
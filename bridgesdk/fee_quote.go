@@ -0,0 +1,79 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bridgesdk
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"poly-bridge/models"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// FeeQuote is the signed fee quote returned by getfee
+type FeeQuote struct {
+	Id         string `json:"Id"`
+	SrcChainId uint64 `json:"SrcChainId"`
+	Hash       string `json:"Hash"`
+	Asset      string `json:"Asset"`
+	DstChainId uint64 `json:"DstChainId"`
+	ServerId   uint64 `json:"ServerId"`
+	User       string `json:"User"`
+	Amount     string `json:"Amount"`
+	Time       int64  `json:"Time"`
+	Expiry     int64  `json:"Expiry"`
+	Signature  string `json:"Signature"`
+}
+
+// Digest is the keccak256 hash of the quote signed by the bridge server
+func (quote *FeeQuote) Digest() []byte {
+	return models.FeeQuoteDigest(quote.SrcChainId, quote.Hash, quote.Asset, quote.DstChainId, quote.ServerId, quote.User, quote.Amount, quote.Time, quote.Expiry)
+}
+
+// VerifyFeeQuote checks the quote is signed by the signer, which is the address of the fee quote key of the
+// bridge server, and is not expired at the time
+func VerifyFeeQuote(quote *FeeQuote, signer string, now int64) error {
+	if quote == nil {
+		return fmt.Errorf("no fee quote")
+	}
+	if _, ok := new(big.Int).SetString(quote.Amount, 10); !ok {
+		return fmt.Errorf("fee quote amount: %s is not valid", quote.Amount)
+	}
+	digest := quote.Digest()
+	if !strings.EqualFold(quote.Id, hex.EncodeToString(digest)) {
+		return fmt.Errorf("fee quote id: %s does not match the quote", quote.Id)
+	}
+	signature, err := hex.DecodeString(quote.Signature)
+	if err != nil {
+		return fmt.Errorf("fee quote signature is not valid: %v", err)
+	}
+	publicKey, err := crypto.SigToPub(digest, signature)
+	if err != nil {
+		return fmt.Errorf("fee quote signature is not valid: %v", err)
+	}
+	if address := crypto.PubkeyToAddress(*publicKey); address != common.HexToAddress(signer) {
+		return fmt.Errorf("fee quote is signed by %s, not %s", address.Hex(), signer)
+	}
+	if now > quote.Expiry {
+		return fmt.Errorf("fee quote expired at %d", quote.Expiry)
+	}
+	return nil
+}
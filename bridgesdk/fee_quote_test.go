@@ -0,0 +1,72 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bridgesdk
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"poly-bridge/models"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func signedFeeQuote(t *testing.T) (*FeeQuote, string) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	quote := &models.FeeQuote{
+		SrcChainId: 6,
+		Hash:       "0000000000000000000000000000000000000000",
+		Asset:      "55d398326f99059ff775485246999027b3197955",
+		DstChainId: 2,
+		ServerId:   1,
+		User:       "2c7536e3605d9c16a7a3d7b1898e529396a65c23",
+		Amount:     models.NewBigInt(big.NewInt(12000000000000000)),
+		Time:       1640995200,
+		Expiry:     1640995800,
+	}
+	assert.NoError(t, quote.Sign(key))
+	// the quote is verified as it is returned by getfee
+	data, err := json.Marshal(models.MakeFeeQuoteRsp(quote))
+	assert.NoError(t, err)
+	feeQuote := new(FeeQuote)
+	assert.NoError(t, json.Unmarshal(data, feeQuote))
+	return feeQuote, crypto.PubkeyToAddress(key.PublicKey).Hex()
+}
+
+func TestVerifyFeeQuote(t *testing.T) {
+	quote, signer := signedFeeQuote(t)
+	assert.NoError(t, VerifyFeeQuote(quote, signer, quote.Time))
+	assert.NoError(t, VerifyFeeQuote(quote, signer, quote.Expiry))
+	assert.Error(t, VerifyFeeQuote(quote, signer, quote.Expiry+1))
+	assert.Error(t, VerifyFeeQuote(quote, "0x0000000000000000000000000000000000000001", quote.Time))
+	assert.Error(t, VerifyFeeQuote(nil, signer, quote.Time))
+
+	quote.Amount = "1"
+	assert.Error(t, VerifyFeeQuote(quote, signer, quote.Time))
+
+	quote, signer = signedFeeQuote(t)
+	quote.Asset = "0000000000000000000000000000000000000000"
+	assert.Error(t, VerifyFeeQuote(quote, signer, quote.Time))
+
+	quote, signer = signedFeeQuote(t)
+	quote.User = "0000000000000000000000000000000000000001"
+	assert.Error(t, VerifyFeeQuote(quote, signer, quote.Time))
+}
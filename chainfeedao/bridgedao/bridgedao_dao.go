@@ -25,6 +25,7 @@ import (
 	"poly-bridge/basedef"
 	"poly-bridge/conf"
 	"poly-bridge/models"
+	"strings"
 )
 
 type BridgeDao struct {
@@ -70,6 +71,108 @@ func (dao *BridgeDao) SaveFees(fees []*models.ChainFee) error {
 	return nil
 }
 
+// GetDstGasUsed returns the gas used by the latest successful destination transactions of the chain since the time,
+// grouped by the transferred assets with the latest first
+func (dao *BridgeDao) GetDstGasUsed(chainId uint64, since int64, limit int) (map[string][]uint64, error) {
+	rows := make([]*struct {
+		Asset   string
+		GasUsed uint64
+	}, 0)
+	res := dao.db.Table("dst_transactions").
+		Select("dst_transfers.asset as asset, dst_transactions.gas_used as gas_used").
+		Joins("inner join dst_transfers on dst_transfers.tx_hash = dst_transactions.hash").
+		Where("dst_transactions.chain_id = ? and dst_transactions.state = 1 and dst_transactions.gas_used > 0 and dst_transactions.time > ?", chainId, since).
+		Order("dst_transactions.time desc").Limit(limit).Find(&rows)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	gasUsed := make(map[string][]uint64)
+	for _, row := range rows {
+		asset := strings.ToLower(row.Asset)
+		gasUsed[asset] = append(gasUsed[asset], row.GasUsed)
+	}
+	return gasUsed, nil
+}
+
+func (dao *BridgeDao) GetTokens(chainId uint64) ([]*models.Token, error) {
+	tokens := make([]*models.Token, 0)
+	res := dao.db.Where("chain_id = ?", chainId).Find(&tokens)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	return tokens, nil
+}
+
+func (dao *BridgeDao) SaveAssetGasLimits(limits []*models.AssetGasLimit) error {
+	if len(limits) > 0 {
+		res := dao.db.Save(limits)
+		if res.Error != nil {
+			return res.Error
+		}
+	}
+	return nil
+}
+
+func (dao *BridgeDao) GetTokenPrices(names []string) (map[string]int64, error) {
+	tokenBasics := make([]*models.TokenBasic, 0)
+	res := dao.db.Where("name in ?", names).Find(&tokenBasics)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	prices := make(map[string]int64)
+	for _, tokenBasic := range tokenBasics {
+		prices[tokenBasic.Name] = tokenBasic.Price
+	}
+	return prices, nil
+}
+
+func (dao *BridgeDao) SaveFeeHistories(histories []*models.ChainFeeHistory) error {
+	if len(histories) > 0 {
+		res := dao.db.Save(histories)
+		if res.Error != nil {
+			return res.Error
+		}
+	}
+	return nil
+}
+
+// DeleteFeeHistories deletes at most limit fee histories older than the time
+func (dao *BridgeDao) DeleteFeeHistories(before int64, limit int) (int64, error) {
+	res := dao.db.Exec("delete from chain_fee_histories where time < ? limit ?", before, limit)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	return res.RowsAffected, nil
+}
+
+func (dao *BridgeDao) SaveAssetGasLimitHistories(histories []*models.AssetGasLimitHistory) error {
+	if len(histories) > 0 {
+		res := dao.db.Save(histories)
+		if res.Error != nil {
+			return res.Error
+		}
+	}
+	return nil
+}
+
+// DeleteAssetGasLimitHistories deletes at most limit asset gas limit histories older than the time
+func (dao *BridgeDao) DeleteAssetGasLimitHistories(before int64, limit int) (int64, error) {
+	res := dao.db.Exec("delete from asset_gas_limit_histories where time < ? limit ?", before, limit)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	return res.RowsAffected, nil
+}
+
+// DeleteFeeQuotes deletes at most limit fee quotes expired before the time
+func (dao *BridgeDao) DeleteFeeQuotes(before int64, limit int) (int64, error) {
+	res := dao.db.Exec("delete from fee_quotes where expiry < ? limit ?", before, limit)
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	return res.RowsAffected, nil
+}
+
 func (dao *BridgeDao) Name() string {
 	return basedef.SERVER_POLY_BRIDGE
 }
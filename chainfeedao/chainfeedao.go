@@ -32,6 +32,27 @@ type ChainFeeDao interface {
 	Name() string
 }
 
+// AssetFeeDao is implemented by the daos which learn the gas limits of the assets from the indexed transactions
+type AssetFeeDao interface {
+	GetDstGasUsed(chainId uint64, since int64, limit int) (map[string][]uint64, error)
+	GetTokens(chainId uint64) ([]*models.Token, error)
+	SaveAssetGasLimits(limits []*models.AssetGasLimit) error
+}
+
+// FeeHistoryDao is implemented by the daos which keep the histories of the chain fees
+type FeeHistoryDao interface {
+	GetTokenPrices(names []string) (map[string]int64, error)
+	SaveFeeHistories(histories []*models.ChainFeeHistory) error
+	DeleteFeeHistories(before int64, limit int) (int64, error)
+	SaveAssetGasLimitHistories(histories []*models.AssetGasLimitHistory) error
+	DeleteAssetGasLimitHistories(before int64, limit int) (int64, error)
+}
+
+// FeeQuoteDao is implemented by the daos which keep the fee quotes of the http server
+type FeeQuoteDao interface {
+	DeleteFeeQuotes(before int64, limit int) (int64, error)
+}
+
 func NewChainFeeDao(server string, dbCfg *conf.DBConfig) ChainFeeDao {
 	if server == basedef.SERVER_POLY_BRIDGE {
 		return bridgedao.NewBridgeDao(dbCfg)
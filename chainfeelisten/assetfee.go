@@ -0,0 +1,130 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package chainfeelisten
+
+import (
+	"poly-bridge/chainfeedao"
+	"poly-bridge/models"
+	"sort"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+)
+
+const (
+	_gas_used_period  = 7 * 24 * 3600 // destination transactions of the latest week are learned
+	_gas_used_limit   = 5000          // latest destination transactions of a chain read at most
+	_gas_used_samples = 100           // latest transactions of an asset learned at most
+	_gas_min_samples  = 3             // the gas limit of the asset is estimated with fewer transactions
+	_gas_percentile   = 90
+	_gas_margin       = 10 // percent added to the learned gas used
+)
+
+// GasLimitEstimator is implemented by the chain fees whose gas limit is set per asset
+type GasLimitEstimator interface {
+	GetGasLimit() int64
+	EstimateGasLimit(asset string) (uint64, error)
+}
+
+// updateAssetGasLimits learns the gas limits of the assets on the chains from the gas used by the latest
+// destination transactions, the gas limit of an asset without enough transactions is estimated
+func (fl *FeeListen) updateAssetGasLimits() {
+	dao, ok := fl.db.(chainfeedao.AssetFeeDao)
+	if !ok {
+		return
+	}
+	for chainId, query := range fl.fees {
+		estimator, ok := query.(GasLimitEstimator)
+		if !ok || estimator.GetGasLimit() <= 0 {
+			continue
+		}
+		limits, err := assetGasLimits(chainId, estimator, dao)
+		if err != nil {
+			logs.Error("get asset gas limits of chain: %d err: %v", chainId, err)
+			continue
+		}
+		err = dao.SaveAssetGasLimits(limits)
+		if err != nil {
+			logs.Error("save asset gas limits of chain: %d err: %v", chainId, err)
+			continue
+		}
+		fl.saveAssetGasLimitHistories(limits)
+	}
+}
+
+func assetGasLimits(chainId uint64, estimator GasLimitEstimator, dao chainfeedao.AssetFeeDao) ([]*models.AssetGasLimit, error) {
+	now := time.Now().Unix()
+	gasUsed, err := dao.GetDstGasUsed(chainId, now-_gas_used_period, _gas_used_limit)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := dao.GetTokens(chainId)
+	if err != nil {
+		return nil, err
+	}
+	standards := make(map[string]uint8)
+	for _, token := range tokens {
+		standards[token.Hash] = token.Standard
+	}
+	for asset := range gasUsed {
+		if _, ok := standards[asset]; !ok {
+			standards[asset] = models.TokenTypeErc20
+		}
+	}
+	chainGasLimit := uint64(estimator.GetGasLimit())
+	limits := make([]*models.AssetGasLimit, 0)
+	for asset, standard := range standards {
+		gasLimit, samples := learnGasLimit(gasUsed[asset])
+		if samples == 0 {
+			if standard != models.TokenTypeErc20 {
+				continue
+			}
+			gasLimit, err = estimator.EstimateGasLimit(asset)
+			if err != nil {
+				logs.Warn("estimate gas limit of asset: %s on chain: %d err: %v", asset, chainId, err)
+				continue
+			}
+		}
+		limits = append(limits, &models.AssetGasLimit{
+			ChainId:       chainId,
+			Hash:          asset,
+			GasLimit:      gasLimit,
+			ChainGasLimit: chainGasLimit,
+			Samples:       samples,
+			Time:          now,
+		})
+	}
+	return limits, nil
+}
+
+// learnGasLimit is the percentile of the gas used by the latest transactions plus a margin, no gas limit
+// is learned from fewer than _gas_min_samples transactions
+func learnGasLimit(gasUsed []uint64) (uint64, uint64) {
+	if len(gasUsed) < _gas_min_samples {
+		return 0, 0
+	}
+	if len(gasUsed) > _gas_used_samples {
+		gasUsed = gasUsed[:_gas_used_samples]
+	}
+	sorted := make([]uint64, len(gasUsed))
+	copy(sorted, gasUsed)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	index := (len(sorted)*_gas_percentile+99)/100 - 1
+	gasLimit := sorted[index] * (100 + _gas_margin) / 100
+	return gasLimit, uint64(len(sorted))
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package chainfeelisten
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"poly-bridge/basedef"
+	"poly-bridge/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testFeeDao struct {
+	fees           []*models.ChainFee
+	gasUsed        map[string][]uint64
+	tokens         []*models.Token
+	limits         []*models.AssetGasLimit
+	prices         map[string]int64
+	histories      []*models.ChainFeeHistory
+	limitHistories []*models.AssetGasLimitHistory
+	quotes         []int64
+}
+
+func (dao *testFeeDao) GetFees() ([]*models.ChainFee, error) { return dao.fees, nil }
+func (dao *testFeeDao) SaveFees(fees []*models.ChainFee) error {
+	dao.fees = fees
+	return nil
+}
+func (dao *testFeeDao) Name() string { return "test" }
+func (dao *testFeeDao) GetDstGasUsed(chainId uint64, since int64, limit int) (map[string][]uint64, error) {
+	return dao.gasUsed, nil
+}
+func (dao *testFeeDao) GetTokens(chainId uint64) ([]*models.Token, error) { return dao.tokens, nil }
+func (dao *testFeeDao) SaveAssetGasLimits(limits []*models.AssetGasLimit) error {
+	dao.limits = append(dao.limits, limits...)
+	return nil
+}
+
+type testChainFee struct {
+	gasLimit  int64
+	estimates map[string]uint64
+}
+
+func (fee *testChainFee) GetFee() (*big.Int, *big.Int, *big.Int, error) {
+	return big.NewInt(1000), big.NewInt(5000), big.NewInt(6000), nil
+}
+func (fee *testChainFee) GetChainId() uint64 { return basedef.ETHEREUM_CROSSCHAIN_ID }
+func (fee *testChainFee) Name() string       { return "test" }
+func (fee *testChainFee) GetGasLimit() int64 { return fee.gasLimit }
+func (fee *testChainFee) EstimateGasLimit(asset string) (uint64, error) {
+	gasLimit, ok := fee.estimates[asset]
+	if !ok {
+		return 0, fmt.Errorf("execution reverted")
+	}
+	return gasLimit, nil
+}
+
+func TestLearnGasLimit(t *testing.T) {
+	gasLimit, samples := learnGasLimit([]uint64{100000, 120000})
+	assert.Equal(t, uint64(0), gasLimit)
+	assert.Equal(t, uint64(0), samples)
+
+	gasUsed := make([]uint64, 0)
+	for i := uint64(1); i <= 10; i++ {
+		gasUsed = append(gasUsed, i*10000)
+	}
+	// the 90th percentile of 10 transactions is the 9th, plus 10 percent
+	gasLimit, samples = learnGasLimit(gasUsed)
+	assert.Equal(t, uint64(99000), gasLimit)
+	assert.Equal(t, uint64(10), samples)
+	assert.Equal(t, uint64(10000), gasUsed[0])
+
+	// only the latest transactions are learned
+	for i := 0; i < _gas_used_samples; i++ {
+		gasUsed = append([]uint64{50000}, gasUsed...)
+	}
+	gasLimit, samples = learnGasLimit(gasUsed)
+	assert.Equal(t, uint64(55000), gasLimit)
+	assert.Equal(t, uint64(_gas_used_samples), samples)
+}
+
+func TestFeeListen_AssetGasLimits(t *testing.T) {
+	dao := &testFeeDao{
+		fees: []*models.ChainFee{{ChainId: basedef.ETHEREUM_CROSSCHAIN_ID}},
+		gasUsed: map[string][]uint64{
+			"0000000000000000000000000000000000000001": {200000, 210000, 190000},
+			"0000000000000000000000000000000000000002": {150000},
+		},
+		tokens: []*models.Token{
+			{Hash: "0000000000000000000000000000000000000002", Standard: models.TokenTypeErc20},
+			{Hash: "0000000000000000000000000000000000000003", Standard: models.TokenTypeErc20},
+			{Hash: "0000000000000000000000000000000000000004", Standard: models.TokenTypeErc721},
+		},
+	}
+	fee := &testChainFee{gasLimit: 100000, estimates: map[string]uint64{"0000000000000000000000000000000000000002": 120000}}
+	NewFeeListen(1, []ChainFee{fee}, dao)
+
+	limits := make(map[string]*models.AssetGasLimit)
+	for _, limit := range dao.limits {
+		limits[limit.Hash] = limit
+	}
+	// learned, estimated with too few transactions, failed to estimate and nft
+	assert.Equal(t, 2, len(limits))
+	assert.Equal(t, uint64(231000), limits["0000000000000000000000000000000000000001"].GasLimit)
+	assert.Equal(t, uint64(3), limits["0000000000000000000000000000000000000001"].Samples)
+	assert.Equal(t, uint64(120000), limits["0000000000000000000000000000000000000002"].GasLimit)
+	assert.Equal(t, uint64(0), limits["0000000000000000000000000000000000000002"].Samples)
+
+	proxyFee := limits["0000000000000000000000000000000000000002"].ScaleFee(dao.fees[0].ProxyFee)
+	assert.Equal(t, "7200", proxyFee.String())
+	// every update of the gas limits is kept in the histories
+	assert.Equal(t, 2, len(dao.limitHistories))
+	for _, history := range dao.limitHistories {
+		assert.Equal(t, limits[history.Hash].GasLimit, history.GasLimit)
+		assert.Equal(t, limits[history.Hash].Time, history.Time)
+	}
+}
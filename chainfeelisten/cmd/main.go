@@ -104,7 +104,7 @@ func startServer(ctx *cli.Context) {
 		conf, _ := json.Marshal(config)
 		logs.Info("%s\n", string(conf))
 	}
-	chainfeelisten.StartFeeListen(config.Server, config.FeeUpdateSlot, config.FeeListenConfig, config.DBConfig)
+	chainfeelisten.StartFeeListen(config.Server, config.FeeUpdateSlot, config.FeeHistoryDays, config.FeeListenConfig, config.DBConfig)
 }
 
 func waitSignal() os.Signal {
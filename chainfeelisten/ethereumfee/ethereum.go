@@ -18,15 +18,37 @@
 package ethereumfee
 
 import (
+	"fmt"
 	"math/big"
 	"poly-bridge/basedef"
 	"poly-bridge/chainsdk"
 	"poly-bridge/conf"
+	"sort"
+	"strings"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/polynetwork/eth-contracts/go_abi/erc20_abi"
+)
+
+const (
+	DEFAULT_FEE_HISTORY_BLOCKS      = 20
+	DEFAULT_PRIORITY_FEE_PERCENTILE = 50
+	DEFAULT_BASE_FEE_BLOCKS         = 3
+	DEFAULT_FEE_WINDOW              = 5
+	DEFAULT_TRANSFER_GAS            = 35000 // gas of the token transfer included in GasLimit
 )
 
+// the recipient of the simulated transfers, it holds the balance of most tokens like a recipient of the bridge
+var _estimate_recipient = common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+
 type EthereumFee struct {
-	ethCfg *conf.FeeListenConfig
-	ethSdk *chainsdk.EthereumSdkPro
+	ethCfg  *conf.FeeListenConfig
+	ethSdk  *chainsdk.EthereumSdkPro
+	samples []*big.Int
 }
 
 func NewEthereumFee(ethCfg *conf.FeeListenConfig, feeUpdateSlot int64) *EthereumFee {
@@ -40,10 +62,11 @@ func NewEthereumFee(ethCfg *conf.FeeListenConfig, feeUpdateSlot int64) *Ethereum
 }
 
 func (this *EthereumFee) GetFee() (*big.Int, *big.Int, *big.Int, error) {
-	gasPrice, err := this.ethSdk.SuggestGasPrice()
+	gasPrice, err := this.getGasPrice()
 	if err != nil {
 		return nil, nil, nil, err
 	}
+	gasPrice = this.smooth(gasPrice)
 	gasPrice = new(big.Int).Mul(gasPrice, big.NewInt(basedef.FEE_PRECISION))
 	gasPrice = new(big.Int).Mul(gasPrice, big.NewInt(this.ethCfg.GasLimit))
 	proxyFee := new(big.Int).Mul(gasPrice, new(big.Int).SetInt64(this.ethCfg.ProxyFee))
@@ -60,3 +83,125 @@ func (this *EthereumFee) GetChainId() uint64 {
 func (this *EthereumFee) Name() string {
 	return this.ethCfg.ChainName
 }
+
+func (this *EthereumFee) GetGasLimit() int64 {
+	return this.ethCfg.GasLimit
+}
+
+// EstimateGasLimit simulates the transfer of the asset from the lock proxy, the simulated gas replaces the
+// DEFAULT_TRANSFER_GAS in GasLimit. The native asset is transferred without a contract and keeps GasLimit.
+func (this *EthereumFee) EstimateGasLimit(asset string) (uint64, error) {
+	gasLimit := uint64(this.ethCfg.GasLimit)
+	token := common.HexToAddress(asset)
+	if token == (common.Address{}) {
+		return gasLimit, nil
+	}
+	if this.ethCfg.ProxyContract == "" {
+		return 0, fmt.Errorf("no proxy contract of chain: %d", this.ethCfg.ChainId)
+	}
+	erc20, err := abi.JSON(strings.NewReader(erc20_abi.ERC20ABI))
+	if err != nil {
+		return 0, err
+	}
+	data, err := erc20.Pack("transfer", _estimate_recipient, big.NewInt(1))
+	if err != nil {
+		return 0, err
+	}
+	gas, err := this.ethSdk.EstimateGas(ethereum.CallMsg{
+		From: common.HexToAddress(this.ethCfg.ProxyContract),
+		To:   &token,
+		Data: data,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("estimate transfer gas of asset: %s err: %v", asset, err)
+	}
+	// the intrinsic gas is paid once by the relay transaction
+	if gas > params.TxGas {
+		gas -= params.TxGas
+	}
+	if gasLimit > DEFAULT_TRANSFER_GAS {
+		gasLimit -= DEFAULT_TRANSFER_GAS
+	}
+	return gasLimit + gas, nil
+}
+
+// getGasPrice is the gas price the relayer pays on the chain, it is the legacy gas price on a chain
+// without eip-1559 or when the fee history can not be read
+func (this *EthereumFee) getGasPrice() (*big.Int, error) {
+	if !this.ethCfg.Legacy {
+		blocks := this.ethCfg.FeeHistoryBlocks
+		if blocks == 0 {
+			blocks = DEFAULT_FEE_HISTORY_BLOCKS
+		}
+		percentile := this.ethCfg.PriorityFeePercentile
+		if percentile <= 0 || percentile > 100 {
+			percentile = DEFAULT_PRIORITY_FEE_PERCENTILE
+		}
+		baseFeeBlocks := this.ethCfg.BaseFeeBlocks
+		if baseFeeBlocks == 0 {
+			baseFeeBlocks = DEFAULT_BASE_FEE_BLOCKS
+		}
+		history, err := this.ethSdk.FeeHistory(blocks, []float64{percentile})
+		if err == nil {
+			var gasPrice *big.Int
+			if gasPrice, err = relayGasPrice(history, baseFeeBlocks); err == nil {
+				return gasPrice, nil
+			}
+		}
+		logs.Debug("eip-1559 fee of chain: %s is not available, use the legacy gas price, err: %v", this.ethCfg.ChainName, err)
+	}
+	return this.ethSdk.SuggestGasPrice()
+}
+
+// relayGasPrice is the base fee of the next block raised by 12.5% for each of baseFeeBlocks blocks, which
+// is the most it can grow before the relay is mined, plus the median of the priority fees of the blocks
+func relayGasPrice(history *chainsdk.FeeHistory, baseFeeBlocks uint64) (*big.Int, error) {
+	if len(history.BaseFee) == 0 {
+		return nil, fmt.Errorf("there is no base fee")
+	}
+	baseFee := history.BaseFee[len(history.BaseFee)-1]
+	if baseFee == nil || baseFee.Sign() <= 0 {
+		return nil, fmt.Errorf("there is no base fee")
+	}
+	baseFee = new(big.Int).Set(baseFee)
+	for i := uint64(0); i < baseFeeBlocks; i++ {
+		baseFee.Add(baseFee, new(big.Int).Div(baseFee, big.NewInt(8)))
+	}
+	rewards := make([]*big.Int, 0, len(history.Reward))
+	for _, items := range history.Reward {
+		if len(items) > 0 && items[0] != nil {
+			rewards = append(rewards, items[0])
+		}
+	}
+	if len(rewards) == 0 {
+		return nil, fmt.Errorf("there is no priority fee")
+	}
+	return new(big.Int).Add(baseFee, median(rewards)), nil
+}
+
+// smooth adds the gas price to the window of the latest samples and returns their median, so that a
+// single spike does not set the fee
+func (this *EthereumFee) smooth(gasPrice *big.Int) *big.Int {
+	window := this.ethCfg.FeeWindow
+	if window <= 0 {
+		window = DEFAULT_FEE_WINDOW
+	}
+	this.samples = append(this.samples, gasPrice)
+	if len(this.samples) > window {
+		this.samples = this.samples[len(this.samples)-window:]
+	}
+	return median(this.samples)
+}
+
+func median(values []*big.Int) *big.Int {
+	sorted := append([]*big.Int{}, values...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Cmp(sorted[j]) < 0
+	})
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return new(big.Int).Set(sorted[middle])
+	}
+	sum := new(big.Int).Add(sorted[middle-1], sorted[middle])
+	return sum.Div(sum, big.NewInt(2))
+}
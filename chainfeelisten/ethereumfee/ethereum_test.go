@@ -0,0 +1,169 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ethereumfee
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"poly-bridge/basedef"
+	"poly-bridge/chainsdk"
+	"poly-bridge/conf"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func gwei(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1000000000))
+}
+
+func TestRelayGasPrice(t *testing.T) {
+	history := &chainsdk.FeeHistory{
+		BaseFee: []*big.Int{gwei(90), gwei(100), gwei(80)},
+		Reward:  [][]*big.Int{{gwei(1)}, {gwei(50)}, {gwei(2)}},
+	}
+	// 80 gwei grows to 90 and 101.25 gwei in two blocks, and the median priority fee is 2 gwei
+	gasPrice, err := relayGasPrice(history, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "103250000000", gasPrice.String())
+
+	_, err = relayGasPrice(&chainsdk.FeeHistory{BaseFee: []*big.Int{big.NewInt(0)}, Reward: history.Reward}, 2)
+	assert.Error(t, err)
+	_, err = relayGasPrice(&chainsdk.FeeHistory{BaseFee: history.BaseFee, Reward: [][]*big.Int{{}}}, 2)
+	assert.Error(t, err)
+}
+
+func TestEthereumFee_Smooth(t *testing.T) {
+	fee := &EthereumFee{ethCfg: &conf.FeeListenConfig{FeeWindow: 3}}
+	assert.Equal(t, gwei(10), fee.smooth(gwei(10)))
+	assert.Equal(t, gwei(11), fee.smooth(gwei(12)))
+	// a spike does not move the median
+	assert.Equal(t, gwei(12), fee.smooth(gwei(500)))
+	assert.Equal(t, gwei(13), fee.smooth(gwei(13)))
+	assert.Equal(t, 3, len(fee.samples))
+}
+
+const _revert_token = "0x0000000000000000000000000000000000000bad"
+
+func newFeeNode(t *testing.T, eip1559 bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := struct {
+			Id     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&call))
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": call.Id}
+		switch call.Method {
+		case "eth_blockNumber":
+			resp["result"] = "0x3e8"
+		case "eth_gasPrice":
+			resp["result"] = "0x4a817c800"
+		case "eth_estimateGas":
+			msg := struct {
+				To string `json:"to"`
+			}{}
+			assert.NoError(t, json.Unmarshal(call.Params[0], &msg))
+			if msg.To == _revert_token {
+				resp["error"] = map[string]interface{}{"code": 3, "message": "execution reverted"}
+			} else {
+				resp["result"] = "0xd6d8"
+			}
+		case "eth_feeHistory":
+			if !eip1559 {
+				resp["error"] = map[string]interface{}{"code": -32601, "message": "the method eth_feeHistory does not exist/is not available"}
+			} else {
+				resp["result"] = map[string]interface{}{
+					"oldestBlock":   "0x3e6",
+					"baseFeePerGas": []string{"0x12a05f200", "0x12a05f200", "0x12a05f200"},
+					"gasUsedRatio":  []float64{0.5, 0.5},
+					"reward":        [][]string{{"0x77359400"}, {"0x77359400"}},
+				}
+			}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestEthereumFee_GetFee(t *testing.T) {
+	server := newFeeNode(t, true)
+	defer server.Close()
+	fee := NewEthereumFee(&conf.FeeListenConfig{
+		ChainId:       basedef.ETHEREUM_CROSSCHAIN_ID,
+		Nodes:         []*conf.Restful{{Url: server.URL}},
+		ProxyFee:      120,
+		MinFee:        20,
+		GasLimit:      100000,
+		BaseFeeBlocks: 1,
+	}, 60)
+	// 5 gwei base fee grows to 5.625 gwei, plus 2 gwei priority fee
+	minFee, maxFee, proxyFee, err := fee.GetFee()
+	assert.NoError(t, err)
+	gasFee := new(big.Int).Mul(big.NewInt(7625000000*100000), big.NewInt(basedef.FEE_PRECISION))
+	assert.Equal(t, gasFee, maxFee)
+	assert.Equal(t, new(big.Int).Div(new(big.Int).Mul(gasFee, big.NewInt(120)), big.NewInt(100)), proxyFee)
+	assert.Equal(t, new(big.Int).Div(new(big.Int).Mul(gasFee, big.NewInt(20)), big.NewInt(100)), minFee)
+}
+
+func TestEthereumFee_Legacy(t *testing.T) {
+	server := newFeeNode(t, false)
+	defer server.Close()
+	fee := NewEthereumFee(&conf.FeeListenConfig{
+		ChainId:  basedef.HECO_CROSSCHAIN_ID,
+		Nodes:    []*conf.Restful{{Url: server.URL}},
+		GasLimit: 100000,
+	}, 60)
+	// the node does not know eth_feeHistory, the gas price of 20 gwei is used and the node is still selected
+	for i := 0; i < 2; i++ {
+		_, maxFee, _, err := fee.GetFee()
+		assert.NoError(t, err)
+		assert.Equal(t, new(big.Int).Mul(big.NewInt(20000000000*100000), big.NewInt(basedef.FEE_PRECISION)), maxFee)
+	}
+}
+
+func TestEthereumFee_EstimateGasLimit(t *testing.T) {
+	server := newFeeNode(t, true)
+	defer server.Close()
+	cfg := &conf.FeeListenConfig{
+		ChainId:  basedef.ETHEREUM_CROSSCHAIN_ID,
+		Nodes:    []*conf.Restful{{Url: server.URL}},
+		GasLimit: 100000,
+	}
+	fee := NewEthereumFee(cfg, 60)
+	gasLimit, err := fee.EstimateGasLimit("0000000000000000000000000000000000000000")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100000), gasLimit)
+	_, err = fee.EstimateGasLimit("0000000000000000000000000000000000000001")
+	assert.Error(t, err)
+
+	cfg.ProxyContract = "0x250e76987d838a75310c34bf422ea9f1ac4cc906"
+	// the simulated 55000 gas less 21000 intrinsic gas replaces the transfer gas in the gas limit
+	gasLimit, err = fee.EstimateGasLimit("0000000000000000000000000000000000000001")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100000-DEFAULT_TRANSFER_GAS+34000), gasLimit)
+	// a reverted simulation fails the estimation but not the node
+	for i := 0; i < 2; i++ {
+		_, err = fee.EstimateGasLimit(_revert_token[2:])
+		assert.Error(t, err)
+	}
+	_, err = fee.EstimateGasLimit("0000000000000000000000000000000000000001")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package chainfeelisten
+
+import (
+	"poly-bridge/chainfeedao"
+	"poly-bridge/models"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+)
+
+const (
+	DEFAULT_FEE_HISTORY_DAYS = 30
+	DEFAULT_FEE_QUOTE_DAYS   = 7
+	_fee_history_delete_size = 10000 // records deleted by one statement at most
+	_fee_history_delete_max  = 100   // statements of one pruning at most
+)
+
+// saveFeeHistories appends the updated chain fees with the prices of their tokens to the fee histories
+func (fl *FeeListen) saveFeeHistories(chainFees []*models.ChainFee) {
+	dao, ok := fl.db.(chainfeedao.FeeHistoryDao)
+	if !ok {
+		return
+	}
+	names := make([]string, 0)
+	for _, fee := range chainFees {
+		if fee.Ind == 1 {
+			names = append(names, fee.TokenBasicName)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	prices, err := dao.GetTokenPrices(names)
+	if err != nil {
+		logs.Error("get prices of fee tokens err: %v", err)
+		return
+	}
+	histories := make([]*models.ChainFeeHistory, 0)
+	for _, fee := range chainFees {
+		if fee.Ind != 1 {
+			continue
+		}
+		histories = append(histories, &models.ChainFeeHistory{
+			ChainId:        fee.ChainId,
+			Time:           fee.Time,
+			TokenBasicName: fee.TokenBasicName,
+			MaxFee:         fee.MaxFee,
+			MinFee:         fee.MinFee,
+			ProxyFee:       fee.ProxyFee,
+			TokenPrice:     prices[fee.TokenBasicName],
+		})
+	}
+	err = dao.SaveFeeHistories(histories)
+	if err != nil {
+		logs.Error("save fee histories err: %v", err)
+	}
+}
+
+// saveAssetGasLimitHistories appends the updated gas limits of the assets to the asset gas limit histories
+func (fl *FeeListen) saveAssetGasLimitHistories(limits []*models.AssetGasLimit) {
+	dao, ok := fl.db.(chainfeedao.FeeHistoryDao)
+	if !ok || len(limits) == 0 {
+		return
+	}
+	histories := make([]*models.AssetGasLimitHistory, 0, len(limits))
+	for _, limit := range limits {
+		histories = append(histories, &models.AssetGasLimitHistory{
+			ChainId:       limit.ChainId,
+			Hash:          limit.Hash,
+			Time:          limit.Time,
+			GasLimit:      limit.GasLimit,
+			ChainGasLimit: limit.ChainGasLimit,
+			Samples:       limit.Samples,
+		})
+	}
+	err := dao.SaveAssetGasLimitHistories(histories)
+	if err != nil {
+		logs.Error("save asset gas limit histories err: %v", err)
+	}
+}
+
+// pruneFeeHistories deletes the fee and asset gas limit histories older than the retention days and the fee
+// quotes expired for DEFAULT_FEE_QUOTE_DAYS
+func (fl *FeeListen) pruneFeeHistories() {
+	now := time.Now().Unix()
+	if dao, ok := fl.db.(chainfeedao.FeeHistoryDao); ok {
+		days := fl.historyDays
+		if days <= 0 {
+			days = DEFAULT_FEE_HISTORY_DAYS
+		}
+		prune("fee histories", now-days*24*3600, dao.DeleteFeeHistories)
+		prune("asset gas limit histories", now-days*24*3600, dao.DeleteAssetGasLimitHistories)
+	}
+	if dao, ok := fl.db.(chainfeedao.FeeQuoteDao); ok {
+		prune("fee quotes", now-DEFAULT_FEE_QUOTE_DAYS*24*3600, dao.DeleteFeeQuotes)
+	}
+}
+
+// prune deletes the records before the time in batches
+func prune(name string, before int64, deleteBefore func(before int64, limit int) (int64, error)) {
+	for i := 0; i < _fee_history_delete_max; i++ {
+		deleted, err := deleteBefore(before, _fee_history_delete_size)
+		if err != nil {
+			logs.Error("delete %s before %d err: %v", name, before, err)
+			return
+		}
+		if deleted > 0 {
+			logs.Info("delete %d %s before %d", deleted, name, before)
+		}
+		if deleted < _fee_history_delete_size {
+			return
+		}
+	}
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package chainfeelisten
+
+import (
+	"testing"
+	"time"
+
+	"poly-bridge/basedef"
+	"poly-bridge/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (dao *testFeeDao) GetTokenPrices(names []string) (map[string]int64, error) {
+	return dao.prices, nil
+}
+
+func (dao *testFeeDao) SaveFeeHistories(histories []*models.ChainFeeHistory) error {
+	dao.histories = append(dao.histories, histories...)
+	return nil
+}
+
+func (dao *testFeeDao) DeleteFeeHistories(before int64, limit int) (int64, error) {
+	histories := make([]*models.ChainFeeHistory, 0)
+	deleted := int64(0)
+	for _, history := range dao.histories {
+		if history.Time < before && deleted < int64(limit) {
+			deleted++
+			continue
+		}
+		histories = append(histories, history)
+	}
+	dao.histories = histories
+	return deleted, nil
+}
+
+func (dao *testFeeDao) SaveAssetGasLimitHistories(histories []*models.AssetGasLimitHistory) error {
+	dao.limitHistories = append(dao.limitHistories, histories...)
+	return nil
+}
+
+func (dao *testFeeDao) DeleteAssetGasLimitHistories(before int64, limit int) (int64, error) {
+	histories := make([]*models.AssetGasLimitHistory, 0)
+	for _, history := range dao.limitHistories {
+		if history.Time >= before {
+			histories = append(histories, history)
+		}
+	}
+	deleted := int64(len(dao.limitHistories) - len(histories))
+	dao.limitHistories = histories
+	return deleted, nil
+}
+
+func (dao *testFeeDao) DeleteFeeQuotes(before int64, limit int) (int64, error) {
+	quotes := make([]int64, 0)
+	for _, expiry := range dao.quotes {
+		if expiry >= before {
+			quotes = append(quotes, expiry)
+		}
+	}
+	deleted := int64(len(dao.quotes) - len(quotes))
+	dao.quotes = quotes
+	return deleted, nil
+}
+
+func TestFeeListen_FeeHistories(t *testing.T) {
+	dao := &testFeeDao{
+		fees: []*models.ChainFee{
+			{ChainId: basedef.ETHEREUM_CROSSCHAIN_ID, TokenBasicName: "Ethereum"},
+			{ChainId: basedef.BSC_CROSSCHAIN_ID, TokenBasicName: "BNB"},
+		},
+		prices: map[string]int64{"Ethereum": 200000000000},
+	}
+	fl := NewFeeListen(1, []ChainFee{&testChainFee{}}, dao)
+	// only the updated fee of ethereum is appended
+	assert.Equal(t, 1, len(dao.histories))
+	history := dao.histories[0]
+	assert.Equal(t, uint64(basedef.ETHEREUM_CROSSCHAIN_ID), history.ChainId)
+	assert.Equal(t, "6000", history.ProxyFee.String())
+	assert.Equal(t, int64(200000000000), history.TokenPrice)
+
+	now := time.Now().Unix()
+	for i := int64(1); i <= 3; i++ {
+		dao.histories = append(dao.histories, &models.ChainFeeHistory{ChainId: basedef.ETHEREUM_CROSSCHAIN_ID, Time: now - (DEFAULT_FEE_HISTORY_DAYS+i)*24*3600})
+	}
+	dao.limitHistories = []*models.AssetGasLimitHistory{{Time: now - (DEFAULT_FEE_HISTORY_DAYS+1)*24*3600}, {Time: now}}
+	dao.quotes = []int64{now - (DEFAULT_FEE_QUOTE_DAYS+1)*24*3600, now}
+	fl.pruneFeeHistories()
+	assert.Equal(t, 1, len(dao.histories))
+	assert.Equal(t, 1, len(dao.limitHistories))
+	assert.Equal(t, []int64{now}, dao.quotes)
+
+	fl.historyDays = 1
+	dao.histories[0].Time = now - 2*24*3600
+	fl.pruneFeeHistories()
+	assert.Equal(t, 0, len(dao.histories))
+}
@@ -18,23 +18,26 @@
 package chainfeelisten
 
 import (
+	"context"
 	"github.com/astaxie/beego/logs"
 	"math/big"
+	"poly-bridge/admin"
 	"poly-bridge/basedef"
 	"poly-bridge/chainfeedao"
 	"poly-bridge/chainfeelisten/ethereumfee"
 	"poly-bridge/chainfeelisten/neofee"
 	"poly-bridge/chainfeelisten/ontologyfee"
 	"poly-bridge/conf"
+	"poly-bridge/metrics"
 	"poly-bridge/models"
-	"runtime/debug"
+	"poly-bridge/supervisor"
 	"strings"
 	"time"
 )
 
 var feeListen *FeeListen
 
-func StartFeeListen(server string, feeUpdateSlot int64, feeListenCfgs []*conf.FeeListenConfig, dbCfg *conf.DBConfig) {
+func StartFeeListen(server string, feeUpdateSlot int64, feeHistoryDays int64, feeListenCfgs []*conf.FeeListenConfig, dbCfg *conf.DBConfig) {
 	dao := chainfeedao.NewChainFeeDao(server, dbCfg)
 	if dao == nil {
 		panic("server is not valid")
@@ -48,12 +51,16 @@ func StartFeeListen(server string, feeUpdateSlot int64, feeListenCfgs []*conf.Fe
 		chainFees = append(chainFees, chainFee)
 	}
 	feeListen = NewFeeListen(feeUpdateSlot, chainFees, dao)
+	feeListen.historyDays = feeHistoryDays
 	feeListen.Start()
+	admin.Register("fee", feeListen)
 }
 
 func StopFeeListen() {
 	if feeListen != nil {
+		admin.Unregister("fee")
 		feeListen.Stop()
+		feeListen = nil
 	}
 }
 
@@ -64,35 +71,32 @@ type ChainFee interface {
 }
 
 func NewChainFee(cfg *conf.FeeListenConfig, feeUpdateSlot int64) ChainFee {
-	if cfg.ChainId == basedef.ETHEREUM_CROSSCHAIN_ID {
+	switch basedef.GetChainFamily(cfg.ChainId) {
+	case basedef.CHAIN_FAMILY_EVM:
 		return ethereumfee.NewEthereumFee(cfg, feeUpdateSlot)
-	} else if cfg.ChainId == basedef.NEO_CROSSCHAIN_ID {
+	case basedef.CHAIN_FAMILY_NEO:
 		return neofee.NewNeoFee(cfg, feeUpdateSlot)
-	} else if cfg.ChainId == basedef.BSC_CROSSCHAIN_ID {
-		return ethereumfee.NewEthereumFee(cfg, feeUpdateSlot)
-	} else if cfg.ChainId == basedef.HECO_CROSSCHAIN_ID {
-		return ethereumfee.NewEthereumFee(cfg, feeUpdateSlot)
-	} else if cfg.ChainId == basedef.ONT_CROSSCHAIN_ID {
+	case basedef.CHAIN_FAMILY_ONTOLOGY:
 		return ontologyfee.NewOntologyFee(cfg, feeUpdateSlot)
-	} else if cfg.ChainId == basedef.OK_CROSSCHAIN_ID {
-		return ethereumfee.NewEthereumFee(cfg, feeUpdateSlot)
-	} else {
+	default:
 		return nil
 	}
 }
 
 type FeeListen struct {
+	admin.Switch
 	feeUpdateSlot int64
+	historyDays   int64
 	fees          map[uint64]ChainFee
 	db            chainfeedao.ChainFeeDao
-	exit          chan bool
+	supervisor    *supervisor.Supervisor
 }
 
 func NewFeeListen(feeUpdateSlot int64, fees []ChainFee, db chainfeedao.ChainFeeDao) *FeeListen {
 	feeListen := &FeeListen{}
 	feeListen.feeUpdateSlot = feeUpdateSlot
 	feeListen.db = db
-	feeListen.exit = make(chan bool, 0)
+	feeListen.supervisor = supervisor.NewSupervisor("fee")
 	feeListen.fees = make(map[uint64]ChainFee)
 	for _, fee := range fees {
 		feeListen.fees[fee.GetChainId()] = fee
@@ -110,49 +114,39 @@ func NewFeeListen(feeUpdateSlot int64, fees []ChainFee, db chainfeedao.ChainFeeD
 	if err != nil {
 		panic(err)
 	}
+	feeListen.saveFeeHistories(chainFees)
 	return feeListen
 }
 
 func (fl *FeeListen) Start() {
 	logs.Info("start chain fee listen.")
-	go fl.ListenFee()
+	fl.supervisor.Go(fl.listenFee)
 }
 
 func (fl *FeeListen) Stop() {
-	fl.exit <- true
+	fl.supervisor.Stop()
 	logs.Info("stop chain fee listen.")
 }
 
+// ListenFee listens the fee until the listen is stopped
 func (fl *FeeListen) ListenFee() {
-	for {
-		exit := fl.listenFee()
-		if exit {
-			close(fl.exit)
-			break
-		}
-		time.Sleep(time.Second * 5)
-	}
+	fl.Start()
+	fl.supervisor.Wait()
 }
 
-func (fl *FeeListen) listenFee() (exit bool) {
-	defer func() {
-		if r := recover(); r != nil {
-			logs.Error("service start, recover info: %s", string(debug.Stack()))
-			exit = false
-		}
-	}()
-
+func (fl *FeeListen) listenFee(ctx context.Context) error {
 	logs.Debug("fee listen, chain: %s, dao: %s......", fl.GetChainFees(), fl.db.Name())
 	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
 			now := time.Now().Unix() / 60
-			if now%fl.feeUpdateSlot != 0 {
+			if now%fl.feeUpdateSlot != 0 || fl.Paused() {
 				continue
 			}
 			counter := 0
-			for counter < 5 {
+			for counter < 5 && ctx.Err() == nil {
 				time.Sleep(time.Second * 5)
 				counter++
 				logs.Info("do fee update at time: %s", time.Now().Format("2006-01-02 15:04:05"))
@@ -160,23 +154,29 @@ func (fl *FeeListen) listenFee() (exit bool) {
 				chainFees, err := fl.db.GetFees()
 				if err != nil {
 					logs.Error("get chain fees err: %v", err)
+					fl.Ran(err)
 					continue
 				}
 				err = fl.updateChainFees(chainFees)
 				if err != nil {
 					logs.Error("updateChainFees err: %v", err)
+					fl.Ran(err)
 					continue
 				}
 				err = fl.db.SaveFees(chainFees)
 				if err != nil {
 					logs.Error("save fees err: %v", err)
+					fl.Ran(err)
 					continue
 				}
+				fl.saveFeeHistories(chainFees)
+				fl.pruneFeeHistories()
+				fl.Ran(nil)
 				break
 			}
-		case <-fl.exit:
+		case <-ctx.Done():
 			logs.Info("fee listen exit, chain: %s, dao: %s......", fl.GetChainFees(), fl.db.Name())
-			return true
+			return nil
 		}
 	}
 }
@@ -209,7 +209,9 @@ func (fl *FeeListen) updateChainFees(chainFees []*models.ChainFee) error {
 		if fee.Ind == 0 {
 			logs.Error("fee of chain %d is not update", fee.ChainId)
 		}
+		metrics.SetFeeUpdated(fee.ChainId, fee.Ind == 1, fee.Time)
 	}
+	fl.updateAssetGasLimits()
 	return nil
 }
 
@@ -0,0 +1,116 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package chainsdk
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rpcCache is a bounded lru cache of rpc results. An entry with a ttl expires after it, an entry without
+// one is kept until it is evicted by newer entries.
+type rpcCache struct {
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+	lock    sync.Mutex
+}
+
+type cacheEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+func newRpcCache(size int) *rpcCache {
+	return &rpcCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (cache *rpcCache) get(key string) (interface{}, bool) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	element, ok := cache.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		cache.order.Remove(element)
+		delete(cache.entries, key)
+		return nil, false
+	}
+	cache.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// set adds the value to the cache, a ttl of 0 means the value does not expire
+func (cache *rpcCache) set(key string, value interface{}, ttl time.Duration) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	expires := time.Time{}
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+	if element, ok := cache.entries[key]; ok {
+		entry := element.Value.(*cacheEntry)
+		entry.value, entry.expires = value, expires
+		cache.order.MoveToFront(element)
+		return
+	}
+	cache.entries[key] = cache.order.PushFront(&cacheEntry{key: key, value: value, expires: expires})
+	for cache.order.Len() > cache.size {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (cache *rpcCache) len() int {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	return cache.order.Len()
+}
+
+var (
+	rpcCaches    = make(map[string]*rpcCache)
+	rpcCacheLock sync.Mutex
+)
+
+// sharedRpcCache returns the cache of the sdks of the chain on the same nodes. The services of a process
+// create their own sdks and share the results through it, while a quorum sdk on other nodes does not.
+func sharedRpcCache(id uint64, urls []string, size int) *rpcCache {
+	sorted := append([]string{}, urls...)
+	sort.Strings(sorted)
+	key := fmt.Sprintf("%d/%s", id, strings.Join(sorted, ","))
+	rpcCacheLock.Lock()
+	defer rpcCacheLock.Unlock()
+	cache, ok := rpcCaches[key]
+	if !ok {
+		cache = newRpcCache(size)
+		rpcCaches[key] = cache
+	}
+	return cache
+}
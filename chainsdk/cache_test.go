@@ -0,0 +1,128 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package chainsdk
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_Evict(t *testing.T) {
+	cache := newRpcCache(2)
+	cache.set("a", 1, 0)
+	cache.set("b", 2, 0)
+	_, ok := cache.get("a")
+	assert.True(t, ok)
+	// b is the least recently used
+	cache.set("c", 3, 0)
+	assert.Equal(t, 2, cache.len())
+	_, ok = cache.get("b")
+	assert.False(t, ok)
+	value, ok := cache.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, value)
+	cache.set("a", 4, 0)
+	value, _ = cache.get("a")
+	assert.Equal(t, 4, value)
+	assert.Equal(t, 2, cache.len())
+}
+
+func TestCache_Expire(t *testing.T) {
+	cache := newRpcCache(10)
+	cache.set("balance", 1, time.Millisecond*50)
+	cache.set("header", 2, 0)
+	_, ok := cache.get("balance")
+	assert.True(t, ok)
+	time.Sleep(time.Millisecond * 100)
+	_, ok = cache.get("balance")
+	assert.False(t, ok)
+	_, ok = cache.get("header")
+	assert.True(t, ok)
+	assert.Equal(t, 1, cache.len())
+}
+
+func TestCache_Shared(t *testing.T) {
+	cache := sharedRpcCache(1001, []string{"http://a", "http://b"}, 10)
+	assert.True(t, cache == sharedRpcCache(1001, []string{"http://b", "http://a"}, 10))
+	assert.True(t, cache != sharedRpcCache(1001, []string{"http://c"}, 10))
+	assert.True(t, cache != sharedRpcCache(1002, []string{"http://a", "http://b"}, 10))
+}
+
+func TestCache_CopyReceipt(t *testing.T) {
+	receipt := &types.Receipt{Status: 1, BlockNumber: big.NewInt(900), Logs: []*types.Log{{Topics: []common.Hash{common.HexToHash("0x1")}, Data: []byte{1}}}}
+	cpy := copyReceipt(receipt)
+	cpy.BlockNumber.SetUint64(901)
+	cpy.Logs[0].Topics[0] = common.HexToHash("0x2")
+	cpy.Logs[0].Data[0] = 2
+	cpy.Logs = append(cpy.Logs, &types.Log{})
+	assert.Equal(t, uint64(900), receipt.BlockNumber.Uint64())
+	assert.Equal(t, common.HexToHash("0x1"), receipt.Logs[0].Topics[0])
+	assert.Equal(t, []byte{1}, receipt.Logs[0].Data)
+	assert.Len(t, receipt.Logs, 1)
+}
+
+func TestCache_EthereumSdkPro(t *testing.T) {
+	node, server := newTestRpcNode(t, map[string]func(params []json.RawMessage) (interface{}, error){
+		"eth_blockNumber": func(params []json.RawMessage) (interface{}, error) {
+			return "0x3e8", nil
+		},
+		"eth_getBlockByNumber": func(params []json.RawMessage) (interface{}, error) {
+			var number hexutil.Uint64
+			json.Unmarshal(params[0], &number)
+			return testHeader(uint64(number)), nil
+		},
+		"eth_getBalance": func(params []json.RawMessage) (interface{}, error) {
+			return "0x64", nil
+		},
+	})
+	defer server.Close()
+	pro := NewEthereumSdkPro([]string{server.URL}, 3600, 1001)
+
+	// the selected height is 999, the block 900 is confirmed and 990 is not
+	headers, err := pro.GetHeadersByNumber([]uint64{900, 990})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(900), headers[0].Number.Uint64())
+	assert.Equal(t, uint64(990), headers[1].Number.Uint64())
+	headers, err = pro.GetHeadersByNumber([]uint64{900, 990})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1600000990), headers[1].Time)
+	_, calls := node.stats()
+	assert.Equal(t, 3, calls["eth_getBlockByNumber"])
+	header, err := pro.GetHeaderByNumber(900)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1600000900), header.Time)
+	_, calls = node.stats()
+	assert.Equal(t, 3, calls["eth_getBlockByNumber"])
+
+	// a cached balance is not changed by its caller
+	balance, err := pro.Erc20Balance("0000000000000000000000000000000000000000", common.HexToAddress("0x10").Hex())
+	assert.NoError(t, err)
+	balance.Add(balance, big.NewInt(1))
+	balance, err = pro.Erc20Balance("0000000000000000000000000000000000000000", common.HexToAddress("0x10").Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, "100", balance.String())
+	_, calls = node.stats()
+	assert.Equal(t, 1, calls["eth_getBalance"])
+}
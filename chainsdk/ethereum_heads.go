@@ -0,0 +1,153 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package chainsdk
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const (
+	headsMinBackoff = time.Second
+	headsMaxBackoff = time.Minute
+)
+
+// EthereumHeads subscribes to newHeads on the websocket nodes of a chain and pushes the latest block height.
+type EthereumHeads struct {
+	urls      []string
+	id        uint64
+	heads     chan uint64
+	connected int32
+	exit      chan bool
+	stop      sync.Once
+	wg        sync.WaitGroup
+}
+
+func NewEthereumHeads(urls []string, id uint64) *EthereumHeads {
+	return &EthereumHeads{
+		urls:  urls,
+		id:    id,
+		heads: make(chan uint64, 1),
+		exit:  make(chan bool),
+	}
+}
+
+func (heads *EthereumHeads) Start() {
+	for _, url := range heads.urls {
+		heads.wg.Add(1)
+		go heads.subscribe(url)
+	}
+}
+
+// Stop can be called more than once
+func (heads *EthereumHeads) Stop() {
+	heads.stop.Do(func() {
+		close(heads.exit)
+	})
+	heads.wg.Wait()
+}
+
+// Heads only keeps the highest height which is not consumed yet.
+func (heads *EthereumHeads) Heads() <-chan uint64 {
+	return heads.heads
+}
+
+func (heads *EthereumHeads) Connected() bool {
+	return atomic.LoadInt32(&heads.connected) > 0
+}
+
+func (heads *EthereumHeads) push(height uint64) {
+	for {
+		select {
+		case heads.heads <- height:
+			return
+		default:
+		}
+		select {
+		case old := <-heads.heads:
+			if old > height {
+				height = old
+			}
+		default:
+		}
+	}
+}
+
+func (heads *EthereumHeads) subscribe(url string) {
+	defer heads.wg.Done()
+	backoff := headsMinBackoff
+	for {
+		subscribed, err := heads.listen(url)
+		select {
+		case <-heads.exit:
+			return
+		default:
+		}
+		if subscribed {
+			backoff = headsMinBackoff
+		}
+		logs.Error("new heads subscription of chain %d dropped, url: %s, err: %v, reconnect in %s", heads.id, url, err, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-heads.exit:
+			return
+		}
+		backoff *= 2
+		if backoff > headsMaxBackoff {
+			backoff = headsMaxBackoff
+		}
+	}
+}
+
+// listen blocks until the subscription fails or the heads are stopped.
+func (heads *EthereumHeads) listen(url string) (subscribed bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	client, err := ethclient.DialContext(ctx, url)
+	cancel()
+	if err != nil {
+		return false, err
+	}
+	defer client.Close()
+	headers := make(chan *types.Header, 16)
+	sub, err := client.SubscribeNewHead(context.Background(), headers)
+	if err != nil {
+		return false, err
+	}
+	defer sub.Unsubscribe()
+	atomic.AddInt32(&heads.connected, 1)
+	defer atomic.AddInt32(&heads.connected, -1)
+	logs.Info("new heads subscription of chain %d, url: %s", heads.id, url)
+	for {
+		select {
+		case header := <-headers:
+			if header != nil && header.Number != nil {
+				heads.push(header.Number.Uint64())
+			}
+		case err := <-sub.Err():
+			return true, err
+		case <-heads.exit:
+			return true, nil
+		}
+	}
+}
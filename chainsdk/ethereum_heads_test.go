@@ -0,0 +1,38 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package chainsdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEthereumHeads_Push(t *testing.T) {
+	heads := NewEthereumHeads(nil, 2)
+	heads.Start()
+	heads.push(10)
+	heads.push(8)
+	assert.Equal(t, uint64(10), <-heads.Heads())
+	heads.push(11)
+	assert.Equal(t, uint64(11), <-heads.Heads())
+	assert.False(t, heads.Connected())
+
+	heads.Stop()
+	heads.Stop()
+}
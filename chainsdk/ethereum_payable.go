@@ -431,16 +431,33 @@ func (s *EthereumSdk) GetUnCrossChainNFTsByIndex(
 	return res, nil
 }
 
+// GetOwnerNFTUrls gets the token uris in batch requests, the tokens whose uri can not be read are skipped
 func (s *EthereumSdk) GetOwnerNFTUrls(asset common.Address, tokenIds []*big.Int) (map[string]string, error) {
-	cm, err := nftmapping.NewCrossChainNFTMapping(asset, s.backend())
+	mappingAbi, err := abi.JSON(strings.NewReader(nftmapping.CrossChainNFTMappingABI))
+	if err != nil {
+		return nil, err
+	}
+
+	msgs := make([]ethereum.CallMsg, len(tokenIds))
+	for i, tokenId := range tokenIds {
+		data, err := mappingAbi.Pack("tokenURI", tokenId)
+		if err != nil {
+			return nil, err
+		}
+		msgs[i] = ethereum.CallMsg{To: &asset, Data: data}
+	}
+	results, errs, err := s.BatchCallContract(msgs)
 	if err != nil {
 		return nil, err
 	}
 
 	res := make(map[string]string)
-	for _, tokenId := range tokenIds {
-		url, err := cm.TokenURI(nil, tokenId)
-		if err == nil {
+	for i, tokenId := range tokenIds {
+		if errs[i] != nil {
+			continue
+		}
+		var url string
+		if err := mappingAbi.Unpack(&url, "tokenURI", results[i]); err == nil {
 			res[tokenId.String()] = url
 		}
 	}
@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -40,6 +41,17 @@ type EthereumSdk struct {
 }
 
 func NewEthereumSdk(url string) (*EthereumSdk, error) {
+	if httpClient := newRpcHttpClient(); httpClient != nil {
+		rpcClient, err := rpc.DialHTTPWithClient(url, httpClient)
+		if err != nil {
+			return nil, fmt.Errorf("ethereum node is not working!, err: %v", err)
+		}
+		return &EthereumSdk{
+			rpcClient: rpcClient,
+			rawClient: ethclient.NewClient(rpcClient),
+			url:       url,
+		}, nil
+	}
 	rpcClient, err1 := rpc.Dial(url)
 	rawClient, err2 := ethclient.Dial(url)
 	if rpcClient == nil || err1 != nil || rawClient == nil || err2 != nil {
@@ -88,6 +100,24 @@ func (ec *EthereumSdk) GetHeaderByNumber(number uint64) (*types.Header, error) {
 	return header, err
 }
 
+type blockHashes struct {
+	Hash       common.Hash `json:"hash"`
+	ParentHash common.Hash `json:"parentHash"`
+}
+
+// GetBlockHashByNumber returns the hash and parent hash of the given block as reported by the node
+func (ec *EthereumSdk) GetBlockHashByNumber(number uint64) (common.Hash, common.Hash, error) {
+	var hashes *blockHashes
+	err := ec.rpcClient.CallContext(context.Background(), &hashes, "eth_getBlockByNumber", toBlockNumArg(new(big.Int).SetUint64(number)), false)
+	if err != nil {
+		return common.Hash{}, common.Hash{}, err
+	}
+	if hashes == nil {
+		return common.Hash{}, common.Hash{}, fmt.Errorf("there is no block %d", number)
+	}
+	return hashes.Hash, hashes.ParentHash, nil
+}
+
 func (ec *EthereumSdk) GetBlockByNumber(number uint64) (*types.Block, error) {
 	return ec.rawClient.BlockByNumber(context.Background(), new(big.Int).SetUint64(number))
 }
@@ -100,6 +130,10 @@ func (ec *EthereumSdk) GetTransactionByHash(hash common.Hash) (*types.Transactio
 	return tx, err
 }
 
+func (ec *EthereumSdk) FilterLogs(query ethereum.FilterQuery) ([]types.Log, error) {
+	return ec.rawClient.FilterLogs(context.Background(), query)
+}
+
 func (ec *EthereumSdk) GetTransactionReceipt(hash common.Hash) (*types.Receipt, error) {
 	receipt, err := ec.rawClient.TransactionReceipt(context.Background(), hash)
 	for err != nil {
@@ -108,6 +142,319 @@ func (ec *EthereumSdk) GetTransactionReceipt(hash common.Hash) (*types.Receipt,
 	return receipt, nil
 }
 
+// GetTransactionGas returns the gas used and the effective gas price of a mined transaction,
+// read from the raw rpc result so that typed transactions can be handled
+func (ec *EthereumSdk) GetTransactionGas(hash common.Hash) (uint64, *big.Int, error) {
+	var receipt struct {
+		GasUsed           *hexutil.Uint64 `json:"gasUsed"`
+		EffectiveGasPrice *hexutil.Big    `json:"effectiveGasPrice"`
+	}
+	err := ec.rpcClient.CallContext(context.Background(), &receipt, "eth_getTransactionReceipt", hash)
+	if err != nil {
+		return 0, nil, err
+	}
+	if receipt.GasUsed == nil {
+		return 0, nil, ethereum.NotFound
+	}
+	if receipt.EffectiveGasPrice != nil {
+		return uint64(*receipt.GasUsed), (*big.Int)(receipt.EffectiveGasPrice), nil
+	}
+	var tx struct {
+		GasPrice *hexutil.Big `json:"gasPrice"`
+	}
+	err = ec.rpcClient.CallContext(context.Background(), &tx, "eth_getTransactionByHash", hash)
+	if err != nil {
+		return 0, nil, err
+	}
+	if tx.GasPrice == nil {
+		return 0, nil, ethereum.NotFound
+	}
+	return uint64(*receipt.GasUsed), (*big.Int)(tx.GasPrice), nil
+}
+
+// TransactionGas is the gas used and the effective gas price of a mined transaction
+type TransactionGas struct {
+	GasUsed     uint64
+	GasPrice    *big.Int
+	BlockNumber uint64
+}
+
+// batchCall sends the calls in batch requests of at most ETHEREUM_BATCH_SIZE calls, the error of each
+// call is set in its element
+func (ec *EthereumSdk) batchCall(elems []rpc.BatchElem) error {
+	for start := 0; start < len(elems); start += ETHEREUM_BATCH_SIZE {
+		end := start + ETHEREUM_BATCH_SIZE
+		if end > len(elems) {
+			end = len(elems)
+		}
+		if err := ec.rpcClient.BatchCallContext(context.Background(), elems[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BatchHeaderByNumber gets the headers of the blocks in batch requests
+func (ec *EthereumSdk) BatchHeaderByNumber(numbers []uint64) ([]*types.Header, error) {
+	headers := make([]*types.Header, len(numbers))
+	elems := make([]rpc.BatchElem, len(numbers))
+	for i, number := range numbers {
+		elems[i] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{toBlockNumArg(new(big.Int).SetUint64(number)), false},
+			Result: &headers[i],
+		}
+	}
+	if err := ec.batchCall(elems); err != nil {
+		return nil, err
+	}
+	for i, elem := range elems {
+		if elem.Error != nil {
+			return nil, elem.Error
+		}
+		if headers[i] == nil {
+			return nil, fmt.Errorf("there is no block %d", numbers[i])
+		}
+	}
+	return headers, nil
+}
+
+// BatchTransactionReceipt gets the receipts of the transactions in batch requests
+func (ec *EthereumSdk) BatchTransactionReceipt(hashes []common.Hash) ([]*types.Receipt, error) {
+	receipts := make([]*types.Receipt, len(hashes))
+	elems := make([]rpc.BatchElem, len(hashes))
+	for i, hash := range hashes {
+		elems[i] = rpc.BatchElem{Method: "eth_getTransactionReceipt", Args: []interface{}{hash}, Result: &receipts[i]}
+	}
+	if err := ec.batchCall(elems); err != nil {
+		return nil, err
+	}
+	for i, elem := range elems {
+		if elem.Error != nil {
+			return nil, elem.Error
+		}
+		if receipts[i] == nil {
+			return nil, ethereum.NotFound
+		}
+	}
+	return receipts, nil
+}
+
+// BatchTransactionGas is GetTransactionGas of the transactions in batch requests, the gas prices of the
+// transactions whose receipts have no effective gas price are read from the transactions in another batch
+func (ec *EthereumSdk) BatchTransactionGas(hashes []common.Hash) ([]*TransactionGas, error) {
+	receipts := make([]struct {
+		GasUsed           *hexutil.Uint64 `json:"gasUsed"`
+		EffectiveGasPrice *hexutil.Big    `json:"effectiveGasPrice"`
+		BlockNumber       *hexutil.Big    `json:"blockNumber"`
+	}, len(hashes))
+	elems := make([]rpc.BatchElem, len(hashes))
+	for i, hash := range hashes {
+		elems[i] = rpc.BatchElem{Method: "eth_getTransactionReceipt", Args: []interface{}{hash}, Result: &receipts[i]}
+	}
+	if err := ec.batchCall(elems); err != nil {
+		return nil, err
+	}
+	gases := make([]*TransactionGas, len(hashes))
+	txs := make([]struct {
+		GasPrice *hexutil.Big `json:"gasPrice"`
+	}, len(hashes))
+	txElems := make([]rpc.BatchElem, 0)
+	for i, elem := range elems {
+		receipt := receipts[i]
+		if elem.Error != nil {
+			return nil, elem.Error
+		}
+		if receipt.GasUsed == nil || receipt.BlockNumber == nil {
+			return nil, ethereum.NotFound
+		}
+		gases[i] = &TransactionGas{
+			GasUsed:     uint64(*receipt.GasUsed),
+			BlockNumber: (*big.Int)(receipt.BlockNumber).Uint64(),
+		}
+		if receipt.EffectiveGasPrice != nil {
+			gases[i].GasPrice = (*big.Int)(receipt.EffectiveGasPrice)
+		} else {
+			txElems = append(txElems, rpc.BatchElem{Method: "eth_getTransactionByHash", Args: []interface{}{hashes[i]}, Result: &txs[i]})
+		}
+	}
+	if len(txElems) == 0 {
+		return gases, nil
+	}
+	if err := ec.batchCall(txElems); err != nil {
+		return nil, err
+	}
+	for _, elem := range txElems {
+		if elem.Error != nil {
+			return nil, elem.Error
+		}
+	}
+	for i, gas := range gases {
+		if gas.GasPrice != nil {
+			continue
+		}
+		if txs[i].GasPrice == nil {
+			return nil, ethereum.NotFound
+		}
+		gas.GasPrice = (*big.Int)(txs[i].GasPrice)
+	}
+	return gases, nil
+}
+
+// BatchCallContract calls the contracts on the latest block in batch requests. The error of a failed
+// request is returned, while the error of each call, such as a revert, is returned in errs.
+func (ec *EthereumSdk) BatchCallContract(msgs []ethereum.CallMsg) (results [][]byte, errs []error, err error) {
+	outputs := make([]hexutil.Bytes, len(msgs))
+	elems := make([]rpc.BatchElem, len(msgs))
+	for i, msg := range msgs {
+		elems[i] = rpc.BatchElem{Method: "eth_call", Args: []interface{}{toCallArg(msg), "latest"}, Result: &outputs[i]}
+	}
+	if err = ec.batchCall(elems); err != nil {
+		return nil, nil, err
+	}
+	results, errs = make([][]byte, len(msgs)), make([]error, len(msgs))
+	for i, elem := range elems {
+		results[i], errs[i] = outputs[i], elem.Error
+	}
+	return results, errs, nil
+}
+
+func toCallArg(msg ethereum.CallMsg) interface{} {
+	arg := map[string]interface{}{
+		"from": msg.From,
+		"to":   msg.To,
+	}
+	if len(msg.Data) > 0 {
+		arg["data"] = hexutil.Bytes(msg.Data)
+	}
+	if msg.Value != nil {
+		arg["value"] = (*hexutil.Big)(msg.Value)
+	}
+	if msg.Gas != 0 {
+		arg["gas"] = hexutil.Uint64(msg.Gas)
+	}
+	if msg.GasPrice != nil {
+		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
+	}
+	return arg
+}
+
+// FeeHistory is the result of eth_feeHistory, BaseFee has the base fee of the block after the newest one
+// as its last item, and Reward has the priority fees of each block at the requested percentiles
+type FeeHistory struct {
+	OldestBlock  uint64
+	BaseFee      []*big.Int
+	GasUsedRatio []float64
+	Reward       [][]*big.Int
+}
+
+// FeeHistory gets the base fees and the priority fees at the percentiles of the latest blocks
+func (ec *EthereumSdk) FeeHistory(blocks uint64, percentiles []float64) (*FeeHistory, error) {
+	var result struct {
+		OldestBlock   *hexutil.Big     `json:"oldestBlock"`
+		BaseFeePerGas []*hexutil.Big   `json:"baseFeePerGas"`
+		GasUsedRatio  []float64        `json:"gasUsedRatio"`
+		Reward        [][]*hexutil.Big `json:"reward"`
+	}
+	err := ec.rpcClient.CallContext(context.Background(), &result, "eth_feeHistory", hexutil.Uint64(blocks), "latest", percentiles)
+	if err != nil {
+		return nil, err
+	}
+	if result.OldestBlock == nil {
+		return nil, ethereum.NotFound
+	}
+	history := &FeeHistory{
+		OldestBlock:  (*big.Int)(result.OldestBlock).Uint64(),
+		BaseFee:      make([]*big.Int, 0, len(result.BaseFeePerGas)),
+		GasUsedRatio: result.GasUsedRatio,
+		Reward:       make([][]*big.Int, 0, len(result.Reward)),
+	}
+	for _, baseFee := range result.BaseFeePerGas {
+		history.BaseFee = append(history.BaseFee, (*big.Int)(baseFee))
+	}
+	for _, items := range result.Reward {
+		rewards := make([]*big.Int, 0, len(items))
+		for _, reward := range items {
+			rewards = append(rewards, (*big.Int)(reward))
+		}
+		history.Reward = append(history.Reward, rewards)
+	}
+	return history, nil
+}
+
+// RpcTransaction is a transaction of a block read from the raw rpc result
+type RpcTransaction struct {
+	Hash     common.Hash     `json:"hash"`
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      hexutil.Uint64  `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Value    *hexutil.Big    `json:"value"`
+	Input    hexutil.Bytes   `json:"input"`
+}
+
+// RpcBlock is a block with its transactions read from the raw rpc result
+type RpcBlock struct {
+	Number       hexutil.Uint64    `json:"number"`
+	Timestamp    hexutil.Uint64    `json:"timestamp"`
+	Transactions []*RpcTransaction `json:"transactions"`
+}
+
+// BatchBlockTransactions gets the blocks with their transactions in batch requests
+func (ec *EthereumSdk) BatchBlockTransactions(numbers []uint64) ([]*RpcBlock, error) {
+	blocks := make([]*RpcBlock, len(numbers))
+	elems := make([]rpc.BatchElem, len(numbers))
+	for i, number := range numbers {
+		elems[i] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{toBlockNumArg(new(big.Int).SetUint64(number)), true},
+			Result: &blocks[i],
+		}
+	}
+	if err := ec.batchCall(elems); err != nil {
+		return nil, err
+	}
+	for i, elem := range elems {
+		if elem.Error != nil {
+			return nil, elem.Error
+		}
+		if blocks[i] == nil {
+			return nil, fmt.Errorf("there is no block %d", numbers[i])
+		}
+	}
+	return blocks, nil
+}
+
+// GetRevertReason replays the transaction on the state of the given block and returns why it reverted,
+// the reason is empty if the replay does not revert
+func (ec *EthereumSdk) GetRevertReason(tx *RpcTransaction, number uint64) (string, error) {
+	arg := map[string]interface{}{
+		"from": tx.From,
+		"to":   tx.To,
+		"gas":  tx.Gas,
+		"data": tx.Input,
+	}
+	if tx.Value != nil {
+		arg["value"] = tx.Value
+	}
+	var result hexutil.Bytes
+	err := ec.rpcClient.CallContext(context.Background(), &result, "eth_call", arg, toBlockNumArg(new(big.Int).SetUint64(number)))
+	if err == nil {
+		return "", nil
+	}
+	if _, ok := err.(rpc.Error); !ok {
+		return "", err
+	}
+	if dataErr, ok := err.(rpc.DataError); ok {
+		if data, ok := dataErr.ErrorData().(string); ok {
+			if reason, err := abi.UnpackRevert(common.FromHex(data)); err == nil {
+				return reason, nil
+			}
+		}
+	}
+	return err.Error(), nil
+}
+
 func (ec *EthereumSdk) NonceAt(addr common.Address) (uint64, error) {
 	nonce, err := ec.rawClient.PendingNonceAt(context.Background(), addr)
 	for err != nil {
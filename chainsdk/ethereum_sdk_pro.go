@@ -22,19 +22,40 @@ import (
 	"math"
 	"math/big"
 	"runtime/debug"
-	"sync"
+	"strconv"
+	"strings"
 	"time"
 
+	"poly-bridge/metrics"
+
 	"github.com/astaxie/beego/logs"
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	ETHEREUM_BATCH_SIZE          = 100
+	ETHEREUM_CACHE_SIZE          = 20000
+	ETHEREUM_CACHE_CONFIRMATIONS = 12
+	ETHEREUM_CACHE_TTL           = time.Second * 10
+)
+
+// the kinds of the cached rpc results
+const (
+	_cache_header      = "header"
+	_cache_receipt     = "receipt"
+	_cache_gas         = "gas"
+	_cache_balance     = "balance"
+	_cache_nft_balance = "nft_balance"
+	_cache_nft_tokens  = "nft_tokens"
 )
 
 type EthereumInfo struct {
-	sdk          *EthereumSdk
-	latestHeight uint64
+	sdk *EthereumSdk
+	url string
 }
 
 func NewEthereumInfo(url string) *EthereumInfo {
@@ -43,16 +64,21 @@ func NewEthereumInfo(url string) *EthereumInfo {
 		panic(err)
 	}
 	return &EthereumInfo{
-		sdk:          sdk,
-		latestHeight: 0,
+		sdk: sdk,
+		url: url,
 	}
 }
 
+// EthereumSdkPro caches the headers and receipts of the blocks which are ETHEREUM_CACHE_CONFIRMATIONS
+// blocks below the selected height until they are evicted, and the balances and nft queries for
+// ETHEREUM_CACHE_TTL. The cache is shared by the sdks of the chain on the same nodes.
 type EthereumSdkPro struct {
 	infos         map[string]*EthereumInfo
 	selectionSlot uint64
 	id            uint64
-	mutex         sync.Mutex
+	selector      *NodeSelector
+	cache         *rpcCache
+	confirmations uint64
 }
 
 func NewEthereumSdkPro(urls []string, slot uint64, id uint64) *EthereumSdkPro {
@@ -60,7 +86,14 @@ func NewEthereumSdkPro(urls []string, slot uint64, id uint64) *EthereumSdkPro {
 	for _, url := range urls {
 		infos[url] = NewEthereumInfo(url)
 	}
-	pro := &EthereumSdkPro{infos: infos, selectionSlot: slot, id: id}
+	pro := &EthereumSdkPro{
+		infos:         infos,
+		selectionSlot: slot,
+		id:            id,
+		selector:      NewNodeSelector(id, urls),
+		cache:         sharedRpcCache(id, urls, ETHEREUM_CACHE_SIZE),
+		confirmations: ETHEREUM_CACHE_CONFIRMATIONS,
+	}
 	pro.selection()
 	go pro.NodeSelection()
 	return pro
@@ -89,50 +122,49 @@ func (pro *EthereumSdkPro) nodeSelection() {
 }
 
 func (pro *EthereumSdkPro) selection() {
-	for url, info := range pro.infos {
-		height, err := info.sdk.GetCurrentBlockHeight()
-		if err != nil || height == math.MaxUint64 {
-			logs.Error("get current block height err: %v, url: %s", err, url)
-			height = 1
-		}
-		/*
-			block, err := info.sdk.GetBlockByNumber(height)
-			if err != nil || block == nil {
-				logs.Error("get current block err: %v, url: %s", err, url)
-				info.latestHeight = height - 1
-				continue
-			}
-			transactions := block.Transactions()
-			if len(transactions) > 0 {
-				transaction := transactions[0]
-				receipt, err := info.sdk.GetTransactionReceipt(transaction.Hash())
-				if err != nil || receipt == nil {
-					logs.Error("get transaction receipt err: %v, url: %s", err, url)
-					info.latestHeight = height - 1
-					continue
-				}
-			}
-		*/
-		pro.mutex.Lock()
-		info.latestHeight = height - 1
-		pro.mutex.Unlock()
-	}
+	pro.selector.Poll(func(url string) (uint64, error) {
+		height, err := pro.infos[url].sdk.GetCurrentBlockHeight()
+		if err != nil {
+			return 0, err
+		}
+		if height == math.MaxUint64 || height == 0 {
+			return 0, fmt.Errorf("invalid height %d", height)
+		}
+		return height - 1, nil
+	})
 }
 
 func (pro *EthereumSdkPro) GetLatest() *EthereumInfo {
-	pro.mutex.Lock()
-	defer func() {
-		pro.mutex.Unlock()
-	}()
-	height := uint64(0)
-	var latestInfo *EthereumInfo = nil
-	for _, info := range pro.infos {
-		if info != nil && info.latestHeight > height {
-			height = info.latestHeight
-			latestInfo = info
-		}
+	url, _ := pro.selector.Select()
+	return pro.infos[url]
+}
+
+// NodeStats returns the stats of the nodes of the sdk
+func (pro *EthereumSdkPro) NodeStats() []*NodeStats {
+	return pro.selector.Stats()
+}
+
+// SetCacheConfirmations raises the depth below which the headers and receipts are cached, the listener
+// sets it to its defer so that no block it may still see reorganized is cached
+func (pro *EthereumSdkPro) SetCacheConfirmations(confirmations uint64) {
+	if confirmations > pro.confirmations {
+		pro.confirmations = confirmations
 	}
-	return latestInfo
+}
+
+func (pro *EthereumSdkPro) confirmed(number uint64) bool {
+	_, height := pro.selector.Select()
+	return height >= number+pro.confirmations
+}
+
+func (pro *EthereumSdkPro) cached(kind string, key string) (interface{}, bool) {
+	value, ok := pro.cache.get(kind + "/" + key)
+	metrics.RpcCacheRequested(pro.id, kind, ok)
+	return value, ok
+}
+
+func (pro *EthereumSdkPro) setCache(kind string, key string, value interface{}, ttl time.Duration) {
+	pro.cache.set(kind+"/"+key, value, ttl)
 }
 
 func (pro *EthereumSdkPro) GetClient() *ethclient.Client {
@@ -144,14 +176,25 @@ func (pro *EthereumSdkPro) GetClient() *ethclient.Client {
 }
 
 func (pro *EthereumSdkPro) GetLatestHeight() (uint64, error) {
-	info := pro.GetLatest()
-	if info == nil {
+	url, height := pro.selector.Select()
+	if url == "" {
 		return 0, fmt.Errorf("all node is not working")
 	}
-	return info.latestHeight, nil
+	return height, nil
+}
+
+// PollLatestHeight polls the heights of the nodes at once instead of waiting for the next selection, and
+// returns the height of the selected node. It is called when a new head is pushed.
+func (pro *EthereumSdkPro) PollLatestHeight() (uint64, error) {
+	pro.selection()
+	return pro.GetLatestHeight()
 }
 
 func (pro *EthereumSdkPro) GetHeaderByNumber(number uint64) (*types.Header, error) {
+	key := strconv.FormatUint(number, 10)
+	if header, ok := pro.cached(_cache_header, key); ok {
+		return types.CopyHeader(header.(*types.Header)), nil
+	}
 	info := pro.GetLatest()
 	if info == nil {
 		return nil, fmt.Errorf("all node is not working")
@@ -160,15 +203,73 @@ func (pro *EthereumSdkPro) GetHeaderByNumber(number uint64) (*types.Header, erro
 	for info != nil {
 		header, err := info.sdk.GetHeaderByNumber(number)
 		if err != nil {
-			info.latestHeight = 0
+			pro.selector.Failed(info.url, err)
 			info = pro.GetLatest()
 		} else {
+			if header != nil && pro.confirmed(number) {
+				pro.setCache(_cache_header, key, types.CopyHeader(header), 0)
+			}
 			return header, nil
 		}
 	}
 	return nil, fmt.Errorf("all node is not working")
 }
 
+// GetHeadersByNumber gets the headers of the blocks, the headers which are not cached are got in batch requests
+func (pro *EthereumSdkPro) GetHeadersByNumber(numbers []uint64) ([]*types.Header, error) {
+	headers := make([]*types.Header, len(numbers))
+	missing, indexes := make([]uint64, 0), make([]int, 0)
+	for i, number := range numbers {
+		if header, ok := pro.cached(_cache_header, strconv.FormatUint(number, 10)); ok {
+			headers[i] = types.CopyHeader(header.(*types.Header))
+		} else {
+			missing, indexes = append(missing, number), append(indexes, i)
+		}
+	}
+	if len(missing) == 0 {
+		return headers, nil
+	}
+	info := pro.GetLatest()
+	if info == nil {
+		return nil, fmt.Errorf("all node is not working")
+	}
+
+	for info != nil {
+		fetched, err := info.sdk.BatchHeaderByNumber(missing)
+		if err != nil {
+			info = pro.reset(info, err)
+			continue
+		}
+		metrics.RpcBatchCalled(pro.id, len(missing))
+		for i, header := range fetched {
+			headers[indexes[i]] = header
+			if pro.confirmed(missing[i]) {
+				pro.setCache(_cache_header, strconv.FormatUint(missing[i], 10), types.CopyHeader(header), 0)
+			}
+		}
+		return headers, nil
+	}
+	return nil, fmt.Errorf("all node is not working")
+}
+
+func (pro *EthereumSdkPro) GetBlockHashByNumber(number uint64) (common.Hash, common.Hash, error) {
+	info := pro.GetLatest()
+	if info == nil {
+		return common.Hash{}, common.Hash{}, fmt.Errorf("all node is not working")
+	}
+
+	for info != nil {
+		hash, parentHash, err := info.sdk.GetBlockHashByNumber(number)
+		if err != nil {
+			pro.selector.Failed(info.url, err)
+			info = pro.GetLatest()
+		} else {
+			return hash, parentHash, nil
+		}
+	}
+	return common.Hash{}, common.Hash{}, fmt.Errorf("all node is not working")
+}
+
 func (pro *EthereumSdkPro) GetTransactionByHash(hash common.Hash) (*types.Transaction, error) {
 	info := pro.GetLatest()
 	if info == nil {
@@ -178,7 +279,7 @@ func (pro *EthereumSdkPro) GetTransactionByHash(hash common.Hash) (*types.Transa
 	for info != nil {
 		tx, err := info.sdk.GetTransactionByHash(hash)
 		if err != nil {
-			info.latestHeight = 0
+			pro.selector.Failed(info.url, err)
 			info = pro.GetLatest()
 		} else {
 			return tx, nil
@@ -188,6 +289,9 @@ func (pro *EthereumSdkPro) GetTransactionByHash(hash common.Hash) (*types.Transa
 }
 
 func (pro *EthereumSdkPro) GetTransactionReceipt(hash common.Hash) (*types.Receipt, error) {
+	if receipt, ok := pro.cached(_cache_receipt, hash.Hex()); ok {
+		return copyReceipt(receipt.(*types.Receipt)), nil
+	}
 	info := pro.GetLatest()
 	if info == nil {
 		return nil, fmt.Errorf("all node is not working")
@@ -196,15 +300,196 @@ func (pro *EthereumSdkPro) GetTransactionReceipt(hash common.Hash) (*types.Recei
 	for info != nil {
 		receipt, err := info.sdk.GetTransactionReceipt(hash)
 		if err != nil {
-			info.latestHeight = 0
+			pro.selector.Failed(info.url, err)
 			info = pro.GetLatest()
 		} else {
+			pro.cacheReceipt(hash, receipt)
 			return receipt, nil
 		}
 	}
 	return nil, fmt.Errorf("all node is not working")
 }
 
+// GetTransactionReceipts gets the receipts of the transactions, the receipts which are not cached are got
+// in batch requests
+func (pro *EthereumSdkPro) GetTransactionReceipts(hashes []common.Hash) ([]*types.Receipt, error) {
+	receipts := make([]*types.Receipt, len(hashes))
+	missing, indexes := make([]common.Hash, 0), make([]int, 0)
+	for i, hash := range hashes {
+		if receipt, ok := pro.cached(_cache_receipt, hash.Hex()); ok {
+			receipts[i] = copyReceipt(receipt.(*types.Receipt))
+		} else {
+			missing, indexes = append(missing, hash), append(indexes, i)
+		}
+	}
+	if len(missing) == 0 {
+		return receipts, nil
+	}
+	info := pro.GetLatest()
+	if info == nil {
+		return nil, fmt.Errorf("all node is not working")
+	}
+
+	for info != nil {
+		fetched, err := info.sdk.BatchTransactionReceipt(missing)
+		if err != nil {
+			info = pro.reset(info, err)
+			continue
+		}
+		metrics.RpcBatchCalled(pro.id, len(missing))
+		for i, receipt := range fetched {
+			receipts[indexes[i]] = receipt
+			pro.cacheReceipt(missing[i], receipt)
+		}
+		return receipts, nil
+	}
+	return nil, fmt.Errorf("all node is not working")
+}
+
+func (pro *EthereumSdkPro) cacheReceipt(hash common.Hash, receipt *types.Receipt) {
+	if receipt != nil && receipt.BlockNumber != nil && pro.confirmed(receipt.BlockNumber.Uint64()) {
+		pro.setCache(_cache_receipt, hash.Hex(), copyReceipt(receipt), 0)
+	}
+}
+
+// copyReceipt copies the receipt with its logs, the cached receipts are shared by all callers
+func copyReceipt(receipt *types.Receipt) *types.Receipt {
+	cpy := *receipt
+	cpy.PostState = common.CopyBytes(receipt.PostState)
+	if receipt.BlockNumber != nil {
+		cpy.BlockNumber = new(big.Int).Set(receipt.BlockNumber)
+	}
+	if receipt.Logs != nil {
+		cpy.Logs = make([]*types.Log, len(receipt.Logs))
+		for i, log := range receipt.Logs {
+			item := *log
+			item.Topics = append([]common.Hash(nil), log.Topics...)
+			item.Data = common.CopyBytes(log.Data)
+			cpy.Logs[i] = &item
+		}
+	}
+	return &cpy
+}
+
+// FilterLogs gets the logs of the query in one eth_getLogs call
+func (pro *EthereumSdkPro) FilterLogs(query ethereum.FilterQuery) ([]types.Log, error) {
+	info := pro.GetLatest()
+	if info == nil {
+		return nil, fmt.Errorf("all node is not working")
+	}
+
+	for info != nil {
+		logs, err := info.sdk.FilterLogs(query)
+		if err != nil {
+			pro.selector.Failed(info.url, err)
+			info = pro.GetLatest()
+		} else {
+			return logs, nil
+		}
+	}
+	return nil, fmt.Errorf("all node is not working")
+}
+
+func (pro *EthereumSdkPro) GetTransactionGas(hash common.Hash) (uint64, *big.Int, error) {
+	if gas, ok := pro.cached(_cache_gas, hash.Hex()); ok {
+		return gas.(*TransactionGas).GasUsed, new(big.Int).Set(gas.(*TransactionGas).GasPrice), nil
+	}
+	info := pro.GetLatest()
+	if info == nil {
+		return 0, nil, fmt.Errorf("all node is not working")
+	}
+
+	for info != nil {
+		gasUsed, gasPrice, err := info.sdk.GetTransactionGas(hash)
+		if err != nil {
+			pro.selector.Failed(info.url, err)
+			info = pro.GetLatest()
+		} else {
+			return gasUsed, gasPrice, nil
+		}
+	}
+	return 0, nil, fmt.Errorf("all node is not working")
+}
+
+// GetTransactionsGas is GetTransactionGas of the transactions, the gases which are not cached are got in
+// batch requests
+func (pro *EthereumSdkPro) GetTransactionsGas(hashes []common.Hash) ([]*TransactionGas, error) {
+	gases := make([]*TransactionGas, len(hashes))
+	missing, indexes := make([]common.Hash, 0), make([]int, 0)
+	for i, hash := range hashes {
+		if gas, ok := pro.cached(_cache_gas, hash.Hex()); ok {
+			gases[i] = copyTransactionGas(gas.(*TransactionGas))
+		} else {
+			missing, indexes = append(missing, hash), append(indexes, i)
+		}
+	}
+	if len(missing) == 0 {
+		return gases, nil
+	}
+	info := pro.GetLatest()
+	if info == nil {
+		return nil, fmt.Errorf("all node is not working")
+	}
+
+	for info != nil {
+		fetched, err := info.sdk.BatchTransactionGas(missing)
+		if err != nil {
+			info = pro.reset(info, err)
+			continue
+		}
+		metrics.RpcBatchCalled(pro.id, len(missing))
+		for i, gas := range fetched {
+			gases[indexes[i]] = gas
+			if pro.confirmed(gas.BlockNumber) {
+				pro.setCache(_cache_gas, missing[i].Hex(), copyTransactionGas(gas), 0)
+			}
+		}
+		return gases, nil
+	}
+	return nil, fmt.Errorf("all node is not working")
+}
+
+func copyTransactionGas(gas *TransactionGas) *TransactionGas {
+	return &TransactionGas{GasUsed: gas.GasUsed, GasPrice: new(big.Int).Set(gas.GasPrice), BlockNumber: gas.BlockNumber}
+}
+
+// GetBlocksTransactions gets the blocks with their transactions in batch requests
+func (pro *EthereumSdkPro) GetBlocksTransactions(numbers []uint64) ([]*RpcBlock, error) {
+	info := pro.GetLatest()
+	if info == nil {
+		return nil, fmt.Errorf("all node is not working")
+	}
+
+	for info != nil {
+		blocks, err := info.sdk.BatchBlockTransactions(numbers)
+		if err != nil {
+			info = pro.reset(info, err)
+			continue
+		}
+		metrics.RpcBatchCalled(pro.id, len(numbers))
+		return blocks, nil
+	}
+	return nil, fmt.Errorf("all node is not working")
+}
+
+func (pro *EthereumSdkPro) GetRevertReason(tx *RpcTransaction, number uint64) (string, error) {
+	info := pro.GetLatest()
+	if info == nil {
+		return "", fmt.Errorf("all node is not working")
+	}
+
+	for info != nil {
+		reason, err := info.sdk.GetRevertReason(tx, number)
+		if err != nil {
+			pro.selector.Failed(info.url, err)
+			info = pro.GetLatest()
+		} else {
+			return reason, nil
+		}
+	}
+	return "", fmt.Errorf("all node is not working")
+}
+
 func (pro *EthereumSdkPro) NonceAt(addr common.Address) (uint64, error) {
 	info := pro.GetLatest()
 	if info == nil {
@@ -214,7 +499,7 @@ func (pro *EthereumSdkPro) NonceAt(addr common.Address) (uint64, error) {
 	for info != nil {
 		nonce, err := info.sdk.NonceAt(addr)
 		if err != nil {
-			info.latestHeight = 0
+			pro.selector.Failed(info.url, err)
 			info = pro.GetLatest()
 		} else {
 			return nonce, nil
@@ -232,7 +517,7 @@ func (pro *EthereumSdkPro) SendRawTransaction(tx *types.Transaction) error {
 	for info != nil {
 		err := info.sdk.SendRawTransaction(tx)
 		if err != nil {
-			info.latestHeight = 0
+			pro.selector.Failed(info.url, err)
 			info = pro.GetLatest()
 		} else {
 			return nil
@@ -250,7 +535,7 @@ func (pro *EthereumSdkPro) TransactionByHash(hash common.Hash) (*types.Transacti
 	for info != nil {
 		tx, isPending, err := info.sdk.TransactionByHash(hash)
 		if err != nil {
-			info.latestHeight = 0
+			pro.selector.Failed(info.url, err)
 			info = pro.GetLatest()
 		} else {
 			return tx, isPending, nil
@@ -268,7 +553,7 @@ func (pro *EthereumSdkPro) SuggestGasPrice() (*big.Int, error) {
 	for info != nil {
 		gas, err := info.sdk.SuggestGasPrice()
 		if err != nil {
-			info.latestHeight = 0
+			pro.selector.Failed(info.url, err)
 			info = pro.GetLatest()
 		} else {
 			return gas, nil
@@ -277,6 +562,27 @@ func (pro *EthereumSdkPro) SuggestGasPrice() (*big.Int, error) {
 	return nil, fmt.Errorf("all node is not working")
 }
 
+// FeeHistory gets the fee history of the latest blocks, an error answered by the node, such as a chain
+// without eip-1559 not knowing eth_feeHistory, is returned without failing the node
+func (pro *EthereumSdkPro) FeeHistory(blocks uint64, percentiles []float64) (*FeeHistory, error) {
+	info := pro.GetLatest()
+	if info == nil {
+		return nil, fmt.Errorf("all node is not working")
+	}
+
+	for info != nil {
+		history, err := info.sdk.FeeHistory(blocks, percentiles)
+		if err == nil {
+			return history, nil
+		}
+		if _, ok := err.(rpc.Error); ok {
+			return nil, err
+		}
+		info = pro.reset(info, err)
+	}
+	return nil, fmt.Errorf("all node is not working")
+}
+
 func (pro *EthereumSdkPro) EstimateGas(msg ethereum.CallMsg) (uint64, error) {
 	info := pro.GetLatest()
 	if info == nil {
@@ -285,12 +591,14 @@ func (pro *EthereumSdkPro) EstimateGas(msg ethereum.CallMsg) (uint64, error) {
 
 	for info != nil {
 		gas, err := info.sdk.EstimateGas(msg)
-		if err != nil {
-			info.latestHeight = 0
-			info = pro.GetLatest()
-		} else {
+		if err == nil {
 			return gas, nil
 		}
+		// a reverted call is answered by the node and is not the failure of the node
+		if _, ok := err.(rpc.Error); ok {
+			return 0, err
+		}
+		info = pro.reset(info, err)
 	}
 	return 0, fmt.Errorf("all node is not working")
 }
@@ -303,7 +611,7 @@ func (pro *EthereumSdkPro) Erc20Info(hash string) (string, string, int64, string
 	for info != nil {
 		hash, name, decimal, symbol, err := info.sdk.Erc20Info(hash)
 		if err != nil {
-			info.latestHeight = 0
+			pro.selector.Failed(info.url, err)
 			info = pro.GetLatest()
 		} else {
 			return hash, name, decimal, symbol, nil
@@ -321,6 +629,10 @@ func (pro *EthereumSdkPro) IsEthAddress(addr string) bool {
 }
 
 func (pro *EthereumSdkPro) Erc20Balance(erc20 string, addr string) (*big.Int, error) {
+	key := strings.ToLower(erc20 + "/" + addr)
+	if balance, ok := pro.cached(_cache_balance, key); ok {
+		return new(big.Int).Set(balance.(*big.Int)), nil
+	}
 	info := pro.GetLatest()
 	if info == nil {
 		return new(big.Int).SetUint64(0), fmt.Errorf("all node is not working")
@@ -338,9 +650,10 @@ func (pro *EthereumSdkPro) Erc20Balance(erc20 string, addr string) (*big.Int, er
 			//logs.Error("erc20, addr: %s, balance: %s", addr, balance.String())
 		}
 		if err != nil {
-			info.latestHeight = 0
+			pro.selector.Failed(info.url, err)
 			info = pro.GetLatest()
 		} else {
+			pro.setCache(_cache_balance, key, new(big.Int).Set(balance), ETHEREUM_CACHE_TTL)
 			return balance, nil
 		}
 	}
@@ -367,6 +680,10 @@ func (pro *EthereumSdkPro) WaitTransactionConfirm(hash common.Hash) bool {
 }
 
 func (pro *EthereumSdkPro) NFTBalance(asset, owner common.Address) (balance *big.Int, err error) {
+	key := asset.Hex() + "/" + owner.Hex()
+	if cached, ok := pro.cached(_cache_nft_balance, key); ok {
+		return new(big.Int).Set(cached.(*big.Int)), nil
+	}
 	info := pro.GetLatest()
 	if info == nil {
 		return nil, fmt.Errorf("all node is not working")
@@ -374,8 +691,9 @@ func (pro *EthereumSdkPro) NFTBalance(asset, owner common.Address) (balance *big
 
 	for info != nil {
 		if balance, err = info.sdk.GetNFTBalance(asset, owner); err != nil {
-			info = pro.reset(info)
+			info = pro.reset(info, err)
 		} else {
+			pro.setCache(_cache_nft_balance, key, new(big.Int).Set(balance), ETHEREUM_CACHE_TTL)
 			return
 		}
 	}
@@ -390,7 +708,7 @@ func (pro *EthereumSdkPro) GetNFTOwner(asset common.Address, tokenId *big.Int) (
 
 	for info != nil {
 		if owner, err = info.sdk.GetNFTOwner(asset, tokenId); err != nil {
-			info = pro.reset(info)
+			info = pro.reset(info, err)
 		} else {
 			return
 		}
@@ -403,6 +721,10 @@ func (pro *EthereumSdkPro) GetTokensByIndex(
 	start, length int,
 ) (res map[string]string, err error) {
 
+	key := fmt.Sprintf("%s/%s/%s/%d/%d", inquirer.Hex(), asset.Hex(), owner.Hex(), start, length)
+	if cached, ok := pro.cached(_cache_nft_tokens, key); ok {
+		return copyTokens(cached.(map[string]string)), nil
+	}
 	info := pro.GetLatest()
 	if info == nil {
 		return nil, fmt.Errorf("all node is not working")
@@ -410,14 +732,26 @@ func (pro *EthereumSdkPro) GetTokensByIndex(
 
 	for info != nil {
 		if res, err = info.sdk.GetOwnerNFTsByIndex(inquirer, asset, owner, start, length); err != nil {
-			info = pro.reset(info)
+			info = pro.reset(info, err)
 		} else {
+			pro.setCache(_cache_nft_tokens, key, copyTokens(res), ETHEREUM_CACHE_TTL)
 			return
 		}
 	}
 	return
 }
 
+func copyTokens(tokens map[string]string) map[string]string {
+	if tokens == nil {
+		return nil
+	}
+	res := make(map[string]string, len(tokens))
+	for id, url := range tokens {
+		res[id] = url
+	}
+	return res
+}
+
 func (pro *EthereumSdkPro) GetNFTUrl(asset common.Address, tokenId *big.Int) (url string, err error) {
 	info := pro.GetLatest()
 	if info == nil {
@@ -426,7 +760,7 @@ func (pro *EthereumSdkPro) GetNFTUrl(asset common.Address, tokenId *big.Int) (ur
 
 	for info != nil {
 		if url, err = info.sdk.GetNFTTokenUri(asset, tokenId); err != nil {
-			info = pro.reset(info)
+			info = pro.reset(info, err)
 		} else {
 			return
 		}
@@ -445,7 +779,7 @@ func (pro *EthereumSdkPro) GetTokensById(
 
 	for info != nil {
 		if res, err = info.sdk.GetNFTsById(inquirer, asset, tokenIdList); err != nil {
-			info = pro.reset(info)
+			info = pro.reset(info, err)
 		} else {
 			return
 		}
@@ -465,7 +799,7 @@ func (pro *EthereumSdkPro) GetAndCheckTokenUrl(
 
 	for info != nil {
 		if url, err = info.sdk.GetAndCheckNFTUrl(inquirer, asset, owner, tokenId); err != nil {
-			info = pro.reset(info)
+			info = pro.reset(info, err)
 		} else {
 			return
 		}
@@ -484,7 +818,7 @@ func (pro *EthereumSdkPro) GetUnCrossChainNFTsByIndex(
 	}
 	for info != nil {
 		if mp, err = info.sdk.GetUnCrossChainNFTsByIndex(inquirer, asset, lockProxy, start, length); err != nil {
-			info = pro.reset(info)
+			info = pro.reset(info, err)
 		} else {
 			return
 		}
@@ -499,7 +833,7 @@ func (pro *EthereumSdkPro) GetNFTURLs(asset common.Address, tokenIds []*big.Int)
 	}
 	for info != nil {
 		if res, err = info.sdk.GetOwnerNFTUrls(asset, tokenIds); err != nil {
-			info = pro.reset(info)
+			info = pro.reset(info, err)
 		} else {
 			return
 		}
@@ -507,7 +841,7 @@ func (pro *EthereumSdkPro) GetNFTURLs(asset common.Address, tokenIds []*big.Int)
 	return
 }
 
-func (pro *EthereumSdkPro) reset(info *EthereumInfo) *EthereumInfo {
-	info.latestHeight = 0
+func (pro *EthereumSdkPro) reset(info *EthereumInfo, err error) *EthereumInfo {
+	pro.selector.Failed(info.url, err)
 	return pro.GetLatest()
 }
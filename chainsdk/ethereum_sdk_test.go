@@ -2,17 +2,24 @@ package chainsdk
 
 import (
 	"encoding/hex"
+	"encoding/json"
 	"github.com/ethereum/go-ethereum/core/types"
+	"io/ioutil"
 	"math/big"
+	"net/http"
+	"net/http/httptest"
 	nftmp "poly-bridge/go_abi/nft_mapping_abi"
 	nftquery "poly-bridge/go_abi/nft_query_abi"
 	nftwrap "poly-bridge/go_abi/nft_wrap_abi"
 	pabi "poly-bridge/utils/abi"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -148,4 +155,160 @@ func TestTransactionHash(t *testing.T) {
 	gasPrice = big.NewInt(2000000000)
 	tx1 := types.NewTransaction(nonce, to, amount, gasLimit, gasPrice, nil)
 	t.Logf(tx1.Hash().Hex())
-}
\ No newline at end of file
+}
+
+type testRpcCall struct {
+	Id     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// testRpcNode is a json rpc node answering single and batch requests, a method returning an error
+// answers the call with the error
+type testRpcNode struct {
+	methods  map[string]func(params []json.RawMessage) (interface{}, error)
+	requests int
+	calls    map[string]int
+	lock     sync.Mutex
+}
+
+func newTestRpcNode(t *testing.T, methods map[string]func(params []json.RawMessage) (interface{}, error)) (*testRpcNode, *httptest.Server) {
+	node := &testRpcNode{methods: methods, calls: make(map[string]int)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		node.lock.Lock()
+		node.requests++
+		node.lock.Unlock()
+		if strings.HasPrefix(string(body), "[") {
+			calls := make([]*testRpcCall, 0)
+			assert.NoError(t, json.Unmarshal(body, &calls))
+			results := make([]interface{}, 0, len(calls))
+			for _, call := range calls {
+				results = append(results, node.answer(call))
+			}
+			json.NewEncoder(w).Encode(results)
+			return
+		}
+		call := &testRpcCall{}
+		assert.NoError(t, json.Unmarshal(body, call))
+		json.NewEncoder(w).Encode(node.answer(call))
+	}))
+	return node, server
+}
+
+func (node *testRpcNode) answer(call *testRpcCall) interface{} {
+	node.lock.Lock()
+	node.calls[call.Method]++
+	node.lock.Unlock()
+	result, err := node.methods[call.Method](call.Params)
+	if err != nil {
+		return map[string]interface{}{"jsonrpc": "2.0", "id": call.Id, "error": map[string]interface{}{"code": 3, "message": err.Error()}}
+	}
+	return map[string]interface{}{"jsonrpc": "2.0", "id": call.Id, "result": result}
+}
+
+func (node *testRpcNode) stats() (int, map[string]int) {
+	node.lock.Lock()
+	defer node.lock.Unlock()
+	calls := make(map[string]int)
+	for method, count := range node.calls {
+		calls[method] = count
+	}
+	return node.requests, calls
+}
+
+func testHeader(number uint64) map[string]interface{} {
+	data, _ := json.Marshal(&types.Header{Number: new(big.Int).SetUint64(number), Time: 1600000000 + number, Difficulty: big.NewInt(1)})
+	fields := make(map[string]interface{})
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+func TestBatch_HeaderByNumber(t *testing.T) {
+	node, server := newTestRpcNode(t, map[string]func(params []json.RawMessage) (interface{}, error){
+		"eth_getBlockByNumber": func(params []json.RawMessage) (interface{}, error) {
+			var number hexutil.Uint64
+			json.Unmarshal(params[0], &number)
+			return testHeader(uint64(number)), nil
+		},
+	})
+	defer server.Close()
+	sdk, err := NewEthereumSdk(server.URL)
+	assert.NoError(t, err)
+
+	numbers := make([]uint64, 0)
+	for number := uint64(1000); number < 1000+ETHEREUM_BATCH_SIZE+50; number++ {
+		numbers = append(numbers, number)
+	}
+	headers, err := sdk.BatchHeaderByNumber(numbers)
+	assert.NoError(t, err)
+	assert.Equal(t, len(numbers), len(headers))
+	for i, header := range headers {
+		assert.Equal(t, numbers[i], header.Number.Uint64())
+		assert.Equal(t, 1600000000+numbers[i], header.Time)
+	}
+	// the calls are split into batches of ETHEREUM_BATCH_SIZE
+	requests, calls := node.stats()
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, len(numbers), calls["eth_getBlockByNumber"])
+}
+
+func TestBatch_TransactionGas(t *testing.T) {
+	london, legacy := common.HexToHash("0x01"), common.HexToHash("0x02")
+	node, server := newTestRpcNode(t, map[string]func(params []json.RawMessage) (interface{}, error){
+		"eth_getTransactionReceipt": func(params []json.RawMessage) (interface{}, error) {
+			var hash common.Hash
+			json.Unmarshal(params[0], &hash)
+			receipt := map[string]interface{}{"gasUsed": "0x5208", "blockNumber": "0x64"}
+			if hash == london {
+				receipt["effectiveGasPrice"] = "0x3b9aca00"
+			}
+			return receipt, nil
+		},
+		"eth_getTransactionByHash": func(params []json.RawMessage) (interface{}, error) {
+			return map[string]interface{}{"gasPrice": "0x77359400"}, nil
+		},
+	})
+	defer server.Close()
+	sdk, err := NewEthereumSdk(server.URL)
+	assert.NoError(t, err)
+
+	gases, err := sdk.BatchTransactionGas([]common.Hash{london, legacy})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(21000), gases[0].GasUsed)
+	assert.Equal(t, uint64(100), gases[0].BlockNumber)
+	assert.Equal(t, "1000000000", gases[0].GasPrice.String())
+	assert.Equal(t, "2000000000", gases[1].GasPrice.String())
+	// only the legacy transaction is read for its gas price
+	requests, calls := node.stats()
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, 1, calls["eth_getTransactionByHash"])
+}
+
+func TestBatch_NFTUrls(t *testing.T) {
+	mappingAbi, err := abi.JSON(strings.NewReader(nftmp.CrossChainNFTMappingABI))
+	assert.NoError(t, err)
+	node, server := newTestRpcNode(t, map[string]func(params []json.RawMessage) (interface{}, error){
+		"eth_call": func(params []json.RawMessage) (interface{}, error) {
+			call := struct {
+				Data hexutil.Bytes `json:"data"`
+			}{}
+			json.Unmarshal(params[0], &call)
+			tokenId := new(big.Int).SetBytes(call.Data[4:])
+			if tokenId.Uint64() == 2 {
+				return nil, rpc.ErrNoResult
+			}
+			output, _ := mappingAbi.Methods["tokenURI"].Outputs.Pack("https://nft/" + tokenId.String())
+			return hexutil.Bytes(output), nil
+		},
+	})
+	defer server.Close()
+	sdk, err := NewEthereumSdk(server.URL)
+	assert.NoError(t, err)
+
+	urls, err := sdk.GetOwnerNFTUrls(common.HexToAddress("0x10"), []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"1": "https://nft/1", "3": "https://nft/3"}, urls)
+	requests, _ := node.stats()
+	assert.Equal(t, 1, requests)
+}
@@ -18,7 +18,12 @@
 package chainsdk
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"time"
+
 	"github.com/astaxie/beego/logs"
 	"github.com/joeqian10/neo-gogogo/helper"
 	"github.com/joeqian10/neo-gogogo/nep5"
@@ -27,19 +32,47 @@ import (
 )
 
 type NeoSdk struct {
-	client *rpc.RpcClient
-	url    string
+	httpClient *http.Client
+	url        string
 }
 
 func NewNeoSdk(url string) *NeoSdk {
+	httpClient := newRpcHttpClient()
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: time.Second * 60}
+	}
 	return &NeoSdk{
-		client: rpc.NewClient(url),
-		url:    url,
+		httpClient: httpClient,
+		url:        url,
 	}
 }
 
+// call sends a json rpc request through the http client of the sdk, the rpc client of neo-gogogo has no way
+// to set its http client
+func (sdk *NeoSdk) call(method string, params []interface{}, out interface{}) error {
+	body, err := json.Marshal(rpc.NewRequest(method, params))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", sdk.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Close = true
+	resp, err := sdk.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
 func (sdk *NeoSdk) GetBlockCount() (uint64, error) {
-	res := sdk.client.GetBlockCount()
+	res := rpc.GetBlockCountResponse{}
+	if err := sdk.call("getblockcount", []interface{}{}, &res); err != nil {
+		return 0, err
+	}
 	if res.ErrorResponse.Error.Message != "" {
 		return 0, fmt.Errorf("%s", res.ErrorResponse.Error.Message)
 	}
@@ -47,7 +80,10 @@ func (sdk *NeoSdk) GetBlockCount() (uint64, error) {
 }
 
 func (sdk *NeoSdk) GetBlockByIndex(index uint64) (*models.RpcBlock, error) {
-	res := sdk.client.GetBlockByIndex(uint32(index))
+	res := rpc.GetBlockResponse{}
+	if err := sdk.call("getblock", []interface{}{uint32(index), 1}, &res); err != nil {
+		return nil, err
+	}
 	if res.ErrorResponse.Error.Message != "" {
 		return nil, fmt.Errorf("%s", res.ErrorResponse.Error.Message)
 	}
@@ -55,7 +91,10 @@ func (sdk *NeoSdk) GetBlockByIndex(index uint64) (*models.RpcBlock, error) {
 }
 
 func (sdk *NeoSdk) GetApplicationLog(txId string) (*models.RpcApplicationLog, error) {
-	res := sdk.client.GetApplicationLog(txId)
+	res := rpc.GetApplicationLogResponse{}
+	if err := sdk.call("getapplicationlog", []interface{}{txId}, &res); err != nil {
+		return nil, err
+	}
 	if res.ErrorResponse.Error.Message != "" {
 		return nil, fmt.Errorf("%s", res.ErrorResponse.Error.Message)
 	}
@@ -63,7 +102,10 @@ func (sdk *NeoSdk) GetApplicationLog(txId string) (*models.RpcApplicationLog, er
 }
 
 func (sdk *NeoSdk) GetTransactionHeight(hash string) (uint64, error) {
-	res := sdk.client.GetTransactionHeight(hash)
+	res := rpc.GetTransactionHeightResponse{}
+	if err := sdk.call("gettransactionheight", []interface{}{hash}, &res); err != nil {
+		return 0, err
+	}
 	if res.ErrorResponse.Error.Message != "" {
 		return 0, fmt.Errorf("%s", res.ErrorResponse.Error.Message)
 	}
@@ -71,7 +113,10 @@ func (sdk *NeoSdk) GetTransactionHeight(hash string) (uint64, error) {
 }
 
 func (sdk *NeoSdk) SendRawTransaction(txHex string) (bool, error) {
-	res := sdk.client.SendRawTransaction(txHex)
+	res := rpc.SendRawTransactionResponse{}
+	if err := sdk.call("sendrawtransaction", []interface{}{txHex, 1}, &res); err != nil {
+		return false, err
+	}
 	if res.HasError() {
 		return false, fmt.Errorf("%s", res.ErrorResponse.Error.Message)
 	}
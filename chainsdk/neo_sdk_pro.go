@@ -24,20 +24,19 @@ import (
 	"math/big"
 	"runtime/debug"
 	"strings"
-	"sync"
 	"time"
 )
 
 type NeoInfo struct {
-	sdk          *NeoSdk
-	latestHeight uint64
+	sdk *NeoSdk
+	url string
 }
 
 func NewNeoInfo(url string) *NeoInfo {
 	sdk := NewNeoSdk(url)
 	return &NeoInfo{
-		sdk:          sdk,
-		latestHeight: 0,
+		sdk: sdk,
+		url: url,
 	}
 }
 
@@ -45,7 +44,7 @@ type NeoSdkPro struct {
 	infos         map[string]*NeoInfo
 	selectionSlot uint64
 	id            uint64
-	mutex         sync.Mutex
+	selector      *NodeSelector
 }
 
 func NewNeoSdkPro(urls []string, slot uint64, id uint64) *NeoSdkPro {
@@ -53,7 +52,7 @@ func NewNeoSdkPro(urls []string, slot uint64, id uint64) *NeoSdkPro {
 	for _, url := range urls {
 		infos[url] = NewNeoInfo(url)
 	}
-	pro := &NeoSdkPro{infos: infos, selectionSlot: slot, id: id}
+	pro := &NeoSdkPro{infos: infos, selectionSlot: slot, id: id, selector: NewNodeSelector(id, urls)}
 	pro.selection()
 	go pro.NodeSelection()
 	return pro
@@ -82,39 +81,28 @@ func (pro *NeoSdkPro) nodeSelection() {
 }
 
 func (pro *NeoSdkPro) selection() {
-	for url, info := range pro.infos {
-		height, err := info.sdk.GetBlockCount()
-		if err != nil {
-			logs.Error("get current block height err: %v, url: %s", err, url)
-		}
-		pro.mutex.Lock()
-		info.latestHeight = height
-		pro.mutex.Unlock()
-	}
+	pro.selector.Poll(func(url string) (uint64, error) {
+		height, err := pro.infos[url].sdk.GetBlockCount()
+		return height, err
+	})
 }
 
 func (pro *NeoSdkPro) GetLatest() *NeoInfo {
-	pro.mutex.Lock()
-	defer func() {
-		pro.mutex.Unlock()
-	}()
-	height := uint64(0)
-	var latestInfo *NeoInfo = nil
-	for _, info := range pro.infos {
-		if info != nil && info.latestHeight > height {
-			height = info.latestHeight
-			latestInfo = info
-		}
-	}
-	return latestInfo
+	url, _ := pro.selector.Select()
+	return pro.infos[url]
+}
+
+// NodeStats returns the stats of the nodes of the sdk
+func (pro *NeoSdkPro) NodeStats() []*NodeStats {
+	return pro.selector.Stats()
 }
 
 func (pro *NeoSdkPro) GetBlockCount() (uint64, error) {
-	info := pro.GetLatest()
-	if info == nil {
+	url, height := pro.selector.Select()
+	if url == "" {
 		return 0, fmt.Errorf("all node is not working")
 	}
-	return info.latestHeight, nil
+	return height, nil
 }
 
 func (pro *NeoSdkPro) GetBlockByIndex(index uint64) (*models.RpcBlock, error) {
@@ -125,7 +113,7 @@ func (pro *NeoSdkPro) GetBlockByIndex(index uint64) (*models.RpcBlock, error) {
 	for info != nil {
 		block, err := info.sdk.GetBlockByIndex(index)
 		if err != nil {
-			info.latestHeight = 0
+			pro.selector.Failed(info.url, err)
 			info = pro.GetLatest()
 		} else {
 			return block, nil
@@ -142,7 +130,7 @@ func (pro *NeoSdkPro) GetApplicationLog(txId string) (*models.RpcApplicationLog,
 	for info != nil {
 		log, err := info.sdk.GetApplicationLog(txId)
 		if err != nil && !strings.Contains(err.Error(), "json: cannot") {
-			info.latestHeight = 0
+			pro.selector.Failed(info.url, err)
 			info = pro.GetLatest()
 		} else {
 			return log, nil
@@ -159,7 +147,7 @@ func (pro *NeoSdkPro) Nep5Info(hash string) (string, string, int64, error) {
 	for info != nil {
 		hash, name, decimal, err := info.sdk.Nep5Info(hash)
 		if err != nil {
-			info.latestHeight = 0
+			pro.selector.Failed(info.url, err)
 			info = pro.GetLatest()
 		} else {
 			return hash, name, decimal, nil
@@ -176,7 +164,7 @@ func (pro *NeoSdkPro) Nep5Balance(hash string, addr string) (*big.Int, error) {
 	for info != nil {
 		balance, err := info.sdk.Nep5Balance(hash, addr)
 		if err != nil {
-			info.latestHeight = 0
+			pro.selector.Failed(info.url, err)
 			info = pro.GetLatest()
 		} else {
 			return new(big.Int).SetUint64(balance), nil
@@ -193,7 +181,7 @@ func (pro *NeoSdkPro) GetTransactionHeight(hash string) (uint64, error) {
 	for info != nil {
 		height, err := info.sdk.GetTransactionHeight(hash)
 		if err != nil || height == 0 {
-			info.latestHeight = 0
+			pro.selector.Failed(info.url, err)
 			info = pro.GetLatest()
 		} else {
 			return height, nil
@@ -210,7 +198,7 @@ func (pro *NeoSdkPro) SendRawTransaction(txHex string) (bool, error) {
 	for info != nil {
 		result, err := info.sdk.SendRawTransaction(txHex)
 		if err != nil || !result {
-			info.latestHeight = 0
+			pro.selector.Failed(info.url, err)
 			info = pro.GetLatest()
 		} else {
 			return result, nil
@@ -0,0 +1,276 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package chainsdk
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"poly-bridge/metrics"
+
+	"github.com/astaxie/beego/logs"
+)
+
+const (
+	NODE_CLOSED    = "closed"
+	NODE_OPEN      = "open"
+	NODE_HALF_OPEN = "half_open"
+)
+
+const (
+	DEFAULT_MAX_FAILURES   = 3
+	DEFAULT_MIN_COOLDOWN   = time.Second * 30
+	DEFAULT_MAX_COOLDOWN   = time.Minute * 10
+	DEFAULT_HEIGHT_WEIGHT  = 1.0
+	DEFAULT_LATENCY_WEIGHT = 10.0
+	DEFAULT_ERROR_WEIGHT   = 20.0
+	DEFAULT_SWITCH_MARGIN  = 2.0
+	_latency_smoothing     = 0.3
+	_error_smoothing       = 0.1
+)
+
+// NodeStats is the state of a node observed by the selector
+type NodeStats struct {
+	Url       string
+	State     string
+	Height    uint64
+	Latency   time.Duration
+	ErrorRate float64
+	Failures  int
+	Selected  bool
+}
+
+type nodeState struct {
+	url       string
+	host      string
+	height    uint64
+	latency   float64
+	errorRate float64
+	failures  int
+	opens     uint
+	state     string
+	openUntil time.Time
+	suspended bool
+}
+
+// NodeSelector selects the node of a chain used by the sdk. The nodes are polled for their heights, and a
+// node is scored by how far it is behind the highest node, its latency and its error rate. The node in use
+// is kept unless another node scores better by more than SwitchMargin, so that a flaky node does not
+// cause the sdk to switch on every poll.
+//
+// A node whose request fails is not selected until it is polled successfully again. After MaxFailures
+// failures in a row its circuit is open and it is not polled for a cooldown, which doubles every time the
+// circuit opens again. Once the cooldown elapses the circuit is half open and the next poll probes the
+// node: the circuit is closed if the node answers, or opened again if not.
+type NodeSelector struct {
+	MaxFailures   int
+	MinCooldown   time.Duration
+	MaxCooldown   time.Duration
+	HeightWeight  float64
+	LatencyWeight float64
+	ErrorWeight   float64
+	SwitchMargin  float64
+	id            uint64
+	nodes         []*nodeState
+	current       *nodeState
+	mutex         sync.Mutex
+}
+
+func NewNodeSelector(id uint64, urls []string) *NodeSelector {
+	nodes := make([]*nodeState, 0, len(urls))
+	added := make(map[string]bool)
+	for _, item := range urls {
+		if added[item] {
+			continue
+		}
+		added[item] = true
+		host := item
+		if u, err := url.Parse(item); err == nil && u.Host != "" {
+			host = u.Host
+		}
+		nodes = append(nodes, &nodeState{url: item, host: host, state: NODE_CLOSED})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].url < nodes[j].url
+	})
+	return &NodeSelector{
+		MaxFailures:   DEFAULT_MAX_FAILURES,
+		MinCooldown:   DEFAULT_MIN_COOLDOWN,
+		MaxCooldown:   DEFAULT_MAX_COOLDOWN,
+		HeightWeight:  DEFAULT_HEIGHT_WEIGHT,
+		LatencyWeight: DEFAULT_LATENCY_WEIGHT,
+		ErrorWeight:   DEFAULT_ERROR_WEIGHT,
+		SwitchMargin:  DEFAULT_SWITCH_MARGIN,
+		id:            id,
+		nodes:         nodes,
+	}
+}
+
+// Poll gets the heights of the nodes, the nodes whose circuit is open are skipped until the cooldown elapses
+func (s *NodeSelector) Poll(getHeight func(url string) (uint64, error)) {
+	for _, node := range s.nodes {
+		s.mutex.Lock()
+		if node.state == NODE_OPEN {
+			if time.Now().Before(node.openUntil) {
+				s.mutex.Unlock()
+				continue
+			}
+			node.state = NODE_HALF_OPEN
+			logs.Info("probe node of chain: %d, url: %s", s.id, node.url)
+		}
+		s.mutex.Unlock()
+		start := time.Now()
+		height, err := getHeight(node.url)
+		latency := time.Since(start)
+		if err == nil && height == 0 {
+			err = fmt.Errorf("node height is 0")
+		}
+		if err != nil {
+			logs.Error("get current block height err: %v, url: %s", err, node.url)
+			s.Failed(node.url, err)
+			continue
+		}
+		s.mutex.Lock()
+		node.height = height
+		if node.latency == 0 {
+			node.latency = float64(latency)
+		} else {
+			node.latency = smooth(node.latency, float64(latency), _latency_smoothing)
+		}
+		node.errorRate = smooth(node.errorRate, 0, _error_smoothing)
+		node.failures = 0
+		node.suspended = false
+		if node.state == NODE_HALF_OPEN {
+			logs.Info("close circuit of node of chain: %d, url: %s", s.id, node.url)
+			node.state = NODE_CLOSED
+			node.opens = 0
+		}
+		s.report(node)
+		s.mutex.Unlock()
+	}
+}
+
+// Failed records a failed request to the node, the node is not selected until it is polled successfully
+func (s *NodeSelector) Failed(url string, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	node := s.node(url)
+	if node == nil {
+		return
+	}
+	node.errorRate = smooth(node.errorRate, 1, _error_smoothing)
+	node.failures++
+	node.suspended = true
+	if node.state == NODE_HALF_OPEN || (node.state == NODE_CLOSED && node.failures >= s.MaxFailures) {
+		cooldown := s.MinCooldown * time.Duration(math.Pow(2, float64(node.opens)))
+		if cooldown > s.MaxCooldown || cooldown <= 0 {
+			cooldown = s.MaxCooldown
+		}
+		node.opens++
+		node.state = NODE_OPEN
+		node.openUntil = time.Now().Add(cooldown)
+		logs.Error("open circuit of node of chain: %d, url: %s, failures: %d, cooldown: %s, err: %v", s.id, url, node.failures, cooldown, err)
+	}
+	s.report(node)
+}
+
+// Select returns the url and the height of the best node, the url is empty if no node is working
+func (s *NodeSelector) Select() (string, uint64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	maxHeight := uint64(0)
+	for _, node := range s.nodes {
+		if s.available(node) && node.height > maxHeight {
+			maxHeight = node.height
+		}
+	}
+	var best *nodeState
+	bestScore := 0.0
+	for _, node := range s.nodes {
+		if !s.available(node) {
+			continue
+		}
+		if score := s.score(node, maxHeight); best == nil || score < bestScore {
+			best, bestScore = node, score
+		}
+	}
+	if best == nil {
+		return "", 0
+	}
+	if s.current != nil && s.current != best && s.available(s.current) && s.score(s.current, maxHeight) <= bestScore+s.SwitchMargin {
+		best = s.current
+	}
+	if best != s.current {
+		if s.current != nil {
+			metrics.NodeSwitched(s.id)
+			logs.Info("switch node of chain: %d, from: %s, to: %s", s.id, s.current.url, best.url)
+		}
+		s.current = best
+	}
+	return best.url, best.height
+}
+
+// Stats returns the stats of the nodes
+func (s *NodeSelector) Stats() []*NodeStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	stats := make([]*NodeStats, 0, len(s.nodes))
+	for _, node := range s.nodes {
+		stats = append(stats, &NodeStats{
+			Url:       node.url,
+			State:     node.state,
+			Height:    node.height,
+			Latency:   time.Duration(node.latency),
+			ErrorRate: node.errorRate,
+			Failures:  node.failures,
+			Selected:  node == s.current,
+		})
+	}
+	return stats
+}
+
+func (s *NodeSelector) node(url string) *nodeState {
+	for _, node := range s.nodes {
+		if node.url == url {
+			return node
+		}
+	}
+	return nil
+}
+
+func (s *NodeSelector) available(node *nodeState) bool {
+	return node.state == NODE_CLOSED && !node.suspended && node.height > 0
+}
+
+// score is lower for the better node, in blocks behind the highest node
+func (s *NodeSelector) score(node *nodeState, maxHeight uint64) float64 {
+	return float64(maxHeight-node.height)*s.HeightWeight +
+		time.Duration(node.latency).Seconds()*s.LatencyWeight +
+		node.errorRate*s.ErrorWeight
+}
+
+func (s *NodeSelector) report(node *nodeState) {
+	metrics.SetNodeStats(s.id, node.host, time.Duration(node.latency), node.errorRate, node.state == NODE_OPEN)
+}
+
+func smooth(average float64, value float64, weight float64) float64 {
+	return average*(1-weight) + value*weight
+}
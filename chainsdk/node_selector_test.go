@@ -0,0 +1,128 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package chainsdk
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testNodes map[string]uint64
+
+func (nodes testNodes) height(url string) (uint64, error) {
+	height, ok := nodes[url]
+	if !ok || height == 0 {
+		return 0, fmt.Errorf("node %s is down", url)
+	}
+	return height, nil
+}
+
+func TestNodeSelector_Select(t *testing.T) {
+	nodes := testNodes{"http://a": 100, "http://b": 101, "http://c": 90}
+	selector := NewNodeSelector(2, []string{"http://a", "http://b", "http://c", "http://a"})
+	selector.Poll(nodes.height)
+	url, height := selector.Select()
+	assert.Equal(t, "http://b", url)
+	assert.Equal(t, uint64(101), height)
+
+	// the node in use is kept unless another node is better by the margin
+	nodes["http://a"] = 102
+	selector.Poll(nodes.height)
+	url, _ = selector.Select()
+	assert.Equal(t, "http://b", url)
+	nodes["http://a"] = 110
+	selector.Poll(nodes.height)
+	url, height = selector.Select()
+	assert.Equal(t, "http://a", url)
+	assert.Equal(t, uint64(110), height)
+	assert.Equal(t, 3, len(selector.Stats()))
+}
+
+func TestNodeSelector_Failed(t *testing.T) {
+	nodes := testNodes{"http://a": 100, "http://b": 99}
+	selector := NewNodeSelector(2, []string{"http://a", "http://b"})
+	selector.Poll(nodes.height)
+	url, _ := selector.Select()
+	assert.Equal(t, "http://a", url)
+
+	// a failed node is not selected until it is polled again
+	selector.Failed("http://a", fmt.Errorf("timeout"))
+	url, _ = selector.Select()
+	assert.Equal(t, "http://b", url)
+	selector.Failed("http://b", fmt.Errorf("timeout"))
+	url, _ = selector.Select()
+	assert.Equal(t, "", url)
+	selector.Poll(nodes.height)
+	url, _ = selector.Select()
+	assert.Equal(t, "http://b", url)
+}
+
+func TestNodeSelector_Circuit(t *testing.T) {
+	nodes := testNodes{"http://a": 100, "http://b": 90}
+	selector := NewNodeSelector(2, []string{"http://a", "http://b"})
+	selector.MinCooldown = time.Millisecond * 50
+	selector.Poll(nodes.height)
+	for i := 0; i < selector.MaxFailures; i++ {
+		selector.Failed("http://a", fmt.Errorf("timeout"))
+	}
+	stats := selector.Stats()
+	assert.Equal(t, NODE_OPEN, stats[0].State)
+	assert.Equal(t, 3, stats[0].Failures)
+
+	// the open node is not polled in the cooldown
+	selector.Poll(nodes.height)
+	url, _ := selector.Select()
+	assert.Equal(t, "http://b", url)
+	assert.Equal(t, NODE_OPEN, selector.Stats()[0].State)
+
+	// the probe fails and the circuit is open for a longer cooldown
+	time.Sleep(time.Millisecond * 60)
+	nodes["http://a"] = 0
+	selector.Poll(nodes.height)
+	assert.Equal(t, NODE_OPEN, selector.Stats()[0].State)
+	time.Sleep(time.Millisecond * 60)
+	nodes["http://a"] = 120
+	selector.Poll(nodes.height)
+	assert.Equal(t, NODE_OPEN, selector.Stats()[0].State)
+
+	// the probe succeeds and the circuit is closed
+	time.Sleep(time.Millisecond * 50)
+	selector.Poll(nodes.height)
+	assert.Equal(t, NODE_CLOSED, selector.Stats()[0].State)
+	url, height := selector.Select()
+	assert.Equal(t, "http://a", url)
+	assert.Equal(t, uint64(120), height)
+}
+
+func TestNodeSelector_ErrorRate(t *testing.T) {
+	nodes := testNodes{"http://a": 101, "http://b": 100}
+	selector := NewNodeSelector(2, []string{"http://a", "http://b"})
+	// the node ahead fails every other request
+	for i := 0; i < 10; i++ {
+		selector.Poll(nodes.height)
+		selector.Failed("http://a", fmt.Errorf("timeout"))
+	}
+	selector.Poll(nodes.height)
+	url, _ := selector.Select()
+	assert.Equal(t, "http://b", url)
+	stats := selector.Stats()
+	assert.True(t, stats[0].ErrorRate > stats[1].ErrorRate)
+	assert.Equal(t, NODE_CLOSED, stats[0].State)
+}
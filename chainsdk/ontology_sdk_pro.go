@@ -26,21 +26,23 @@ import (
 	"math/big"
 	"runtime/debug"
 	common1 "github.com/ontio/ontology/common"
-	"sync"
 	"time"
 )
 
 type OntologyInfo struct {
-	sdk          *ontology_go_sdk.OntologySdk
-	latestHeight uint64
+	sdk *ontology_go_sdk.OntologySdk
+	url string
 }
 
 func NewOntologyInfo(url string) *OntologyInfo {
 	sdk := ontology_go_sdk.NewOntologySdk()
-	sdk.NewRpcClient().SetAddress(url)
+	client := sdk.NewRpcClient().SetAddress(url)
+	if httpClient := newRpcHttpClient(); httpClient != nil {
+		client.SetHttpClient(httpClient)
+	}
 	return &OntologyInfo{
-		sdk:          sdk,
-		latestHeight: 0,
+		sdk: sdk,
+		url: url,
 	}
 }
 
@@ -48,7 +50,7 @@ type OntologySdkPro struct {
 	infos         map[string]*OntologyInfo
 	selectionSlot uint64
 	id            uint64
-	mutex         sync.Mutex
+	selector      *NodeSelector
 }
 
 func NewOntologySdkPro(urls []string, slot uint64, id uint64) *OntologySdkPro {
@@ -56,7 +58,7 @@ func NewOntologySdkPro(urls []string, slot uint64, id uint64) *OntologySdkPro {
 	for _, url := range urls {
 		infos[url] = NewOntologyInfo(url)
 	}
-	pro := &OntologySdkPro{infos: infos, selectionSlot: slot, id: id}
+	pro := &OntologySdkPro{infos: infos, selectionSlot: slot, id: id, selector: NewNodeSelector(id, urls)}
 	pro.selection()
 	go pro.NodeSelection()
 	return pro
@@ -85,39 +87,28 @@ func (pro *OntologySdkPro) nodeSelection() {
 }
 
 func (pro *OntologySdkPro) selection() {
-	for url, info := range pro.infos {
-		height, err := info.sdk.GetCurrentBlockHeight()
-		if err != nil {
-			logs.Error("get current block height err: %v, url: %s", err, url)
-		}
-		pro.mutex.Lock()
-		info.latestHeight = uint64(height)
-		pro.mutex.Unlock()
-	}
+	pro.selector.Poll(func(url string) (uint64, error) {
+		height, err := pro.infos[url].sdk.GetCurrentBlockHeight()
+		return uint64(height), err
+	})
 }
 
 func (pro *OntologySdkPro) GetLatest() *OntologyInfo {
-	pro.mutex.Lock()
-	defer func() {
-		pro.mutex.Unlock()
-	}()
-	height := uint64(0)
-	var latestInfo *OntologyInfo = nil
-	for _, info := range pro.infos {
-		if info != nil && info.latestHeight > height {
-			height = info.latestHeight
-			latestInfo = info
-		}
-	}
-	return latestInfo
+	url, _ := pro.selector.Select()
+	return pro.infos[url]
+}
+
+// NodeStats returns the stats of the nodes of the sdk
+func (pro *OntologySdkPro) NodeStats() []*NodeStats {
+	return pro.selector.Stats()
 }
 
 func (pro *OntologySdkPro) GetCurrentBlockHeight() (uint64, error) {
-	info := pro.GetLatest()
-	if info == nil {
+	url, height := pro.selector.Select()
+	if url == "" {
 		return 0, fmt.Errorf("all node is not working")
 	}
-	return info.latestHeight, nil
+	return height, nil
 }
 
 func (pro *OntologySdkPro) GetBlockByHeight(height uint32) (*types.Block, error) {
@@ -30,7 +30,10 @@ type PolySDK struct {
 
 func NewPolySDK(url string) *PolySDK {
 	rawsdk := poly_go_sdk.NewPolySdk()
-	rawsdk.NewRpcClient().SetAddress(url)
+	client := rawsdk.NewRpcClient().SetAddress(url)
+	if httpClient := newRpcHttpClient(); httpClient != nil {
+		client.SetHttpClient(httpClient)
+	}
 	return &PolySDK{
 		sdk: rawsdk,
 		url: url,
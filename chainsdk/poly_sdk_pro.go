@@ -23,20 +23,19 @@ import (
 	"github.com/polynetwork/poly-go-sdk/common"
 	"github.com/polynetwork/poly/core/types"
 	"runtime/debug"
-	"sync"
 	"time"
 )
 
 type PolyInfo struct {
-	sdk          *PolySDK
-	latestHeight uint64
+	sdk *PolySDK
+	url string
 }
 
 func NewPolyInfo(url string) *PolyInfo {
 	sdk := NewPolySDK(url)
 	return &PolyInfo{
-		sdk:          sdk,
-		latestHeight: 0,
+		sdk: sdk,
+		url: url,
 	}
 }
 
@@ -44,7 +43,7 @@ type PolySDKPro struct {
 	infos         map[string]*PolyInfo
 	selectionSlot uint64
 	id            uint64
-	mutex         sync.Mutex
+	selector      *NodeSelector
 }
 
 func NewPolySDKPro(urls []string, slot uint64, id uint64) *PolySDKPro {
@@ -52,7 +51,7 @@ func NewPolySDKPro(urls []string, slot uint64, id uint64) *PolySDKPro {
 	for _, url := range urls {
 		infos[url] = NewPolyInfo(url)
 	}
-	pro := &PolySDKPro{infos: infos, selectionSlot: slot, id: id}
+	pro := &PolySDKPro{infos: infos, selectionSlot: slot, id: id, selector: NewNodeSelector(id, urls)}
 	pro.selection()
 	go pro.NodeSelection()
 	return pro
@@ -81,39 +80,28 @@ func (pro *PolySDKPro) nodeSelection() {
 }
 
 func (pro *PolySDKPro) selection() {
-	for url, info := range pro.infos {
-		height, err := info.sdk.GetCurrentBlockHeight()
-		if err != nil {
-			logs.Error("get current block height err: %v, url: %s", err, url)
-		}
-		pro.mutex.Lock()
-		info.latestHeight = height
-		pro.mutex.Unlock()
-	}
+	pro.selector.Poll(func(url string) (uint64, error) {
+		height, err := pro.infos[url].sdk.GetCurrentBlockHeight()
+		return height, err
+	})
 }
 
 func (pro *PolySDKPro) GetLatest() *PolyInfo {
-	pro.mutex.Lock()
-	defer func() {
-		pro.mutex.Unlock()
-	}()
-	height := uint64(0)
-	var latestInfo *PolyInfo = nil
-	for _, info := range pro.infos {
-		if info != nil && info.latestHeight > height {
-			height = info.latestHeight
-			latestInfo = info
-		}
-	}
-	return latestInfo
+	url, _ := pro.selector.Select()
+	return pro.infos[url]
+}
+
+// NodeStats returns the stats of the nodes of the sdk
+func (pro *PolySDKPro) NodeStats() []*NodeStats {
+	return pro.selector.Stats()
 }
 
 func (pro *PolySDKPro) GetCurrentBlockHeight() (uint64, error) {
-	info := pro.GetLatest()
-	if info == nil {
+	url, height := pro.selector.Select()
+	if url == "" {
 		return 0, fmt.Errorf("all node is not working")
 	}
-	return info.latestHeight, nil
+	return height, nil
 }
 
 func (pro *PolySDKPro) GetBlockByHeight(height uint64) (*types.Block, error) {
@@ -124,7 +112,7 @@ func (pro *PolySDKPro) GetBlockByHeight(height uint64) (*types.Block, error) {
 	for info != nil {
 		block, err := info.sdk.GetBlockByHeight(height)
 		if err != nil {
-			info.latestHeight = 0
+			pro.selector.Failed(info.url, err)
 			info = pro.GetLatest()
 		} else {
 			return block, nil
@@ -141,7 +129,7 @@ func (pro *PolySDKPro) GetSmartContractEventByBlock(height uint64) ([]*common.Sm
 	for info != nil {
 		event, err := info.sdk.GetSmartContractEventByBlock(height)
 		if err != nil {
-			info.latestHeight = 0
+			pro.selector.Failed(info.url, err)
 			info = pro.GetLatest()
 		} else {
 			return event, nil
@@ -0,0 +1,47 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package chainsdk
+
+import (
+	"net/http"
+	"sync"
+)
+
+var (
+	rpcTransport  http.RoundTripper
+	transportLock sync.Mutex
+)
+
+// SetRpcTransport makes the sdks created afterwards send their rpc requests through transport, it is used
+// to record and replay the rpc of the listeners in tests. Only the rpc clients of the sdks use it, the other
+// http clients of the process keep http.DefaultTransport. A nil transport restores the default one.
+func SetRpcTransport(transport http.RoundTripper) {
+	transportLock.Lock()
+	defer transportLock.Unlock()
+	rpcTransport = transport
+}
+
+// newRpcHttpClient returns nil when no transport is set and the sdk should use its own http client.
+func newRpcHttpClient() *http.Client {
+	transportLock.Lock()
+	defer transportLock.Unlock()
+	if rpcTransport == nil {
+		return nil
+	}
+	return &http.Client{Transport: rpcTransport}
+}
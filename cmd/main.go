@@ -22,6 +22,8 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"poly-bridge/admin"
+	"poly-bridge/alert"
 	"poly-bridge/chainfeelisten"
 	"poly-bridge/coinpricelisten"
 	"poly-bridge/common"
@@ -29,7 +31,9 @@ import (
 	"poly-bridge/crosschaineffect"
 	"poly-bridge/crosschainlisten"
 	"poly-bridge/crosschainstats"
+	"poly-bridge/metrics"
 	"runtime"
+	"strconv"
 	"strings"
 	"syscall"
 
@@ -55,6 +59,13 @@ var (
 		Usage: "log directory",
 		Value: "./Log/",
 	}
+
+	adminCommand = cli.Command{
+		Name:      "admin",
+		Usage:     "Control the services of the running server by its AdminConfig",
+		ArgsUsage: "status [name] | pause <name> | resume <name> | height <name> <height> | skip <name> <height>",
+		Action:    adminAction,
+	}
 )
 
 //getFlagName deal with short flag, and return the flag name whether flag name have short name
@@ -77,7 +88,7 @@ func setupApp() *cli.App {
 		configPathFlag,
 		logDirFlag,
 	}
-	app.Commands = []cli.Command{}
+	app.Commands = []cli.Command{adminCommand}
 	app.Before = func(context *cli.Context) error {
 		runtime.GOMAXPROCS(runtime.NumCPU())
 		return nil
@@ -86,64 +97,108 @@ func setupApp() *cli.App {
 }
 
 func StartServer(ctx *cli.Context) {
-	for true {
-		startServer(ctx)
-		sig := waitSignal()
-		stopServer()
+	sc := make(chan os.Signal, 1)
+	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sc)
+	for {
+		err := startServer(ctx)
+		if err != nil {
+			logs.Error("start server err: %v", err)
+			stopServer(sc)
+			return
+		}
+		sig := <-sc
+		logs.Info("cross chain listen received signal:(%s).", sig.String())
+		stopServer(sc)
 		if sig != syscall.SIGHUP {
-			break
-		} else {
-			continue
+			return
 		}
 	}
 }
 
-func startServer(ctx *cli.Context) {
+func startServer(ctx *cli.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
 	logs.SetLogger(logs.AdapterFile, `{"filename":"logs/bridge_server.log"}`)
 	configFile := ctx.GlobalString(getFlagName(configPathFlag))
 	config := conf.NewConfig(configFile)
 	if config == nil {
-		logs.Error("startServer - read config failed!")
-		return
+		return fmt.Errorf("read config failed")
 	}
 	{
 		conf, _ := json.Marshal(config)
 		logs.Info("%s\n", string(conf))
 	}
 	common.SetupChainsSDK(config)
+	alert.SetupAlert(config.AlertConfig)
+	metrics.StartMetrics(config.MetricsConfig)
+	admin.StartAdmin(config.AdminConfig)
 	crosschainlisten.StartCrossChainListen(config.Server, config.Backup, config.ChainListenConfig, config.DBConfig)
 	if config.Backup {
-		return
+		return nil
 	}
 	coinpricelisten.StartCoinPriceListen(config.Server, config.CoinPriceUpdateSlot, config.CoinPriceListenConfig, config.DBConfig)
-	chainfeelisten.StartFeeListen(config.Server, config.FeeUpdateSlot, config.FeeListenConfig, config.DBConfig)
+	chainfeelisten.StartFeeListen(config.Server, config.FeeUpdateSlot, config.FeeHistoryDays, config.FeeListenConfig, config.DBConfig)
 	crosschaineffect.StartCrossChainEffect(config.Server, config.EventEffectConfig, config.DBConfig)
 	crosschainstats.StartCrossChainStats(config.Server, config.StatsConfig, config.DBConfig)
+	return nil
 }
 
-func waitSignal() os.Signal {
-	exit := make(chan os.Signal, 0)
-	sc := make(chan os.Signal, 1)
-	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
-	defer signal.Stop(sc)
+// stopServer stops the services in order, the listens which produce the events are stopped first and the
+// servers last. Every service stops in a bounded time, and a second SIGINT or SIGTERM exits at once.
+func stopServer(sc <-chan os.Signal) {
+	done := make(chan struct{})
 	go func() {
-		for sig := range sc {
-			logs.Info("cross chain listen received signal:(%s).", sig.String())
-			exit <- sig
-			close(exit)
-			break
-		}
+		defer close(done)
+		admin.StopAdmin()
+		crosschainlisten.StopCrossChainListen()
+		coinpricelisten.StopCoinPriceListen()
+		chainfeelisten.StopFeeListen()
+		crosschaineffect.StopCrossChainEffect()
+		crosschainstats.StopCrossChainStats()
+		metrics.StopMetrics()
 	}()
-	sig := <-exit
-	return sig
+	for {
+		select {
+		case <-done:
+			return
+		case sig := <-sc:
+			if sig != syscall.SIGHUP {
+				logs.Error("received signal:(%s) while stopping, exit now.", sig.String())
+				logs.GetBeeLogger().Flush()
+				os.Exit(1)
+			}
+			logs.Info("server is stopping, signal:(%s) is ignored.", sig.String())
+		}
+	}
 }
 
-func stopServer() {
-	crosschainlisten.StopCrossChainListen()
-	coinpricelisten.StopCoinPriceListen()
-	chainfeelisten.StopFeeListen()
-	crosschaineffect.StopCrossChainEffect()
-	crosschainstats.StopCrossChainStats()
+func adminAction(ctx *cli.Context) error {
+	config := conf.NewConfig(ctx.GlobalString(getFlagName(configPathFlag)))
+	if config == nil {
+		return fmt.Errorf("read config failed")
+	}
+	args := ctx.Args()
+	if len(args) == 0 {
+		return fmt.Errorf("action is required")
+	}
+	height := uint64(0)
+	if len(args) > 2 {
+		var err error
+		height, err = strconv.ParseUint(args.Get(2), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid height %s", args.Get(2))
+		}
+	}
+	body, err := admin.Request(config.AdminConfig, args.Get(0), args.Get(1), height)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(body))
+	return nil
 }
 
 func main() {
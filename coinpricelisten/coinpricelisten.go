@@ -18,16 +18,19 @@
 package coinpricelisten
 
 import (
+	"context"
 	"github.com/astaxie/beego/logs"
 	"math/big"
+	"poly-bridge/admin"
 	"poly-bridge/basedef"
 	"poly-bridge/coinpricedao"
 	"poly-bridge/coinpricelisten/binance"
 	"poly-bridge/coinpricelisten/coinmarketcap"
 	"poly-bridge/coinpricelisten/self"
 	"poly-bridge/conf"
+	"poly-bridge/metrics"
 	"poly-bridge/models"
-	"runtime/debug"
+	"poly-bridge/supervisor"
 	"strings"
 	"time"
 )
@@ -49,11 +52,14 @@ func StartCoinPriceListen(server string, priceUpdateSlot int64, coinPricecfg []*
 	}
 	cpListen = NewCoinPriceListen(priceUpdateSlot, priceMarkets, dao)
 	cpListen.Start()
+	admin.Register("price", cpListen)
 }
 
 func StopCoinPriceListen() {
 	if cpListen != nil {
+		admin.Unregister("price")
 		cpListen.Stop()
+		cpListen = nil
 	}
 }
 
@@ -75,17 +81,18 @@ func NewPriceMarket(cfg *conf.CoinPriceListenConfig) PriceMarket {
 }
 
 type CoinPriceListen struct {
+	admin.Switch
 	priceUpdateSlot int64
 	priceMarket     map[string]PriceMarket
 	db              coinpricedao.CoinPriceDao
-	exit            chan bool
+	supervisor      *supervisor.Supervisor
 }
 
 func NewCoinPriceListen(priceUpdateSlot int64, priceMarkets []PriceMarket, db coinpricedao.CoinPriceDao) *CoinPriceListen {
 	cpListen := &CoinPriceListen{}
 	cpListen.priceUpdateSlot = priceUpdateSlot
 	cpListen.db = db
-	cpListen.exit = make(chan bool, 0)
+	cpListen.supervisor = supervisor.NewSupervisor("price")
 	cpListen.priceMarket = make(map[string]PriceMarket)
 	for _, market := range priceMarkets {
 		cpListen.priceMarket[market.GetMarketName()] = market
@@ -112,67 +119,60 @@ func (cpl *CoinPriceListen) RegisterPriceQuery(priceMarket PriceMarket) {
 
 func (cpl *CoinPriceListen) Start() {
 	logs.Info("start coin price listen.")
-	go cpl.ListenPrice()
+	cpl.supervisor.Go(cpl.listenPrice)
 }
 
 func (cpl *CoinPriceListen) Stop() {
-	cpl.exit <- true
+	cpl.supervisor.Stop()
 	logs.Info("stop coin price listen.")
 }
 
+// ListenPrice listens the price until the listen is stopped
 func (cpl *CoinPriceListen) ListenPrice() {
-	for {
-		exit := cpl.listenPrice()
-		if exit {
-			close(cpl.exit)
-			break
-		}
-		time.Sleep(time.Second * 5)
-	}
+	cpl.Start()
+	cpl.supervisor.Wait()
 }
 
-func (cpl *CoinPriceListen) listenPrice() (exit bool) {
-	defer func() {
-		if r := recover(); r != nil {
-			logs.Error("service start, recover info: %s", string(debug.Stack()))
-			exit = false
-		}
-	}()
-
+func (cpl *CoinPriceListen) listenPrice(ctx context.Context) error {
 	logs.Debug("coin price listen, market: %s, dao: %s......", cpl.GetPriceMarket(), cpl.db.Name())
 	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
 			now := time.Now().Unix() / 60
-			if now%cpl.priceUpdateSlot != 0 {
+			if now%cpl.priceUpdateSlot != 0 || cpl.Paused() {
 				continue
 			}
 			counter := 0
-			for counter < 5 {
+			for counter < 5 && ctx.Err() == nil {
 				logs.Info("do price update at time: %s", time.Now().Format("2006-01-02 15:04:05"))
 				time.Sleep(time.Second * 5)
 				counter++
 				tokenBasics, err := cpl.db.GetTokens()
 				if err != nil {
 					logs.Error("get token basic err: %v", err)
+					cpl.Ran(err)
 					continue
 				}
 				err = cpl.updateCoinPrice(tokenBasics)
 				if err != nil {
 					logs.Error("updateCoinPrice err: %v", err)
+					cpl.Ran(err)
 					continue
 				}
 				err = cpl.db.SavePrices(tokenBasics)
 				if err != nil {
 					logs.Error("save price err: %v", err)
+					cpl.Ran(err)
 					continue
 				}
+				cpl.Ran(nil)
 				break
 			}
-		case <-cpl.exit:
+		case <-ctx.Done():
 			logs.Info("coin price listen exit, market: %s, dao: %s......", cpl.GetPriceMarket(), cpl.db.Name())
-			return true
+			return nil
 		}
 	}
 }
@@ -201,6 +201,7 @@ func (cpl *CoinPriceListen) updateCoinPrice(tokenBasics []*models.TokenBasic) er
 			continue
 		}
 		coinPrices, err := query.GetCoinPrice(coins)
+		metrics.ObservePriceQuery(market, err)
 		if err != nil {
 			logs.Error("get coin price of market: %s err: %v", market, err)
 			continue
@@ -238,6 +239,7 @@ func (cpl *CoinPriceListen) updateCoinPrice(tokenBasics []*models.TokenBasic) er
 		if tokenBasic.Ind == 0 {
 			logs.Error("Price of token %s is not update", tokenBasic.Name)
 		}
+		metrics.SetPriceUpdated(tokenBasic.Name, tokenBasic.Ind == 1, tokenBasic.Time)
 	}
 	return nil
 }
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"fmt"
 	"math/big"
 	"poly-bridge/basedef"
 	"poly-bridge/chainsdk"
@@ -8,13 +9,10 @@ import (
 )
 
 var (
-	ethereumSdk *chainsdk.EthereumSdkPro
-	bscSdk      *chainsdk.EthereumSdkPro
-	hecoSdk     *chainsdk.EthereumSdkPro
-	okSdk       *chainsdk.EthereumSdkPro
-	neoSdk      *chainsdk.NeoSdkPro
-	ontologySdk *chainsdk.OntologySdkPro
-	config      *conf.Config
+	ethereumSdks = make(map[uint64]*chainsdk.EthereumSdkPro)
+	neoSdks      = make(map[uint64]*chainsdk.NeoSdkPro)
+	ontologySdks = make(map[uint64]*chainsdk.OntologySdkPro)
+	config       *conf.Config
 )
 
 func SetupChainsSDK(cfg *conf.Config) {
@@ -23,101 +21,53 @@ func SetupChainsSDK(cfg *conf.Config) {
 	}
 	config = cfg
 	newChainSdks(cfg)
+	newFeeQuoteKey(cfg.FeeQuoteConfig)
 }
 
 func newChainSdks(config *conf.Config) {
-	{
-		ethereumConfig := config.GetChainListenConfig(basedef.ETHEREUM_CROSSCHAIN_ID)
-		if ethereumConfig == nil {
-			panic("chain is invalid")
+	for _, chainConfig := range config.ChainListenConfig {
+		urls := chainConfig.GetNodesUrl()
+		switch basedef.GetChainFamily(chainConfig.ChainId) {
+		case basedef.CHAIN_FAMILY_EVM:
+			ethereumSdks[chainConfig.ChainId] = chainsdk.NewEthereumSdkPro(urls, chainConfig.ListenSlot, chainConfig.ChainId)
+		case basedef.CHAIN_FAMILY_NEO:
+			neoSdks[chainConfig.ChainId] = chainsdk.NewNeoSdkPro(urls, chainConfig.ListenSlot, chainConfig.ChainId)
+		case basedef.CHAIN_FAMILY_ONTOLOGY:
+			ontologySdks[chainConfig.ChainId] = chainsdk.NewOntologySdkPro(urls, chainConfig.ListenSlot, chainConfig.ChainId)
 		}
-		urls := ethereumConfig.GetNodesUrl()
-		ethereumSdk = chainsdk.NewEthereumSdkPro(urls, ethereumConfig.ListenSlot, ethereumConfig.ChainId)
-	}
-	{
-		bscConfig := config.GetChainListenConfig(basedef.BSC_CROSSCHAIN_ID)
-		if bscConfig == nil {
-			panic("chain is invalid")
-		}
-		urls := bscConfig.GetNodesUrl()
-		bscSdk = chainsdk.NewEthereumSdkPro(urls, bscConfig.ListenSlot, bscConfig.ChainId)
-	}
-	{
-		hecoConfig := config.GetChainListenConfig(basedef.HECO_CROSSCHAIN_ID)
-		if hecoConfig == nil {
-			panic("chain is invalid")
-		}
-		urls := hecoConfig.GetNodesUrl()
-		hecoSdk = chainsdk.NewEthereumSdkPro(urls, hecoConfig.ListenSlot, hecoConfig.ChainId)
-	}
-	{
-		okConfig := config.GetChainListenConfig(basedef.OK_CROSSCHAIN_ID)
-		if okConfig == nil {
-			panic("chain is invalid")
-		}
-		urls := okConfig.GetNodesUrl()
-		okSdk = chainsdk.NewEthereumSdkPro(urls, okConfig.ListenSlot, okConfig.ChainId)
-	}
-	{
-		neoConfig := config.GetChainListenConfig(basedef.NEO_CROSSCHAIN_ID)
-		if neoConfig == nil {
-			panic("chain is invalid")
-		}
-		urls := neoConfig.GetNodesUrl()
-		neoSdk = chainsdk.NewNeoSdkPro(urls, neoConfig.ListenSlot, neoConfig.ChainId)
-	}
-	{
-		ontConfig := config.GetChainListenConfig(basedef.ONT_CROSSCHAIN_ID)
-		if ontConfig == nil {
-			panic("chain is invalid")
-		}
-		urls := ontConfig.GetNodesUrl()
-		ontologySdk = chainsdk.NewOntologySdkPro(urls, ontConfig.ListenSlot, ontConfig.ChainId)
 	}
 }
 
 func GetBalance(chainId uint64, hash string) (*big.Int, error) {
-	if chainId == basedef.ETHEREUM_CROSSCHAIN_ID {
-		ethereumConfig := config.GetChainListenConfig(basedef.ETHEREUM_CROSSCHAIN_ID)
-		if ethereumConfig == nil {
-			panic("chain is invalid")
-		}
-		return ethereumSdk.Erc20Balance(hash, ethereumConfig.ProxyContract)
+	chainConfig := config.GetChainListenConfig(chainId)
+	if chainConfig == nil {
+		return nil, fmt.Errorf("chain %d is not configured", chainId)
 	}
-	if chainId == basedef.BSC_CROSSCHAIN_ID {
-		bscConfig := config.GetChainListenConfig(basedef.BSC_CROSSCHAIN_ID)
-		if bscConfig == nil {
-			panic("chain is invalid")
-		}
-		return bscSdk.Erc20Balance(hash, bscConfig.ProxyContract)
-	}
-	if chainId == basedef.HECO_CROSSCHAIN_ID {
-		hecoConfig := config.GetChainListenConfig(basedef.HECO_CROSSCHAIN_ID)
-		if hecoConfig == nil {
-			panic("chain is invalid")
+	return GetProxyBalance(chainId, hash, chainConfig.ProxyContract)
+}
+
+func GetProxyBalance(chainId uint64, hash string, proxy string) (*big.Int, error) {
+	switch basedef.GetChainFamily(chainId) {
+	case basedef.CHAIN_FAMILY_EVM:
+		if sdk, ok := ethereumSdks[chainId]; ok {
+			return sdk.Erc20Balance(hash, proxy)
 		}
-		return hecoSdk.Erc20Balance(hash, hecoConfig.ProxyContract)
-	}
-	if chainId == basedef.OK_CROSSCHAIN_ID {
-		okConfig := config.GetChainListenConfig(basedef.OK_CROSSCHAIN_ID)
-		if okConfig == nil {
-			panic("chain is invalid")
+	case basedef.CHAIN_FAMILY_NEO:
+		if sdk, ok := neoSdks[chainId]; ok {
+			return sdk.Nep5Balance(hash, proxy)
 		}
-		return okSdk.Erc20Balance(hash, okConfig.ProxyContract)
-	}
-	if chainId == basedef.NEO_CROSSCHAIN_ID {
-		neoConfig := config.GetChainListenConfig(basedef.NEO_CROSSCHAIN_ID)
-		if neoConfig == nil {
-			panic("chain is invalid")
+	case basedef.CHAIN_FAMILY_ONTOLOGY:
+		if sdk, ok := ontologySdks[chainId]; ok {
+			return sdk.Oep4Balance(hash, proxy)
 		}
-		return neoSdk.Nep5Balance(hash, neoConfig.ProxyContract)
-	}
-	if chainId == basedef.ONT_CROSSCHAIN_ID {
-		ontConfig := config.GetChainListenConfig(basedef.ONT_CROSSCHAIN_ID)
-		if ontConfig == nil {
-			panic("chain is invalid")
-		}
-		return ontologySdk.Oep4Balance(hash, ontConfig.ProxyContract)
 	}
 	return new(big.Int).SetUint64(0), nil
 }
+
+func GetProxyContracts(chainId uint64) []*conf.ProxyContract {
+	chainConfig := config.GetChainListenConfig(chainId)
+	if chainConfig == nil {
+		return nil
+	}
+	return chainConfig.GetProxyContracts()
+}
@@ -0,0 +1,52 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package common
+
+import (
+	"crypto/ecdsa"
+	"poly-bridge/conf"
+	"poly-bridge/utils/wallet"
+)
+
+const DEFAULT_FEE_QUOTE_LIFETIME = 600
+
+var (
+	feeQuoteKey      *ecdsa.PrivateKey
+	feeQuoteLifetime int64
+)
+
+func newFeeQuoteKey(cfg *conf.FeeQuoteConfig) {
+	if cfg == nil || cfg.KeyFile == "" {
+		return
+	}
+	key, err := wallet.LoadEthKey(cfg.KeyFile, cfg.Password)
+	if err != nil {
+		panic(err)
+	}
+	feeQuoteKey = key
+	feeQuoteLifetime = cfg.Lifetime
+	if feeQuoteLifetime <= 0 {
+		feeQuoteLifetime = DEFAULT_FEE_QUOTE_LIFETIME
+	}
+}
+
+// GetFeeQuoteKey returns the key the fee quotes are signed with and the lifetime of the quotes, the key is nil
+// if the fee quotes are not made
+func GetFeeQuoteKey() (*ecdsa.PrivateKey, int64) {
+	return feeQuoteKey, feeQuoteLifetime
+}
@@ -20,6 +20,7 @@ package conf
 import (
 	"encoding/json"
 	"poly-bridge/basedef"
+	"strings"
 
 	"github.com/astaxie/beego/logs"
 )
@@ -37,18 +38,32 @@ type Restful struct {
 	Key string
 }
 
+// ProxyContract is a lock proxy of the chain, Label tells who runs it, such as poly, o3 or a partner
+type ProxyContract struct {
+	Address string
+	Label   string
+}
+
 type ChainListenConfig struct {
 	ChainName          string
 	ChainId            uint64
+	Family             string
+	AddressCodec       string
+	ByteOrder          string
 	ListenSlot         uint64
 	Defer              uint64
+	BatchSize          uint64
 	Nodes              []*Restful
 	ExtendNodes        []*Restful
+	WsNodes            []*Restful // websocket nodes which push new heads, evm chains only
+	QuorumNodes        []*Restful // independent nodes which must agree with Nodes before the events are stored
 	WrapperContract    []string
 	CCMContract        string
 	ProxyContract      string
+	ProxyContracts     []*ProxyContract // other lock proxies, ProxyContract is labelled as poly
 	NFTWrapperContract string
 	NFTProxyContract   string
+	NFTProxyContracts  []*ProxyContract
 	NFTQueryContract   string
 }
 
@@ -84,6 +99,81 @@ func (cfg *ChainListenConfig) GetExtendNodesKey() []string {
 	return keys
 }
 
+func (cfg *ChainListenConfig) GetWsNodesUrl() []string {
+	urls := make([]string, 0)
+	for _, node := range cfg.WsNodes {
+		urls = append(urls, node.Url)
+	}
+	return urls
+}
+
+// GetQuorumConfig returns the config of the listen on the quorum nodes, or nil without quorum nodes
+func (cfg *ChainListenConfig) GetQuorumConfig() *ChainListenConfig {
+	if len(cfg.QuorumNodes) == 0 {
+		return nil
+	}
+	quorumCfg := *cfg
+	quorumCfg.Nodes = cfg.QuorumNodes
+	quorumCfg.QuorumNodes = nil
+	quorumCfg.WsNodes = nil
+	return &quorumCfg
+}
+
+// GetProxyContracts returns ProxyContract and ProxyContracts, the same address is returned only once
+func (cfg *ChainListenConfig) GetProxyContracts() []*ProxyContract {
+	return mergeProxyContracts(cfg.ProxyContract, cfg.ProxyContracts)
+}
+
+func (cfg *ChainListenConfig) GetNFTProxyContracts() []*ProxyContract {
+	return mergeProxyContracts(cfg.NFTProxyContract, cfg.NFTProxyContracts)
+}
+
+// GetProxyLabel returns the label of the lock proxy or nft lock proxy, or empty if it is not one of ours
+func (cfg *ChainListenConfig) GetProxyLabel(address string) string {
+	for _, proxy := range append(cfg.GetProxyContracts(), cfg.GetNFTProxyContracts()...) {
+		if sameProxyAddress(proxy.Address, address) {
+			return proxy.Label
+		}
+	}
+	return ""
+}
+
+func (cfg *ChainListenConfig) IsNFTProxyContract(address string) bool {
+	for _, proxy := range cfg.GetNFTProxyContracts() {
+		if sameProxyAddress(proxy.Address, address) {
+			return true
+		}
+	}
+	return false
+}
+
+func mergeProxyContracts(address string, proxies []*ProxyContract) []*ProxyContract {
+	merged := make([]*ProxyContract, 0)
+	if address != "" {
+		merged = append(merged, &ProxyContract{Address: address, Label: basedef.PROXY_LABEL_POLY})
+	}
+	for _, proxy := range proxies {
+		if proxy == nil || proxy.Address == "" {
+			continue
+		}
+		exist := false
+		for _, item := range merged {
+			if sameProxyAddress(item.Address, proxy.Address) {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			merged = append(merged, proxy)
+		}
+	}
+	return merged
+}
+
+func sameProxyAddress(a string, b string) bool {
+	return strings.EqualFold(strings.TrimPrefix(a, "0x"), strings.TrimPrefix(b, "0x"))
+}
+
 type CoinPriceListenConfig struct {
 	MarketName string
 	Nodes      []*Restful
@@ -106,12 +196,18 @@ func (cfg *CoinPriceListenConfig) GetNodesKey() []string {
 }
 
 type FeeListenConfig struct {
-	ChainId   uint64
-	ChainName string
-	Nodes     []*Restful
-	ProxyFee  int64
-	MinFee    int64
-	GasLimit  int64
+	ChainId               uint64
+	ChainName             string
+	Nodes                 []*Restful
+	ProxyFee              int64
+	MinFee                int64
+	GasLimit              int64
+	Legacy                bool    // use the legacy gas price on evm chains without eip-1559, such as heco and ok
+	FeeHistoryBlocks      uint64  // latest blocks whose priority fees are read, default 20
+	PriorityFeePercentile float64 // percentile of the priority fees of the transactions in a block, default 50
+	BaseFeeBlocks         uint64  // blocks of max base fee growth added to the base fee of the next block, default 3
+	FeeWindow             int     // the median of the latest gas price samples is used, default 5
+	ProxyContract         string  // lock proxy the transfers of the assets are simulated from when the gas used is not learned
 }
 
 func (cfg *FeeListenConfig) GetNodesUrl() []string {
@@ -143,6 +239,42 @@ type EventEffectConfig struct {
 	TimeStatisticSlot int64
 }
 
+type NotifierConfig struct {
+	Type        string // webhook, slack, telegram or smtp
+	MinSeverity string // alerts below this severity are not sent by the notifier
+	Url         string // webhook url, slack incoming webhook url or telegram api url
+	Token       string // telegram bot token
+	ChatId      string // telegram chat id
+	SmtpHost    string
+	SmtpPort    int
+	User        string
+	Password    string
+	From        string
+	To          []string
+}
+
+type AlertConfig struct {
+	DedupWindow int64 // Same alert is sent at most once in the window, in seconds
+	Notifiers   []*NotifierConfig
+}
+
+type MetricsConfig struct {
+	Port int    // metrics are not served when the port is 0
+	Path string // default /metrics
+}
+
+type AdminConfig struct {
+	Port  int    // the admin server listens on 127.0.0.1, it is not started when the port is 0
+	Token string // bearer token of the admin requests, the server is not started without it
+}
+
+// FeeQuoteConfig is the key the http server signs the fee quotes with, no quote is made without the key
+type FeeQuoteConfig struct {
+	KeyFile  string // hex private key file or keystore file
+	Password string // password of the keystore file
+	Lifetime int64  // seconds a fee quote is honoured, default 600
+}
+
 type Config struct {
 	Server                string
 	Backup                bool
@@ -150,9 +282,14 @@ type Config struct {
 	CoinPriceUpdateSlot   int64
 	CoinPriceListenConfig []*CoinPriceListenConfig
 	FeeUpdateSlot         int64
+	FeeHistoryDays        int64 // days the fee histories are kept, default 30
 	FeeListenConfig       []*FeeListenConfig
+	FeeQuoteConfig        *FeeQuoteConfig
 	EventEffectConfig     *EventEffectConfig
 	StatsConfig           *StatsConfig
+	AlertConfig           *AlertConfig
+	MetricsConfig         *MetricsConfig
+	AdminConfig           *AdminConfig
 	DBConfig              *DBConfig
 }
 
@@ -195,5 +332,14 @@ func NewConfig(filePath string) *Config {
 		logs.Error("NewServiceConfig: failed, err: %s", err)
 		return nil
 	}
+	for _, cfg := range config.ChainListenConfig {
+		basedef.RegisterChain(&basedef.ChainInfo{
+			ChainId:      cfg.ChainId,
+			ChainName:    cfg.ChainName,
+			Family:       cfg.Family,
+			AddressCodec: cfg.AddressCodec,
+			ByteOrder:    cfg.ByteOrder,
+		})
+	}
 	return config
 }
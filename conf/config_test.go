@@ -0,0 +1,69 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package conf
+
+import (
+	"testing"
+
+	"poly-bridge/basedef"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChainListenConfig_GetProxyContracts(t *testing.T) {
+	cfg := &ChainListenConfig{
+		ProxyContract: "250e76987d838a75310c34bf422ea9f1AC4Cc906",
+		ProxyContracts: []*ProxyContract{
+			{Address: "0x250e76987d838a75310c34bf422ea9f1ac4cc906", Label: "dup"},
+			{Address: "a0b4c3d2e1f0a9b8c7d6e5f4a3b2c1d0e9f8a7b6", Label: "o3"},
+			{Address: "", Label: "empty"},
+		},
+		NFTProxyContract: "2cdfc90250ef967036838da601099656e74bcfb6",
+	}
+
+	proxies := cfg.GetProxyContracts()
+	assert.Equal(t, 2, len(proxies))
+	assert.Equal(t, basedef.PROXY_LABEL_POLY, proxies[0].Label)
+	assert.Equal(t, "o3", proxies[1].Label)
+
+	assert.Equal(t, basedef.PROXY_LABEL_POLY, cfg.GetProxyLabel("0x250E76987D838A75310C34BF422EA9F1AC4CC906"))
+	assert.Equal(t, "o3", cfg.GetProxyLabel("a0b4c3d2e1f0a9b8c7d6e5f4a3b2c1d0e9f8a7b6"))
+	assert.Equal(t, "", cfg.GetProxyLabel("0000000000000000000000000000000000000000"))
+
+	assert.True(t, cfg.IsNFTProxyContract("2CDFC90250EF967036838DA601099656E74BCFB6"))
+	assert.False(t, cfg.IsNFTProxyContract("a0b4c3d2e1f0a9b8c7d6e5f4a3b2c1d0e9f8a7b6"))
+}
+
+func TestChainListenConfig_GetQuorumConfig(t *testing.T) {
+	cfg := &ChainListenConfig{
+		ChainId:     basedef.BSC_CROSSCHAIN_ID,
+		Nodes:       []*Restful{{Url: "http://node"}},
+		WsNodes:     []*Restful{{Url: "ws://node"}},
+		CCMContract: "838bf9e95cb12dd76a54c9f9d2e3082eaf928270",
+	}
+	assert.Nil(t, cfg.GetQuorumConfig())
+
+	cfg.QuorumNodes = []*Restful{{Url: "http://quorum"}}
+	quorumCfg := cfg.GetQuorumConfig()
+	assert.NotNil(t, quorumCfg)
+	assert.Equal(t, []string{"http://quorum"}, quorumCfg.GetNodesUrl())
+	assert.Equal(t, 0, len(quorumCfg.QuorumNodes))
+	assert.Equal(t, 0, len(quorumCfg.WsNodes))
+	assert.Equal(t, cfg.CCMContract, quorumCfg.CCMContract)
+	assert.Equal(t, []string{"http://node"}, cfg.GetNodesUrl())
+}
@@ -24,11 +24,20 @@ import (
 	"poly-bridge/basedef"
 	"poly-bridge/common"
 	"poly-bridge/models"
+	xecdsa "poly-bridge/utils/ecdsa"
+	"strings"
+	"time"
 
 	"github.com/astaxie/beego"
 	"github.com/astaxie/beego/logs"
 )
 
+const (
+	_fee_history_resolution  = 3600
+	_fee_history_max_periods = 1000
+	_fee_quote_skew          = 60 // seconds the block time of a wrapper transaction may be earlier than the quote
+)
+
 type FeeController struct {
 	beego.Controller
 }
@@ -57,14 +66,17 @@ func (c *FeeController) GetFee() {
 		c.ServeJSON()
 		return
 	}
-	proxyFee := new(big.Float).SetInt(&chainFee.ProxyFee.Int)
+	proxyFee := new(big.Float).SetInt(&getAssetProxyFee(&getFeeReq, chainFee).Int)
 	proxyFee = new(big.Float).Quo(proxyFee, new(big.Float).SetInt64(basedef.FEE_PRECISION))
 	proxyFee = new(big.Float).Quo(proxyFee, new(big.Float).SetInt64(basedef.Int64FromFigure(int(chainFee.TokenBasic.Precision))))
 	usdtFee := new(big.Float).Mul(proxyFee, new(big.Float).SetInt64(chainFee.TokenBasic.Price))
 	usdtFee = new(big.Float).Quo(usdtFee, new(big.Float).SetInt64(basedef.PRICE_PRECISION))
+	policy := models.MatchFeePolicy(getFeePolicies(), getFeeReq.SrcChainId, getFeeReq.DstChainId, token.TokenBasicName, getFeeReq.ServerId, time.Now().Unix())
+	usdtFee = policy.Apply(usdtFee)
 	tokenFee := new(big.Float).Mul(usdtFee, new(big.Float).SetInt64(basedef.PRICE_PRECISION))
 	tokenFee = new(big.Float).Quo(tokenFee, new(big.Float).SetInt64(token.TokenBasic.Price))
 	tokenFeeWithPrecision := new(big.Float).Mul(tokenFee, new(big.Float).SetInt64(basedef.Int64FromFigure(int(token.Precision))))
+	quote := makeFeeQuote(&getFeeReq, token, tokenFeeWithPrecision, policy)
 
 	{
 		chainFeeJson, _ := json.Marshal(chainFee)
@@ -79,38 +91,38 @@ func (c *FeeController) GetFee() {
 		tokenMap := new(models.TokenMap)
 		res := db.Where("src_token_hash = ? and src_chain_id = ? and dst_chain_id = ?", getFeeReq.SwapTokenHash, getFeeReq.SrcChainId, getFeeReq.DstChainId).Preload("DstToken").First(tokenMap)
 		if res.RowsAffected == 0 {
-			c.Data["json"] = models.MakeGetFeeRsp(getFeeReq.SrcChainId, getFeeReq.Hash, getFeeReq.DstChainId, usdtFee, tokenFee, tokenFeeWithPrecision,
-				getFeeReq.SwapTokenHash, new(big.Float).SetUint64(0), new(big.Float).SetUint64(0))
+			c.Data["json"] = withFeeQuote(models.MakeGetFeeRsp(getFeeReq.SrcChainId, getFeeReq.Hash, getFeeReq.DstChainId, usdtFee, tokenFee, tokenFeeWithPrecision,
+				getFeeReq.SwapTokenHash, new(big.Float).SetUint64(0), new(big.Float).SetUint64(0)), quote)
 			c.ServeJSON()
 			return
 		}
 		if tokenMap.DstChainId != getFeeReq.DstChainId || tokenMap.DstToken == nil {
-			c.Data["json"] = models.MakeGetFeeRsp(getFeeReq.SrcChainId, getFeeReq.Hash, getFeeReq.DstChainId, usdtFee, tokenFee, tokenFeeWithPrecision,
-				getFeeReq.SwapTokenHash, new(big.Float).SetUint64(0), new(big.Float).SetUint64(0))
+			c.Data["json"] = withFeeQuote(models.MakeGetFeeRsp(getFeeReq.SrcChainId, getFeeReq.Hash, getFeeReq.DstChainId, usdtFee, tokenFee, tokenFeeWithPrecision,
+				getFeeReq.SwapTokenHash, new(big.Float).SetUint64(0), new(big.Float).SetUint64(0)), quote)
 			c.ServeJSON()
 			return
 		}
 		tokenBalance, err := common.GetBalance(tokenMap.DstChainId, tokenMap.DstTokenHash)
 		if err != nil {
-			c.Data["json"] = models.MakeGetFeeRsp(getFeeReq.SrcChainId, getFeeReq.Hash, getFeeReq.DstChainId, usdtFee, tokenFee, tokenFeeWithPrecision,
-				getFeeReq.SwapTokenHash, new(big.Float).SetUint64(0), new(big.Float).SetUint64(0))
+			c.Data["json"] = withFeeQuote(models.MakeGetFeeRsp(getFeeReq.SrcChainId, getFeeReq.Hash, getFeeReq.DstChainId, usdtFee, tokenFee, tokenFeeWithPrecision,
+				getFeeReq.SwapTokenHash, new(big.Float).SetUint64(0), new(big.Float).SetUint64(0)), quote)
 			c.ServeJSON()
 			return
 		}
 		balance, result := new(big.Float).SetString(tokenBalance.String())
 		if !result {
-			c.Data["json"] = models.MakeGetFeeRsp(getFeeReq.SrcChainId, getFeeReq.Hash, getFeeReq.DstChainId, usdtFee, tokenFee, tokenFeeWithPrecision,
-				getFeeReq.SwapTokenHash, new(big.Float).SetUint64(0), new(big.Float).SetUint64(0))
+			c.Data["json"] = withFeeQuote(models.MakeGetFeeRsp(getFeeReq.SrcChainId, getFeeReq.Hash, getFeeReq.DstChainId, usdtFee, tokenFee, tokenFeeWithPrecision,
+				getFeeReq.SwapTokenHash, new(big.Float).SetUint64(0), new(big.Float).SetUint64(0)), quote)
 			c.ServeJSON()
 			return
 		}
 		tokenBalanceWithoutPrecision := new(big.Float).Quo(balance, new(big.Float).SetInt64(basedef.Int64FromFigure(int(tokenMap.DstToken.Precision))))
-		c.Data["json"] = models.MakeGetFeeRsp(getFeeReq.SrcChainId, getFeeReq.Hash, getFeeReq.DstChainId, usdtFee, tokenFee, tokenFeeWithPrecision,
-			getFeeReq.SwapTokenHash, balance, tokenBalanceWithoutPrecision)
+		c.Data["json"] = withFeeQuote(models.MakeGetFeeRsp(getFeeReq.SrcChainId, getFeeReq.Hash, getFeeReq.DstChainId, usdtFee, tokenFee, tokenFeeWithPrecision,
+			getFeeReq.SwapTokenHash, balance, tokenBalanceWithoutPrecision), quote)
 		c.ServeJSON()
 	} else {
-		c.Data["json"] = models.MakeGetFeeRsp(getFeeReq.SrcChainId, getFeeReq.Hash, getFeeReq.DstChainId, usdtFee, tokenFee, tokenFeeWithPrecision,
-			getFeeReq.SwapTokenHash, new(big.Float).SetUint64(0), new(big.Float).SetUint64(0))
+		c.Data["json"] = withFeeQuote(models.MakeGetFeeRsp(getFeeReq.SrcChainId, getFeeReq.Hash, getFeeReq.DstChainId, usdtFee, tokenFee, tokenFeeWithPrecision,
+			getFeeReq.SwapTokenHash, new(big.Float).SetUint64(0), new(big.Float).SetUint64(0)), quote)
 		c.ServeJSON()
 	}
 }
@@ -184,6 +196,10 @@ func (c *FeeController) checkFee(Checks []*models.CheckFeeReq) []*models.CheckFe
 	for _, wrapperTransactionWithToken := range wrapperTransactionWithTokens {
 		txHash2WrapperTransaction[wrapperTransactionWithToken.Hash] = wrapperTransactionWithToken
 	}
+	hash2SrcTransfer := getSrcTransfers(wrapperTransactionWithTokens)
+	hash2GasLimit := getAssetGasLimits(wrapperTransactionWithTokens, hash2SrcTransfer)
+	hash2Quote := getFeeQuotes(wrapperTransactionWithTokens, hash2SrcTransfer)
+	policies := getFeePolicies()
 	chainFees := make([]*models.ChainFee, 0)
 	db.Preload("TokenBasic").Find(&chainFees)
 	chain2Fees := make(map[uint64]*models.ChainFee, 0)
@@ -224,12 +240,22 @@ func (c *FeeController) checkFee(Checks []*models.CheckFeeReq) []*models.CheckFe
 		x := new(big.Int).Mul(&wrapperTransactionWithToken.FeeAmount.Int, big.NewInt(wrapperTransactionWithToken.FeeToken.TokenBasic.Price))
 		feePay := new(big.Float).Quo(new(big.Float).SetInt(x), new(big.Float).SetInt64(basedef.Int64FromFigure(int(wrapperTransactionWithToken.FeeToken.Precision))))
 		feePay = new(big.Float).Quo(feePay, new(big.Float).SetInt64(basedef.PRICE_PRECISION))
-		x = new(big.Int).Mul(&chainFee.MinFee.Int, big.NewInt(chainFee.TokenBasic.Price))
+		minFee := chainFee.MinFee
+		if gasLimit, ok := hash2GasLimit[newHash]; ok {
+			minFee = gasLimit.ScaleFee(minFee)
+		}
+		x = new(big.Int).Mul(&minFee.Int, big.NewInt(chainFee.TokenBasic.Price))
 		feeMin := new(big.Float).Quo(new(big.Float).SetInt(x), new(big.Float).SetInt64(basedef.PRICE_PRECISION))
 		feeMin = new(big.Float).Quo(feeMin, new(big.Float).SetInt64(basedef.FEE_PRECISION))
 		feeMin = new(big.Float).Quo(feeMin, new(big.Float).SetInt64(basedef.Int64FromFigure(int(chainFee.TokenBasic.Precision))))
+		policy := models.MatchFeePolicy(policies, wrapperTransactionWithToken.SrcChainId, wrapperTransactionWithToken.DstChainId,
+			wrapperTransactionWithToken.FeeToken.TokenBasicName, wrapperTransactionWithToken.ServerId, int64(wrapperTransactionWithToken.Time))
+		feeMin = policy.Apply(feeMin)
 		if feePay.Cmp(feeMin) >= 0 {
 			checkFee.PayState = 1
+		} else if quote, ok := hash2Quote[newHash]; ok {
+			checkFee.PayState = 1
+			checkFee.QuoteId = quote.Id
 		} else {
 			checkFee.PayState = -1
 		}
@@ -305,6 +331,10 @@ func (c *FeeController) CheckSwapFee(Checks []*models.CheckFeeReq) []*models.Che
 	for _, wrapperTransactionWithToken := range wrapperTransactionWithTokens {
 		txHash2WrapperTransaction[wrapperTransactionWithToken.Hash] = wrapperTransactionWithToken
 	}
+	hash2SrcTransfer := getSrcTransfers(wrapperTransactionWithTokens)
+	hash2GasLimit := getAssetGasLimits(wrapperTransactionWithTokens, hash2SrcTransfer)
+	hash2Quote := getFeeQuotes(wrapperTransactionWithTokens, hash2SrcTransfer)
+	policies := getFeePolicies()
 	chainFees := make([]*models.ChainFee, 0)
 	db.Preload("TokenBasic").Find(&chainFees)
 	chain2Fees := make(map[uint64]*models.ChainFee, 0)
@@ -351,12 +381,22 @@ func (c *FeeController) CheckSwapFee(Checks []*models.CheckFeeReq) []*models.Che
 		x := new(big.Int).Mul(&wrapperTransactionWithToken.FeeAmount.Int, big.NewInt(wrapperTransactionWithToken.FeeToken.TokenBasic.Price))
 		feePay := new(big.Float).Quo(new(big.Float).SetInt(x), new(big.Float).SetInt64(basedef.Int64FromFigure(int(wrapperTransactionWithToken.FeeToken.Precision))))
 		feePay = new(big.Float).Quo(feePay, new(big.Float).SetInt64(basedef.PRICE_PRECISION))
-		x = new(big.Int).Mul(&chainFee.MinFee.Int, big.NewInt(chainFee.TokenBasic.Price))
+		minFee := chainFee.MinFee
+		if gasLimit, ok := hash2GasLimit[newHash]; ok {
+			minFee = gasLimit.ScaleFee(minFee)
+		}
+		x = new(big.Int).Mul(&minFee.Int, big.NewInt(chainFee.TokenBasic.Price))
 		feeMin := new(big.Float).Quo(new(big.Float).SetInt(x), new(big.Float).SetInt64(basedef.PRICE_PRECISION))
 		feeMin = new(big.Float).Quo(feeMin, new(big.Float).SetInt64(basedef.FEE_PRECISION))
 		feeMin = new(big.Float).Quo(feeMin, new(big.Float).SetInt64(basedef.Int64FromFigure(int(chainFee.TokenBasic.Precision))))
+		policy := models.MatchFeePolicy(policies, wrapperTransactionWithToken.SrcChainId, wrapperTransactionWithToken.DstChainId,
+			wrapperTransactionWithToken.FeeToken.TokenBasicName, wrapperTransactionWithToken.ServerId, int64(wrapperTransactionWithToken.Time))
+		feeMin = policy.Apply(feeMin)
 		if feePay.Cmp(feeMin) >= 0 {
 			checkFee.PayState = 1
+		} else if quote, ok := hash2Quote[newHash]; ok {
+			checkFee.PayState = 1
+			checkFee.QuoteId = quote.Id
 		} else {
 			checkFee.PayState = -1
 		}
@@ -366,3 +406,258 @@ func (c *FeeController) CheckSwapFee(Checks []*models.CheckFeeReq) []*models.Che
 	}
 	return checkFees
 }
+
+// GetFeeHistory returns the fees of the chain in the time range, with the latest fee update of every period
+func (c *FeeController) GetFeeHistory() {
+	var feeHistoryReq models.FeeHistoryReq
+	var err error
+	if err = json.Unmarshal(c.Ctx.Input.RequestBody, &feeHistoryReq); err != nil {
+		c.Data["json"] = models.MakeErrorRsp(fmt.Sprintf("request parameter is invalid!"))
+		c.Ctx.ResponseWriter.WriteHeader(400)
+		c.ServeJSON()
+		return
+	}
+	if feeHistoryReq.EndTime == 0 {
+		feeHistoryReq.EndTime = time.Now().Unix()
+	}
+	if feeHistoryReq.StartTime == 0 {
+		feeHistoryReq.StartTime = feeHistoryReq.EndTime - 24*3600
+	}
+	if feeHistoryReq.Resolution == 0 {
+		feeHistoryReq.Resolution = _fee_history_resolution
+	}
+	if feeHistoryReq.Resolution < 60 || feeHistoryReq.StartTime > feeHistoryReq.EndTime ||
+		(feeHistoryReq.EndTime-feeHistoryReq.StartTime)/feeHistoryReq.Resolution > _fee_history_max_periods {
+		c.Data["json"] = models.MakeErrorRsp(fmt.Sprintf("time range or resolution is invalid, %d periods at most", _fee_history_max_periods))
+		c.Ctx.ResponseWriter.WriteHeader(400)
+		c.ServeJSON()
+		return
+	}
+	histories := make([]*models.ChainFeeHistory, 0)
+	res := db.Where("chain_id = ? and time >= ? and time <= ?", feeHistoryReq.ChainId, feeHistoryReq.StartTime, feeHistoryReq.EndTime).
+		Order("time asc").Preload("TokenBasic").Find(&histories)
+	if res.Error != nil {
+		c.Data["json"] = models.MakeErrorRsp(fmt.Sprintf("get fee history of chain: %d err: %v", feeHistoryReq.ChainId, res.Error))
+		c.Ctx.ResponseWriter.WriteHeader(400)
+		c.ServeJSON()
+		return
+	}
+	limits := make([]*models.AssetGasLimitHistory, 0)
+	if feeHistoryReq.Hash != "" {
+		// the gas limit in effect at the start time is the latest one before it
+		res = db.Where("chain_id = ? and hash = ? and time <= ?", feeHistoryReq.ChainId, feeHistoryReq.Hash, feeHistoryReq.StartTime).
+			Order("time desc").Limit(1).Find(&limits)
+		if res.Error == nil {
+			ranged := make([]*models.AssetGasLimitHistory, 0)
+			res = db.Where("chain_id = ? and hash = ? and time > ? and time <= ?", feeHistoryReq.ChainId, feeHistoryReq.Hash, feeHistoryReq.StartTime, feeHistoryReq.EndTime).
+				Order("time asc").Find(&ranged)
+			limits = append(limits, ranged...)
+		}
+		if res.Error != nil {
+			c.Data["json"] = models.MakeErrorRsp(fmt.Sprintf("get asset gas limit history of chain: %d err: %v", feeHistoryReq.ChainId, res.Error))
+			c.Ctx.ResponseWriter.WriteHeader(400)
+			c.ServeJSON()
+			return
+		}
+	}
+	c.Data["json"] = models.MakeFeeHistoryRsp(feeHistoryReq.ChainId, feeHistoryReq.Resolution, histories, limits)
+	c.ServeJSON()
+}
+
+// transferredAsset is the asset transferred on the source chain, which is the swap token if any
+func transferredAsset(getFeeReq *models.GetFeeReq) string {
+	if getFeeReq.SwapTokenHash != "" {
+		return getFeeReq.SwapTokenHash
+	}
+	return getFeeReq.Hash
+}
+
+// getAssetProxyFee scales the proxy fee of the destination chain by the gas limit of the asset transferred on
+// the destination chain, the proxy fee of the chain is used when the gas limit of the asset is unknown
+func getAssetProxyFee(getFeeReq *models.GetFeeReq, chainFee *models.ChainFee) *models.BigInt {
+	tokenMap := new(models.TokenMap)
+	res := db.Where("src_token_hash = ? and src_chain_id = ? and dst_chain_id = ?", transferredAsset(getFeeReq), getFeeReq.SrcChainId, getFeeReq.DstChainId).First(tokenMap)
+	if res.RowsAffected == 0 {
+		return chainFee.ProxyFee
+	}
+	gasLimit := new(models.AssetGasLimit)
+	res = db.Where("chain_id = ? and hash = ?", getFeeReq.DstChainId, tokenMap.DstTokenHash).First(gasLimit)
+	if res.RowsAffected == 0 {
+		return chainFee.ProxyFee
+	}
+	return gasLimit.ScaleFee(chainFee.ProxyFee)
+}
+
+func getFeePolicies() []*models.FeePolicy {
+	policies := make([]*models.FeePolicy, 0)
+	res := db.Find(&policies)
+	if res.Error != nil {
+		logs.Error("get fee policies err: %v", res.Error)
+	}
+	return policies
+}
+
+// getSrcTransfers returns the source transfers of the wrapper transactions by the hashes of the transactions
+func getSrcTransfers(wrapperTransactions []*models.WrapperTransactionWithToken) map[string]*models.SrcTransfer {
+	hash2SrcTransfer := make(map[string]*models.SrcTransfer)
+	if len(wrapperTransactions) == 0 {
+		return hash2SrcTransfer
+	}
+	hashes := make([]string, 0)
+	for _, wrapperTransaction := range wrapperTransactions {
+		hashes = append(hashes, wrapperTransaction.Hash)
+	}
+	srcTransfers := make([]*models.SrcTransfer, 0)
+	db.Where("tx_hash in ?", hashes).Find(&srcTransfers)
+	for _, srcTransfer := range srcTransfers {
+		hash2SrcTransfer[srcTransfer.TxHash] = srcTransfer
+	}
+	return hash2SrcTransfer
+}
+
+// getAssetGasLimits returns the gas limits of the assets transferred by the wrapper transactions on the destination
+// chains by the hashes of the transactions, so that the minimum fee is scaled the same as the quoted fee
+func getAssetGasLimits(wrapperTransactions []*models.WrapperTransactionWithToken, hash2SrcTransfer map[string]*models.SrcTransfer) map[string]*models.AssetGasLimit {
+	hash2GasLimit := make(map[string]*models.AssetGasLimit)
+	if len(hash2SrcTransfer) == 0 {
+		return hash2GasLimit
+	}
+	assets := make([]string, 0)
+	for _, srcTransfer := range hash2SrcTransfer {
+		assets = append(assets, srcTransfer.DstAsset)
+	}
+	gasLimits := make([]*models.AssetGasLimit, 0)
+	db.Where("hash in ?", assets).Find(&gasLimits)
+	asset2GasLimit := make(map[string]*models.AssetGasLimit)
+	for _, gasLimit := range gasLimits {
+		asset2GasLimit[fmt.Sprintf("%d:%s", gasLimit.ChainId, gasLimit.Hash)] = gasLimit
+	}
+	for _, wrapperTransaction := range wrapperTransactions {
+		srcTransfer, ok := hash2SrcTransfer[wrapperTransaction.Hash]
+		// the fee is paid for the destination chain of the wrapper transaction
+		if !ok || srcTransfer.DstChainId != wrapperTransaction.DstChainId {
+			continue
+		}
+		if gasLimit, ok := asset2GasLimit[fmt.Sprintf("%d:%s", srcTransfer.DstChainId, srcTransfer.DstAsset)]; ok {
+			hash2GasLimit[wrapperTransaction.Hash] = gasLimit
+		}
+	}
+	return hash2GasLimit
+}
+
+// makeFeeQuote signs and saves the quote of the fee amount for the user when it is requested, the unexpired quote
+// of the same route, user and amount is returned if it is valid for half of its lifetime. A quote under a policy
+// with an end time, such as a free promotion, expires no later than the policy. No quote is made without the key
+// or the user.
+func makeFeeQuote(getFeeReq *models.GetFeeReq, token *models.Token, tokenFeeWithPrecision *big.Float, policy *models.FeePolicy) *models.FeeQuote {
+	key, lifetime := common.GetFeeQuoteKey()
+	user := feeQuoteUser(getFeeReq.User)
+	if key == nil || !getFeeReq.Quote || user == "" {
+		return nil
+	}
+	amount, accuracy := tokenFeeWithPrecision.Int(nil)
+	if accuracy == big.Below {
+		amount = new(big.Int).Add(amount, big.NewInt(1))
+	}
+	now := time.Now().Unix()
+	expiry := now + lifetime
+	if policy != nil && policy.EndTime != 0 && policy.EndTime < expiry {
+		expiry = policy.EndTime
+	}
+	quote := &models.FeeQuote{
+		SrcChainId: getFeeReq.SrcChainId,
+		Hash:       token.Hash,
+		Asset:      strings.ToLower(transferredAsset(getFeeReq)),
+		DstChainId: getFeeReq.DstChainId,
+		ServerId:   getFeeReq.ServerId,
+		User:       user,
+		Amount:     models.NewBigInt(amount),
+		Time:       now,
+		Expiry:     expiry,
+	}
+	existing := new(models.FeeQuote)
+	res := db.Where("src_chain_id = ? and hash = ? and asset = ? and dst_chain_id = ? and server_id = ? and user = ? and amount = ? and expiry >= ? and expiry <= ?",
+		quote.SrcChainId, quote.Hash, quote.Asset, quote.DstChainId, quote.ServerId, quote.User, amount.String(), now+(expiry-now)/2, expiry).First(existing)
+	if res.RowsAffected > 0 {
+		return existing
+	}
+	err := quote.Sign(key)
+	if err != nil {
+		logs.Error("sign fee quote err: %v", err)
+		return nil
+	}
+	res = db.Save(quote)
+	if res.Error != nil {
+		logs.Error("save fee quote err: %v", res.Error)
+		return nil
+	}
+	return quote
+}
+
+// feeQuoteUser is the user of a quote as the wrapper transactions record the sender, hex without 0x in lower case
+func feeQuoteUser(user string) string {
+	user = strings.ToLower(strings.TrimSpace(user))
+	return strings.TrimPrefix(user, "0x")
+}
+
+func withFeeQuote(getFeeRsp *models.GetFeeRsp, quote *models.FeeQuote) *models.GetFeeRsp {
+	getFeeRsp.Quote = models.MakeFeeQuoteRsp(quote)
+	return getFeeRsp
+}
+
+// getFeeQuotes returns the quotes signed by the server for the senders of the wrapper transactions which the
+// transactions have paid before their expiry by the hashes of the transactions, the quotes of all the transactions
+// are read by one query
+func getFeeQuotes(wrapperTransactions []*models.WrapperTransactionWithToken, hash2SrcTransfer map[string]*models.SrcTransfer) map[string]*models.FeeQuote {
+	hash2Quote := make(map[string]*models.FeeQuote)
+	key, _ := common.GetFeeQuoteKey()
+	if key == nil || len(hash2SrcTransfer) == 0 {
+		return hash2Quote
+	}
+	srcChainIds, feeTokens, users := make([]uint64, 0), make([]string, 0), make([]string, 0)
+	minTime, maxTime := int64(-1), int64(0)
+	for _, wrapperTransaction := range wrapperTransactions {
+		txTime := int64(wrapperTransaction.Time)
+		srcChainIds = append(srcChainIds, wrapperTransaction.SrcChainId)
+		feeTokens = append(feeTokens, wrapperTransaction.FeeTokenHash)
+		users = append(users, feeQuoteUser(wrapperTransaction.User))
+		if minTime < 0 || txTime < minTime {
+			minTime = txTime
+		}
+		if txTime > maxTime {
+			maxTime = txTime
+		}
+	}
+	quotes := make([]*models.FeeQuote, 0)
+	db.Where("src_chain_id in ? and hash in ? and user in ? and time <= ? and expiry >= ?", srcChainIds, feeTokens, users, maxTime+_fee_quote_skew, minTime).Find(&quotes)
+	signer := xecdsa.Key2address(key)
+	for _, wrapperTransaction := range wrapperTransactions {
+		srcTransfer, ok := hash2SrcTransfer[wrapperTransaction.Hash]
+		if !ok || wrapperTransaction.FeeAmount == nil {
+			continue
+		}
+		txTime := int64(wrapperTransaction.Time)
+		for _, quote := range quotes {
+			if quote.SrcChainId != wrapperTransaction.SrcChainId || quote.Hash != wrapperTransaction.FeeTokenHash ||
+				quote.DstChainId != wrapperTransaction.DstChainId || quote.ServerId != wrapperTransaction.ServerId ||
+				quote.User != feeQuoteUser(wrapperTransaction.User) ||
+				!strings.EqualFold(quote.Asset, srcTransfer.Asset) || srcTransfer.DstChainId != quote.DstChainId {
+				continue
+			}
+			if quote.Time > txTime+_fee_quote_skew || quote.Expiry < txTime {
+				continue
+			}
+			if quote.Amount == nil || quote.Amount.Cmp(&wrapperTransaction.FeeAmount.Int) > 0 {
+				continue
+			}
+			address, err := quote.Signer()
+			if err != nil || address != signer {
+				logs.Error("fee quote: %s is not signed by the server", quote.Id)
+				continue
+			}
+			hash2Quote[wrapperTransaction.Hash] = quote
+			break
+		}
+	}
+	return hash2Quote
+}
@@ -52,7 +52,7 @@ func (c *TokenController) Token() {
 		c.ServeJSON()
 	}
 	token := new(models.Token)
-	res := db.Where("hash = ? and chain_id = ? and standard = 0", tokenReq.Hash, tokenReq.ChainId).Preload("TokenBasic").Preload("TokenMaps").Preload("TokenMaps.DstToken").First(token)
+	res := db.Where("hash = ? and chain_id = ? and standard = 0", tokenReq.Hash, tokenReq.ChainId).Preload("TokenBasic").Preload("TokenMaps").Preload("TokenMaps.DstToken").Preload("ProxyAmounts").First(token)
 	if res.RowsAffected == 0 {
 		c.Data["json"] = models.MakeErrorRsp(fmt.Sprintf("token: (%s,%d) does not exist", tokenReq.Hash, tokenReq.ChainId))
 		c.Ctx.ResponseWriter.WriteHeader(400)
@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/astaxie/beego"
+	"gorm.io/gorm"
 )
 
 type TransactionController struct {
@@ -105,6 +106,7 @@ func (c *TransactionController) TransactionsOfAddress() {
 		Preload("SrcTransaction").
 		Preload("SrcTransaction.SrcTransfer").
 		Preload("PolyTransaction").
+		Preload("PolyTransaction.FailedDstTransactions").
 		Preload("DstTransaction").
 		Preload("DstTransaction.DstTransfer").
 		Preload("Token").
@@ -139,7 +141,9 @@ func (c *TransactionController) getTransactionByHash(hash string) (*models.SrcPo
 		Preload("WrapperTransaction").
 		Preload("SrcTransaction").
 		Preload("SrcTransaction.SrcTransfer").
+		Preload("SrcTransaction.MakeTxParam").
 		Preload("PolyTransaction").
+		Preload("PolyTransaction.FailedDstTransactions").
 		Preload("DstTransaction").
 		Preload("DstTransaction.DstTransfer").
 		Preload("Token").
@@ -167,6 +171,7 @@ func (c *TransactionController) getTransactionByDstHash(hash string) (*models.Sr
 		Preload("SrcTransaction").
 		Preload("SrcTransaction.SrcTransfer").
 		Preload("PolyTransaction").
+		Preload("PolyTransaction.FailedDstTransactions").
 		Preload("DstTransaction").
 		Preload("DstTransaction.DstTransfer").
 		Preload("Token").
@@ -304,6 +309,7 @@ func (c *TransactionController) TransactionsOfUnfinished() {
 		Preload("SrcTransaction").
 		Preload("SrcTransaction.SrcTransfer").
 		Preload("PolyTransaction").
+		Preload("PolyTransaction.FailedDstTransactions").
 		Preload("DstTransaction").
 		Preload("DstTransaction.DstTransfer").
 		Preload("Token").
@@ -354,6 +360,7 @@ func (c *TransactionController) TransactionsOfAsset() {
 		Preload("SrcTransaction").
 		Preload("SrcTransaction.SrcTransfer").
 		Preload("PolyTransaction").
+		Preload("PolyTransaction.FailedDstTransactions").
 		Preload("DstTransaction").
 		Preload("DstTransaction.DstTransfer").
 		Preload("Token").
@@ -381,3 +388,56 @@ func (c *TransactionController) TransactionsOfAsset() {
 		(int(transactionNum)+transactionsOfAssetReq.PageSize-1)/transactionsOfAssetReq.PageSize, int(transactionNum), srcPolyDstRelations)
 	c.ServeJSON()
 }
+
+func (c *TransactionController) TransactionsOfType() {
+	var transactionsOfTypeReq models.TransactionsOfTypeReq
+	var err error
+	if err = json.Unmarshal(c.Ctx.Input.RequestBody, &transactionsOfTypeReq); err != nil || transactionsOfTypeReq.PageSize == 0 {
+		c.Data["json"] = models.MakeErrorRsp(fmt.Sprintf("request parameter is invalid!"))
+		c.Ctx.ResponseWriter.WriteHeader(400)
+		c.ServeJSON()
+		return
+	}
+	srcPolyDstRelations := make([]*models.SrcPolyDstRelation, 0)
+	res := c.transactionsOfType(&transactionsOfTypeReq).
+		Select("src_transactions.hash as src_hash, poly_transactions.hash as poly_hash, dst_transactions.hash as dst_hash, src_transactions.chain_id as chain_id, src_transfers.asset as token_hash, wrapper_transactions.fee_token_hash as fee_token_hash").
+		Joins("left join src_transfers on src_transactions.hash = src_transfers.tx_hash").
+		Joins("left join poly_transactions on src_transactions.hash = poly_transactions.src_hash").
+		Joins("left join dst_transactions on poly_transactions.hash = dst_transactions.poly_hash").
+		Joins("left join wrapper_transactions on src_transactions.hash = wrapper_transactions.hash").
+		Preload("WrapperTransaction").
+		Preload("SrcTransaction").
+		Preload("SrcTransaction.SrcTransfer").
+		Preload("SrcTransaction.MakeTxParam").
+		Preload("PolyTransaction").
+		Preload("PolyTransaction.FailedDstTransactions").
+		Preload("DstTransaction").
+		Preload("DstTransaction.DstTransfer").
+		Preload("Token").
+		Preload("Token.TokenBasic").
+		Limit(transactionsOfTypeReq.PageSize).Offset(transactionsOfTypeReq.PageSize * transactionsOfTypeReq.PageNo).
+		Order("src_transactions.time desc").
+		Find(&srcPolyDstRelations)
+	if res.Error != nil {
+		c.Data["json"] = res.Error.Error()
+		c.Ctx.ResponseWriter.WriteHeader(400)
+		c.ServeJSON()
+		return
+	}
+	var transactionNum int64
+	c.transactionsOfType(&transactionsOfTypeReq).Count(&transactionNum)
+	c.Data["json"] = models.MakeTransactionOfUnfinishedRsp(transactionsOfTypeReq.PageSize, transactionsOfTypeReq.PageNo,
+		(int(transactionNum)+transactionsOfTypeReq.PageSize-1)/transactionsOfTypeReq.PageSize, int(transactionNum), srcPolyDstRelations)
+	c.ServeJSON()
+}
+
+func (c *TransactionController) transactionsOfType(transactionsOfTypeReq *models.TransactionsOfTypeReq) *gorm.DB {
+	query := db.Table("src_transactions").Where("src_transactions.standard = ?", 0)
+	if transactionsOfTypeReq.MessageType != nil {
+		query = query.Where("src_transactions.message_type = ?", *transactionsOfTypeReq.MessageType)
+	}
+	if transactionsOfTypeReq.ChainId != 0 {
+		query = query.Where("src_transactions.chain_id = ?", transactionsOfTypeReq.ChainId)
+	}
+	return query
+}
@@ -22,6 +22,7 @@ import (
 	"poly-bridge/conf"
 	"poly-bridge/crosschaindao/bridgedao"
 	"poly-bridge/crosschaindao/explorerdao"
+	"poly-bridge/crosschaindao/memorydao"
 	"poly-bridge/crosschaindao/stakedao"
 	"poly-bridge/crosschaindao/swapdao"
 	"poly-bridge/models"
@@ -39,6 +40,17 @@ type CrossChainDao interface {
 	Name() string
 }
 
+// EventsQuery is implemented by the daos which can read back stored events by hash.
+type EventsQuery interface {
+	GetEvents(wrapperHashes []string, srcHashes []string, polyHashes []string, dstHashes []string) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error)
+}
+
+// FailedDstDao is implemented by the daos which store the reverted destination transactions. They are
+// written in the same transaction as the events of the blocks and removed by RemoveEvents with the dst hashes.
+type FailedDstDao interface {
+	UpdateEventsWithFailedDst(chain *models.Chain, wrapperTransactions []*models.WrapperTransaction, srcTransactions []*models.SrcTransaction, polyTransactions []*models.PolyTransaction, dstTransactions []*models.DstTransaction, failedDstTransactions []*models.FailedDstTransaction) error
+}
+
 func NewCrossChainDao(server string, backup bool, dbCfg *conf.DBConfig) CrossChainDao {
 	if server == basedef.SERVER_POLY_SWAP {
 		return swapdao.NewSwapDao(dbCfg, backup)
@@ -48,6 +60,8 @@ func NewCrossChainDao(server string, backup bool, dbCfg *conf.DBConfig) CrossCha
 		return explorerdao.NewExplorerDao(dbCfg, backup)
 	} else if server == basedef.SERVER_STAKE {
 		return stakedao.NewStakeDao()
+	} else if server == basedef.SERVER_MEMORY {
+		return memorydao.NewMemoryDao()
 	} else {
 		return nil
 	}
@@ -22,12 +22,16 @@ import (
 	"fmt"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 	"poly-bridge/basedef"
 	"poly-bridge/conf"
 	"poly-bridge/models"
 )
 
+// rows written by one INSERT ... ON DUPLICATE KEY UPDATE statement
+const upsertBatchSize = 100
+
 type Chain struct {
 	ChainId uint64 `gorm:"column:id"`
 	Name    string `gorm:"column:xname"`
@@ -179,12 +183,12 @@ func NewExplorerDao(dbCfg *conf.DBConfig, backup bool) *ExplorerDao {
 }
 
 func (dao *ExplorerDao) UpdateEvents(chain *models.Chain, wrapperTransactions []*models.WrapperTransaction, srcTransactions []*models.SrcTransaction, polyTransactions []*models.PolyTransaction, dstTransactions []*models.DstTransaction) error {
+	newSrcTransactions := make([]*SrcTransaction, 0)
 	if srcTransactions != nil && len(srcTransactions) > 0 {
 		srcTransactionsJson, err := json.Marshal(srcTransactions)
 		if err != nil {
 			return err
 		}
-		newSrcTransactions := make([]*SrcTransaction, 0)
 		err = json.Unmarshal(srcTransactionsJson, &newSrcTransactions)
 		if err != nil {
 			return err
@@ -203,32 +207,24 @@ func (dao *ExplorerDao) UpdateEvents(chain *models.Chain, wrapperTransactions []
 				transaction.SrcTransfer.TxHash = transaction.Hash
 			}
 		}
-		res := dao.db.Save(newSrcTransactions)
-		if res.Error != nil {
-			return res.Error
-		}
 	}
+	newPolyTransactions := make([]*PolyTransaction, 0)
 	if polyTransactions != nil && len(polyTransactions) > 0 {
 		polyTransactionsJson, err := json.Marshal(polyTransactions)
 		if err != nil {
 			return err
 		}
-		newPolyTransactions := make([]*PolyTransaction, 0)
 		err = json.Unmarshal(polyTransactionsJson, &newPolyTransactions)
 		if err != nil {
 			return err
 		}
-		res := dao.db.Save(newPolyTransactions)
-		if res.Error != nil {
-			return res.Error
-		}
 	}
+	newDstTransactions := make([]*DstTransaction, 0)
 	if dstTransactions != nil && len(dstTransactions) > 0 {
 		dstTransactionsJson, err := json.Marshal(dstTransactions)
 		if err != nil {
 			return err
 		}
-		newDstTransactions := make([]*DstTransaction, 0)
 		err = json.Unmarshal(dstTransactionsJson, &newDstTransactions)
 		if err != nil {
 			return err
@@ -239,46 +235,90 @@ func (dao *ExplorerDao) UpdateEvents(chain *models.Chain, wrapperTransactions []
 				transaction.DstTransfer.To = basedef.Hash2Address(transaction.DstTransfer.ChainId, transaction.DstTransfer.To)
 			}
 		}
-		res := dao.db.Save(newDstTransactions)
-		if res.Error != nil {
-			return res.Error
-		}
 	}
-	if chain != nil && !dao.backup {
-		chainJson, err := json.Marshal(chain)
-		if err != nil {
-			return err
+	return dao.db.Session(&gorm.Session{FullSaveAssociations: true}).Transaction(func(tx *gorm.DB) error {
+		// only transactions seen for the first time are counted, so that re-processing a block keeps txin and txout
+		var srcExist, dstExist int64
+		if len(newSrcTransactions) > 0 {
+			hashes := make([]string, 0, len(newSrcTransactions))
+			for _, transaction := range newSrcTransactions {
+				hashes = append(hashes, transaction.Hash)
+			}
+			res := tx.Model(&SrcTransaction{}).Where("txhash in ?", hashes).Count(&srcExist)
+			if res.Error != nil {
+				return res.Error
+			}
+			res = tx.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(newSrcTransactions, upsertBatchSize)
+			if res.Error != nil {
+				return res.Error
+			}
 		}
-		newChain := new(Chain)
-		err = json.Unmarshal(chainJson, newChain)
-		if err != nil {
-			return err
+		if len(newPolyTransactions) > 0 {
+			res := tx.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(newPolyTransactions, upsertBatchSize)
+			if res.Error != nil {
+				return res.Error
+			}
 		}
-		if chain.HeightSwap > chain.Height {
-			newChain.Height = chain.HeightSwap
+		if len(newDstTransactions) > 0 {
+			hashes := make([]string, 0, len(newDstTransactions))
+			for _, transaction := range newDstTransactions {
+				hashes = append(hashes, transaction.Hash)
+			}
+			res := tx.Model(&DstTransaction{}).Where("txhash in ?", hashes).Count(&dstExist)
+			if res.Error != nil {
+				return res.Error
+			}
+			res = tx.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(newDstTransactions, upsertBatchSize)
+			if res.Error != nil {
+				return res.Error
+			}
 		}
-		newChain.In = uint64(len(srcTransactions))
-		newChain.Out = uint64(len(dstTransactions))
-		res := dao.db.Model(newChain).Updates(map[string]interface{}{
-			"txin":   gorm.Expr("txin + ?", newChain.In),
-			"txout":  gorm.Expr("txout + ?", newChain.Out),
-			"height": gorm.Expr("?", newChain.Height)})
-		if res.Error != nil {
-			return res.Error
+		if chain != nil && !dao.backup {
+			chainJson, err := json.Marshal(chain)
+			if err != nil {
+				return err
+			}
+			newChain := new(Chain)
+			err = json.Unmarshal(chainJson, newChain)
+			if err != nil {
+				return err
+			}
+			if chain.HeightSwap > chain.Height {
+				newChain.Height = chain.HeightSwap
+			}
+			newChain.In = uint64(int64(len(newSrcTransactions)) - srcExist)
+			newChain.Out = uint64(int64(len(newDstTransactions)) - dstExist)
+			res := tx.Model(newChain).Updates(map[string]interface{}{
+				"txin":   gorm.Expr("txin + ?", newChain.In),
+				"txout":  gorm.Expr("txout + ?", newChain.Out),
+				"height": gorm.Expr("?", newChain.Height)})
+			if res.Error != nil {
+				return res.Error
+			}
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 func (dao *ExplorerDao) RemoveEvents(srcHashes []string, polyHashes []string, dstHashes []string) error {
-	dao.db.Where("`txhash` in ?", srcHashes).Delete(&SrcTransfer{})
-	dao.db.Where("`txhash` in ?", srcHashes).Delete(&SrcTransaction{})
-
-	dao.db.Where("`txhash` in ?", polyHashes).Delete(&PolyTransaction{})
-
-	dao.db.Where("`txhash` in ?", dstHashes).Delete(&DstTransfer{})
-	dao.db.Where("`txhash` in ?", dstHashes).Delete(&DstTransaction{})
-	return nil
+	return dao.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("`txhash` in ?", srcHashes).Delete(&SrcTransfer{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("`txhash` in ?", srcHashes).Delete(&SrcTransaction{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("`txhash` in ?", polyHashes).Delete(&PolyTransaction{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("`txhash` in ?", dstHashes).Delete(&DstTransfer{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("`txhash` in ?", dstHashes).Delete(&DstTransaction{}).Error; err != nil {
+			return err
+		}
+		return nil
+	})
 }
 
 func (dao *ExplorerDao) GetChain(chainId uint64) (*models.Chain, error) {
@@ -0,0 +1,273 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package memorydao
+
+import (
+	"fmt"
+	"poly-bridge/basedef"
+	"poly-bridge/models"
+	"sync"
+)
+
+// MemoryDao keeps the events in memory with the upsert semantics of the database daos, it is used to
+// assert what the listeners write without a database.
+type MemoryDao struct {
+	chains              map[uint64]*models.Chain
+	wrapperTransactions map[string]*models.WrapperTransaction
+	srcTransactions     map[string]*models.SrcTransaction
+	polyTransactions    map[string]*models.PolyTransaction
+	dstTransactions     map[string]*models.DstTransaction
+	failedDst           map[string]*models.FailedDstTransaction
+	tokenBasics         map[string]*models.TokenBasic
+	tokenMaps           map[string]*models.TokenMap
+	chainFees           map[uint64]*models.ChainFee
+	lock                sync.RWMutex
+}
+
+func NewMemoryDao() *MemoryDao {
+	return &MemoryDao{
+		chains:              make(map[uint64]*models.Chain),
+		wrapperTransactions: make(map[string]*models.WrapperTransaction),
+		srcTransactions:     make(map[string]*models.SrcTransaction),
+		polyTransactions:    make(map[string]*models.PolyTransaction),
+		dstTransactions:     make(map[string]*models.DstTransaction),
+		failedDst:           make(map[string]*models.FailedDstTransaction),
+		tokenBasics:         make(map[string]*models.TokenBasic),
+		tokenMaps:           make(map[string]*models.TokenMap),
+		chainFees:           make(map[uint64]*models.ChainFee),
+	}
+}
+
+func tokenMapKey(tokenMap *models.TokenMap) string {
+	return fmt.Sprintf("%d:%s:%d:%s", tokenMap.SrcChainId, tokenMap.SrcTokenHash, tokenMap.DstChainId, tokenMap.DstTokenHash)
+}
+
+func (dao *MemoryDao) UpdateEvents(chain *models.Chain, wrapperTransactions []*models.WrapperTransaction, srcTransactions []*models.SrcTransaction, polyTransactions []*models.PolyTransaction, dstTransactions []*models.DstTransaction) error {
+	return dao.UpdateEventsWithFailedDst(chain, wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, nil)
+}
+
+func (dao *MemoryDao) UpdateEventsWithFailedDst(chain *models.Chain, wrapperTransactions []*models.WrapperTransaction, srcTransactions []*models.SrcTransaction, polyTransactions []*models.PolyTransaction, dstTransactions []*models.DstTransaction, failedDstTransactions []*models.FailedDstTransaction) error {
+	dao.lock.Lock()
+	defer dao.lock.Unlock()
+	for _, wrapperTransaction := range wrapperTransactions {
+		item := *wrapperTransaction
+		// the status of a stored wrapper transaction is owned by the effects and kept on upsert
+		if old, ok := dao.wrapperTransactions[item.Hash]; ok {
+			item.Status = old.Status
+			// a speed up only updates the fee of the stored lock
+			if wrapperTransaction.IsSpeedUp() {
+				item = *old
+				item.FeeTokenHash = wrapperTransaction.FeeTokenHash
+				item.FeeAmount = wrapperTransaction.FeeAmount
+			}
+		}
+		dao.wrapperTransactions[item.Hash] = &item
+	}
+	for _, srcTransaction := range srcTransactions {
+		item := *srcTransaction
+		dao.srcTransactions[item.Hash] = &item
+	}
+	for _, polyTransaction := range polyTransactions {
+		item := *polyTransaction
+		dao.polyTransactions[item.Hash] = &item
+	}
+	for _, dstTransaction := range dstTransactions {
+		item := *dstTransaction
+		dao.dstTransactions[item.Hash] = &item
+	}
+	for _, failedDstTransaction := range failedDstTransactions {
+		item := *failedDstTransaction
+		dao.failedDst[item.Hash] = &item
+	}
+	if chain != nil && chain.ChainId != nil {
+		item := *chain
+		dao.chains[*chain.ChainId] = &item
+	}
+	return nil
+}
+
+func (dao *MemoryDao) RemoveEvents(srcHashes []string, polyHashes []string, dstHashes []string) error {
+	dao.lock.Lock()
+	defer dao.lock.Unlock()
+	for _, hash := range srcHashes {
+		delete(dao.srcTransactions, hash)
+		delete(dao.wrapperTransactions, hash)
+	}
+	for _, hash := range polyHashes {
+		delete(dao.polyTransactions, hash)
+	}
+	for _, hash := range dstHashes {
+		delete(dao.dstTransactions, hash)
+		delete(dao.failedDst, hash)
+	}
+	return nil
+}
+
+func (dao *MemoryDao) GetEvents(wrapperHashes []string, srcHashes []string, polyHashes []string, dstHashes []string) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error) {
+	dao.lock.RLock()
+	defer dao.lock.RUnlock()
+	wrapperTransactions := make([]*models.WrapperTransaction, 0)
+	srcTransactions := make([]*models.SrcTransaction, 0)
+	polyTransactions := make([]*models.PolyTransaction, 0)
+	dstTransactions := make([]*models.DstTransaction, 0)
+	for _, hash := range wrapperHashes {
+		if item, ok := dao.wrapperTransactions[hash]; ok {
+			wrapperTransactions = append(wrapperTransactions, item)
+		}
+	}
+	for _, hash := range srcHashes {
+		if item, ok := dao.srcTransactions[hash]; ok {
+			srcTransactions = append(srcTransactions, item)
+		}
+	}
+	for _, hash := range polyHashes {
+		if item, ok := dao.polyTransactions[hash]; ok {
+			polyTransactions = append(polyTransactions, item)
+		}
+	}
+	for _, hash := range dstHashes {
+		if item, ok := dao.dstTransactions[hash]; ok {
+			dstTransactions = append(dstTransactions, item)
+		}
+	}
+	return wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, nil
+}
+
+func (dao *MemoryDao) WrapperTransactions() map[string]*models.WrapperTransaction {
+	dao.lock.RLock()
+	defer dao.lock.RUnlock()
+	items := make(map[string]*models.WrapperTransaction, len(dao.wrapperTransactions))
+	for k, v := range dao.wrapperTransactions {
+		items[k] = v
+	}
+	return items
+}
+
+func (dao *MemoryDao) SrcTransactions() map[string]*models.SrcTransaction {
+	dao.lock.RLock()
+	defer dao.lock.RUnlock()
+	items := make(map[string]*models.SrcTransaction, len(dao.srcTransactions))
+	for k, v := range dao.srcTransactions {
+		items[k] = v
+	}
+	return items
+}
+
+func (dao *MemoryDao) PolyTransactions() map[string]*models.PolyTransaction {
+	dao.lock.RLock()
+	defer dao.lock.RUnlock()
+	items := make(map[string]*models.PolyTransaction, len(dao.polyTransactions))
+	for k, v := range dao.polyTransactions {
+		items[k] = v
+	}
+	return items
+}
+
+func (dao *MemoryDao) DstTransactions() map[string]*models.DstTransaction {
+	dao.lock.RLock()
+	defer dao.lock.RUnlock()
+	items := make(map[string]*models.DstTransaction, len(dao.dstTransactions))
+	for k, v := range dao.dstTransactions {
+		items[k] = v
+	}
+	return items
+}
+
+func (dao *MemoryDao) FailedDstTransactions() map[string]*models.FailedDstTransaction {
+	dao.lock.RLock()
+	defer dao.lock.RUnlock()
+	items := make(map[string]*models.FailedDstTransaction, len(dao.failedDst))
+	for k, v := range dao.failedDst {
+		items[k] = v
+	}
+	return items
+}
+
+func (dao *MemoryDao) GetChain(chainId uint64) (*models.Chain, error) {
+	dao.lock.RLock()
+	defer dao.lock.RUnlock()
+	chain, ok := dao.chains[chainId]
+	if !ok {
+		return nil, fmt.Errorf("no record!")
+	}
+	item := *chain
+	item.HeightSwap = 0
+	return &item, nil
+}
+
+func (dao *MemoryDao) UpdateChain(chain *models.Chain) error {
+	if chain == nil || chain.ChainId == nil {
+		return fmt.Errorf("no value!")
+	}
+	dao.lock.Lock()
+	defer dao.lock.Unlock()
+	item := *chain
+	dao.chains[*chain.ChainId] = &item
+	return nil
+}
+
+func (dao *MemoryDao) AddChains(chains []*models.Chain, chainFees []*models.ChainFee) error {
+	dao.lock.Lock()
+	defer dao.lock.Unlock()
+	for _, chain := range chains {
+		if chain.ChainId == nil {
+			continue
+		}
+		item := *chain
+		dao.chains[*chain.ChainId] = &item
+	}
+	for _, chainFee := range chainFees {
+		item := *chainFee
+		dao.chainFees[chainFee.ChainId] = &item
+	}
+	return nil
+}
+
+func (dao *MemoryDao) AddTokens(tokens []*models.TokenBasic, tokenMaps []*models.TokenMap) error {
+	dao.lock.Lock()
+	defer dao.lock.Unlock()
+	for _, token := range tokens {
+		dao.tokenBasics[token.Name] = token
+	}
+	for _, tokenMap := range tokenMaps {
+		dao.tokenMaps[tokenMapKey(tokenMap)] = tokenMap
+	}
+	return nil
+}
+
+func (dao *MemoryDao) RemoveTokens(tokens []string) error {
+	dao.lock.Lock()
+	defer dao.lock.Unlock()
+	for _, token := range tokens {
+		delete(dao.tokenBasics, token)
+	}
+	return nil
+}
+
+func (dao *MemoryDao) RemoveTokenMaps(tokenMaps []*models.TokenMap) error {
+	dao.lock.Lock()
+	defer dao.lock.Unlock()
+	for _, tokenMap := range tokenMaps {
+		delete(dao.tokenMaps, tokenMapKey(tokenMap))
+	}
+	return nil
+}
+
+func (dao *MemoryDao) Name() string {
+	return basedef.SERVER_MEMORY
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package memorydao
+
+import (
+	"poly-bridge/basedef"
+	"poly-bridge/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryDao_UpdateEvents(t *testing.T) {
+	dao := NewMemoryDao()
+	chainId := uint64(2)
+	wrapper := &models.WrapperTransaction{Hash: "aa", DstChainId: 6, DstUser: "cc", ServerId: 1, Status: basedef.STATE_SOURCE_DONE, FeeAmount: models.NewBigIntFromInt(1)}
+	src := &models.SrcTransaction{Hash: "aa", ChainId: 2}
+	assert.Nil(t, dao.UpdateEvents(&models.Chain{ChainId: &chainId, Height: 100}, []*models.WrapperTransaction{wrapper}, []*models.SrcTransaction{src}, nil, nil))
+
+	dao.WrapperTransactions()["aa"].Status = basedef.STATE_FINISHED
+	// an upsert of the same events keeps the status of the wrapper transaction
+	wrapper.FeeAmount = models.NewBigIntFromInt(2)
+	assert.Nil(t, dao.UpdateEvents(&models.Chain{ChainId: &chainId, Height: 101}, []*models.WrapperTransaction{wrapper}, []*models.SrcTransaction{src}, nil, nil))
+	wrappers, srcs, _, _, err := dao.GetEvents([]string{"aa"}, []string{"aa", "bb"}, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(wrappers))
+	assert.Equal(t, uint64(basedef.STATE_FINISHED), wrappers[0].Status)
+	assert.Equal(t, "2", wrappers[0].FeeAmount.String())
+	assert.Equal(t, 1, len(srcs))
+
+	// a speed up only updates the fee of the lock
+	speedUp := &models.WrapperTransaction{Hash: "aa", FeeTokenHash: "dd", FeeAmount: models.NewBigIntFromInt(3)}
+	assert.Nil(t, dao.UpdateEvents(nil, []*models.WrapperTransaction{speedUp}, nil, nil, nil))
+	wrappers, _, _, _, err = dao.GetEvents([]string{"aa"}, nil, nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(6), wrappers[0].DstChainId)
+	assert.Equal(t, "cc", wrappers[0].DstUser)
+	assert.Equal(t, uint64(1), wrappers[0].ServerId)
+	assert.Equal(t, uint64(basedef.STATE_FINISHED), wrappers[0].Status)
+	assert.Equal(t, "dd", wrappers[0].FeeTokenHash)
+	assert.Equal(t, "3", wrappers[0].FeeAmount.String())
+
+	chain, err := dao.GetChain(chainId)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(101), chain.Height)
+
+	assert.Nil(t, dao.RemoveEvents([]string{"aa"}, nil, nil))
+	assert.Equal(t, 0, len(dao.WrapperTransactions()))
+	assert.Equal(t, 0, len(dao.SrcTransactions()))
+}
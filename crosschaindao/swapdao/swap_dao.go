@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 	"poly-bridge/basedef"
 	"poly-bridge/conf"
@@ -52,81 +53,129 @@ func NewSwapDao(dbCfg *conf.DBConfig, backup bool) *SwapDao {
 	return swapDao
 }
 
+// rows written by one INSERT ... ON DUPLICATE KEY UPDATE statement
+const upsertBatchSize = 100
+
+// the status of wrapper transactions is owned by the effect loop, re-processing a block must not reset it
+var wrapperUpsertColumns = []string{"user", "src_chain_id", "standard", "block_height", "time", "dst_chain_id", "dst_user", "server_id", "fee_token_hash", "fee_amount"}
+
+// a speed up only carries the fee of the locked transaction, the other columns of the lock are kept
+var wrapperSpeedUpColumns = []string{"fee_token_hash", "fee_amount"}
+
 func (dao *SwapDao) UpdateEvents(chain *models.Chain, wrapperTransactions []*models.WrapperTransaction, srcTransactions []*models.SrcTransaction, polyTransactions []*models.PolyTransaction, dstTransactions []*models.DstTransaction) error {
-	if !dao.backup {
-		if wrapperTransactions != nil && len(wrapperTransactions) > 0 {
-			res := dao.db.Save(wrapperTransactions)
-			if res.Error != nil {
-				return res.Error
+	return dao.db.Session(&gorm.Session{FullSaveAssociations: true}).Transaction(func(tx *gorm.DB) error {
+		if len(wrapperTransactions) > 0 {
+			locks, speedUps := models.SplitWrapperSpeedUps(wrapperTransactions)
+			if !dao.backup {
+				if len(locks) > 0 {
+					res := tx.Clauses(clause.OnConflict{DoUpdates: clause.AssignmentColumns(wrapperUpsertColumns)}).CreateInBatches(locks, upsertBatchSize)
+					if res.Error != nil {
+						return res.Error
+					}
+				}
+				if len(speedUps) > 0 {
+					res := tx.Clauses(clause.OnConflict{DoUpdates: clause.AssignmentColumns(wrapperSpeedUpColumns)}).CreateInBatches(speedUps, upsertBatchSize)
+					if res.Error != nil {
+						return res.Error
+					}
+				}
+			} else {
+				for _, wrapperTransaction := range locks {
+					wrapperTransaction.Status = 0
+					res := tx.Updates(wrapperTransaction)
+					if res.Error != nil {
+						return res.Error
+					}
+				}
+				for _, wrapperTransaction := range speedUps {
+					res := tx.Model(wrapperTransaction).Select(wrapperSpeedUpColumns).Updates(wrapperTransaction)
+					if res.Error != nil {
+						return res.Error
+					}
+				}
 			}
 		}
-		if srcTransactions != nil && len(srcTransactions) > 0 {
-			res := dao.db.Save(srcTransactions)
+		if len(srcTransactions) > 0 {
+			res := tx.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(srcTransactions, upsertBatchSize)
 			if res.Error != nil {
 				return res.Error
 			}
 		}
-		if polyTransactions != nil && len(polyTransactions) > 0 {
-			res := dao.db.Save(polyTransactions)
+		if len(polyTransactions) > 0 {
+			res := tx.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(polyTransactions, upsertBatchSize)
 			if res.Error != nil {
 				return res.Error
 			}
 		}
-		if dstTransactions != nil && len(dstTransactions) > 0 {
-			res := dao.db.Save(dstTransactions)
+		if len(dstTransactions) > 0 {
+			res := tx.Clauses(clause.OnConflict{UpdateAll: true}).CreateInBatches(dstTransactions, upsertBatchSize)
 			if res.Error != nil {
 				return res.Error
 			}
 		}
-		if chain != nil {
-			res := dao.db.Updates(chain)
+		if chain != nil && !dao.backup {
+			res := tx.Updates(chain)
 			if res.Error != nil {
 				return res.Error
 			}
 		}
 		return nil
-	} else {
-		if wrapperTransactions != nil && len(wrapperTransactions) > 0 {
-			for _, wrapperTransaction := range wrapperTransactions {
-				wrapperTransaction.Status = 0
-				res := dao.db.Updates(wrapperTransaction)
-				if res.Error != nil {
-					return res.Error
-				}
-			}
+	})
+}
+
+func (dao *SwapDao) RemoveEvents(srcHashes []string, polyHashes []string, dstHashes []string) error {
+	return dao.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("`tx_hash` in ?", srcHashes).Delete(&models.SrcTransfer{}).Error; err != nil {
+			return err
 		}
-		if srcTransactions != nil && len(srcTransactions) > 0 {
-			res := dao.db.Save(srcTransactions)
-			if res.Error != nil {
-				return res.Error
-			}
+		if err := tx.Where("`tx_hash` in ?", srcHashes).Delete(&models.MakeTxParam{}).Error; err != nil {
+			return err
 		}
-		if polyTransactions != nil && len(polyTransactions) > 0 {
-			res := dao.db.Save(polyTransactions)
-			if res.Error != nil {
-				return res.Error
-			}
+		if err := tx.Where("`hash` in ?", srcHashes).Delete(&models.SrcTransaction{}).Error; err != nil {
+			return err
 		}
-		if dstTransactions != nil && len(dstTransactions) > 0 {
-			res := dao.db.Save(dstTransactions)
-			if res.Error != nil {
-				return res.Error
-			}
+		if err := tx.Where("`hash` in ?", srcHashes).Delete(&models.WrapperTransaction{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("`hash` in ?", polyHashes).Delete(&models.PolyTransaction{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("`tx_hash` in ?", dstHashes).Delete(&models.DstTransfer{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("`hash` in ?", dstHashes).Delete(&models.DstTransaction{}).Error; err != nil {
+			return err
 		}
 		return nil
-	}
+	})
 }
 
-func (dao *SwapDao) RemoveEvents(srcHashes []string, polyHashes []string, dstHashes []string) error {
-	dao.db.Where("`tx_hash` in ?", srcHashes).Delete(&models.SrcTransfer{})
-	dao.db.Where("`hash` in ?", srcHashes).Delete(&models.SrcTransaction{})
-	dao.db.Where("`hash` in ?", srcHashes).Delete(&models.WrapperTransaction{})
-
-	dao.db.Where("`hash` in ?", polyHashes).Delete(&models.PolyTransaction{})
-
-	dao.db.Where("`tx_hash` in ?", dstHashes).Delete(&models.DstTransfer{})
-	dao.db.Where("`hash` in ?", dstHashes).Delete(&models.DstTransaction{})
-	return nil
+func (dao *SwapDao) GetEvents(wrapperHashes []string, srcHashes []string, polyHashes []string, dstHashes []string) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error) {
+	wrapperTransactions := make([]*models.WrapperTransaction, 0)
+	srcTransactions := make([]*models.SrcTransaction, 0)
+	polyTransactions := make([]*models.PolyTransaction, 0)
+	dstTransactions := make([]*models.DstTransaction, 0)
+	if len(wrapperHashes) > 0 {
+		if err := dao.db.Where("`hash` in ?", wrapperHashes).Find(&wrapperTransactions).Error; err != nil {
+			return nil, nil, nil, nil, err
+		}
+	}
+	if len(srcHashes) > 0 {
+		if err := dao.db.Where("`hash` in ?", srcHashes).Preload("SrcTransfer").Preload("MakeTxParam").Find(&srcTransactions).Error; err != nil {
+			return nil, nil, nil, nil, err
+		}
+	}
+	if len(polyHashes) > 0 {
+		if err := dao.db.Where("`hash` in ?", polyHashes).Find(&polyTransactions).Error; err != nil {
+			return nil, nil, nil, nil, err
+		}
+	}
+	if len(dstHashes) > 0 {
+		if err := dao.db.Where("`hash` in ?", dstHashes).Preload("DstTransfer").Find(&dstTransactions).Error; err != nil {
+			return nil, nil, nil, nil, err
+		}
+	}
+	return wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, nil
 }
 
 func (dao *SwapDao) GetChain(chainId uint64) (*models.Chain, error) {
@@ -19,12 +19,15 @@ package bridgeeffect
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/astaxie/beego/logs"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"poly-bridge/alert"
 	"poly-bridge/basedef"
 	"poly-bridge/conf"
+	"poly-bridge/metrics"
 	"poly-bridge/models"
 	"time"
 )
@@ -77,6 +80,10 @@ func (eff *BridgeEffect) Effect() error {
 	if err != nil {
 		logs.Error("update status- err: %s", err)
 	}
+	err = eff.countStatus()
+	if err != nil {
+		logs.Error("count status- err: %s", err)
+	}
 	err = eff.doStatistic()
 	if err != nil {
 		logs.Error("update status- err: %s", err)
@@ -120,6 +127,15 @@ func (eff *BridgeEffect) checkStatus() error {
 		if len(wrapperTransactions) > 0 {
 			wrapperTransactionsJson, _ := json.Marshal(wrapperTransactions)
 			logs.Error("There is unfinished transactions(%d) %s", now, string(wrapperTransactionsJson))
+			alert.Fire(&alert.Alert{
+				Key:      "unfinished_transactions_bsc_heco",
+				Severity: alert.SEVERITY_WARNING,
+				Source:   basedef.SERVER_POLY_BRIDGE,
+				Title:    fmt.Sprintf("There is unfinished transactions(%d)", len(wrapperTransactions)),
+				Message:  models.WrapperTransactionHashes(wrapperTransactions),
+			})
+		} else {
+			alert.Resolve("unfinished_transactions_bsc_heco", "all transactions are finished")
 		}
 	}
 	{
@@ -129,11 +145,35 @@ func (eff *BridgeEffect) checkStatus() error {
 		if len(wrapperTransactions) > 0 {
 			wrapperTransactionsJson, _ := json.Marshal(wrapperTransactions)
 			logs.Error("There is unfinished transactions(%d) %s", now, string(wrapperTransactionsJson))
+			alert.Fire(&alert.Alert{
+				Key:      "unfinished_transactions",
+				Severity: alert.SEVERITY_WARNING,
+				Source:   basedef.SERVER_POLY_BRIDGE,
+				Title:    fmt.Sprintf("There is unfinished transactions(%d)", len(wrapperTransactions)),
+				Message:  models.WrapperTransactionHashes(wrapperTransactions),
+			})
+		} else {
+			alert.Resolve("unfinished_transactions", "all transactions are finished")
 		}
 	}
 	return nil
 }
 
+func (eff *BridgeEffect) countStatus() error {
+	statusCounters := make([]*models.WrapperStatusCounter, 0)
+	res := eff.db.Model(&models.WrapperTransaction{}).Select("status, count(*) as counter").Where("status != ?", basedef.STATE_FINISHED).Group("status").Scan(&statusCounters)
+	if res.Error != nil {
+		return res.Error
+	}
+	counters := make(map[uint64]int64)
+	for _, statusCounter := range statusCounters {
+		counters[statusCounter.Status] = statusCounter.Counter
+	}
+	metrics.SetUnfinishedWrapperTransactions(counters)
+	return nil
+}
+
+
 func (eff *BridgeEffect) updateStatus() error {
 	chains := make([]*models.Chain, 0)
 	id2Chains := make(map[uint64]*models.Chain)
@@ -201,8 +241,18 @@ func (eff *BridgeEffect) checkChainListening() error {
 		if !ok {
 			continue
 		}
+		key := fmt.Sprintf("chain_not_listening_%d", *chain.ChainId)
 		if chain.Height == old.Height && chain.HeightSwap == old.HeightSwap {
 			logs.Error("Chain %d is not listening!", *chain.ChainId)
+			alert.Fire(&alert.Alert{
+				Key:      key,
+				Severity: alert.SEVERITY_CRITICAL,
+				Source:   basedef.SERVER_POLY_BRIDGE,
+				Title:    fmt.Sprintf("Chain %d is not listening!", *chain.ChainId),
+				Fields:   map[string]string{"height": fmt.Sprintf("%d", chain.Height)},
+			})
+		} else {
+			alert.Resolve(key, fmt.Sprintf("chain %d is listening, height: %d", *chain.ChainId, chain.Height))
 		}
 	}
 	eff.chains = chains
@@ -18,13 +18,15 @@
 package crosschaineffect
 
 import (
+	"context"
 	"github.com/astaxie/beego/logs"
+	"poly-bridge/admin"
 	"poly-bridge/basedef"
 	"poly-bridge/conf"
 	"poly-bridge/crosschaineffect/bridgeeffect"
 	"poly-bridge/crosschaineffect/explorereffect"
 	"poly-bridge/crosschaineffect/swapeffect"
-	"runtime/debug"
+	"poly-bridge/supervisor"
 	"time"
 )
 
@@ -43,11 +45,14 @@ func StartCrossChainEffect(server string, effCfg *conf.EventEffectConfig, dbCfg
 	}
 	crossChainEffect = NewCrossChainEffect(effect)
 	crossChainEffect.Start()
+	admin.Register("effect", crossChainEffect)
 }
 
 func StopCrossChainEffect() {
 	if crossChainEffect != nil {
+		admin.Unregister("effect")
 		crossChainEffect.Stop()
+		crossChainEffect = nil
 	}
 }
 
@@ -64,58 +69,53 @@ func NewEffect(server string, effCfg *conf.EventEffectConfig, dbCfg *conf.DBConf
 }
 
 type CrossChainEffect struct {
-	effect Effect
-	exit   chan bool
+	admin.Switch
+	effect     Effect
+	supervisor *supervisor.Supervisor
 }
 
 func NewCrossChainEffect(monitor Effect) *CrossChainEffect {
 	crossChainMonitor := &CrossChainEffect{
-		effect: monitor,
-		exit:   make(chan bool, 0),
+		effect:     monitor,
+		supervisor: supervisor.NewSupervisor("effect"),
 	}
 	return crossChainMonitor
 }
 
 func (eff *CrossChainEffect) Start() {
 	logs.Info("start cross chain effect.")
-	go eff.Check()
+	eff.supervisor.Go(eff.check)
 }
 
 func (eff *CrossChainEffect) Stop() {
-	eff.exit <- true
+	eff.supervisor.Stop()
 	logs.Info("stop cross chain effect.")
 }
 
+// Check checks the cross chain effect until it is stopped
 func (eff *CrossChainEffect) Check() {
-	for {
-		exit := eff.check()
-		if exit {
-			close(eff.exit)
-			break
-		}
-		time.Sleep(time.Second * 5)
-	}
+	eff.Start()
+	eff.supervisor.Wait()
 }
 
-func (eff *CrossChainEffect) check() (exit bool) {
-	defer func() {
-		if r := recover(); r != nil {
-			logs.Error("service start, recover info: %s", string(debug.Stack()))
-			exit = false
-		}
-	}()
+func (eff *CrossChainEffect) check(ctx context.Context) error {
 	logs.Debug("cross chain effect, server: %s......", eff.effect.Name())
 	ticker := time.NewTicker(time.Second * time.Duration(eff.effect.GetEffectSlot()))
+	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
+			if eff.Paused() {
+				continue
+			}
 			err := eff.effect.Effect()
+			eff.Ran(err)
 			if err != nil {
 				logs.Error("cross chain effect err: %v", err)
 			}
-		case <-eff.exit:
+		case <-ctx.Done():
 			logs.Info("cross chain effect exit, server: %s......", eff.effect.Name())
-			return true
+			return nil
 		}
 	}
 }
@@ -18,10 +18,12 @@
 package explorereffect
 
 import (
+	"fmt"
 	"github.com/astaxie/beego/logs"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"poly-bridge/alert"
 	"poly-bridge/basedef"
 	"poly-bridge/conf"
 	"poly-bridge/crosschaindao/explorerdao"
@@ -121,8 +123,18 @@ func (eff *ExplorerEffect) checkChainListening() error {
 		if !ok {
 			continue
 		}
+		key := fmt.Sprintf("chain_not_listening_%d", chain.ChainId)
 		if chain.Height == old.Height {
 			logs.Error("Chain %d is not listening!", chain.ChainId)
+			alert.Fire(&alert.Alert{
+				Key:      key,
+				Severity: alert.SEVERITY_CRITICAL,
+				Source:   basedef.SERVER_EXPLORER,
+				Title:    fmt.Sprintf("Chain %d is not listening!", chain.ChainId),
+				Fields:   map[string]string{"height": fmt.Sprintf("%d", chain.Height)},
+			})
+		} else {
+			alert.Resolve(key, fmt.Sprintf("chain %d is listening, height: %d", chain.ChainId, chain.Height))
 		}
 	}
 	eff.chains = chains
@@ -18,9 +18,12 @@
 package crosschainlisten
 
 import (
+	"context"
 	"fmt"
 	"github.com/astaxie/beego/logs"
 	"math"
+	"poly-bridge/admin"
+	"poly-bridge/alert"
 	"poly-bridge/basedef"
 	"poly-bridge/conf"
 	"poly-bridge/crosschaindao"
@@ -28,170 +31,681 @@ import (
 	"poly-bridge/crosschainlisten/neolisten"
 	"poly-bridge/crosschainlisten/ontologylisten"
 	"poly-bridge/crosschainlisten/polylisten"
+	"poly-bridge/metrics"
 	"poly-bridge/models"
-	"runtime/debug"
+	"poly-bridge/supervisor"
+	"sort"
+	"sync"
 	"time"
 )
 
-var chainListens [10]*CrossChainListen
+var chainListens []*CrossChainListen
 
 func StartCrossChainListen(server string, backup bool, listenCfg []*conf.ChainListenConfig, dbCfg *conf.DBConfig) {
 	dao := crosschaindao.NewCrossChainDao(server, backup, dbCfg)
 	if dao == nil {
 		panic("server is not valid")
 	}
-	for i, cfg := range listenCfg {
+	chainListens = make([]*CrossChainListen, 0, len(listenCfg))
+	for _, cfg := range listenCfg {
 		chainHandle := NewChainHandle(cfg)
 		if chainHandle == nil {
 			panic(fmt.Sprintf("chain %d handler is invalid", cfg.ChainId))
 		}
 		chainListen := NewCrossChainListen(chainHandle, dao)
 		chainListen.Start()
-		chainListens[i] = chainListen
+		chainListens = append(chainListens, chainListen)
+		admin.Register(chainListen.ServiceName(), chainListen)
 	}
 }
 
+// StopCrossChainListen stops the listens of all chains at the same time
 func StopCrossChainListen() {
+	wg := sync.WaitGroup{}
 	for _, chainListen := range chainListens {
 		if chainListen != nil {
-			chainListen.Stop()
+			admin.Unregister(chainListen.ServiceName())
+			wg.Add(1)
+			go func(chainListen *CrossChainListen) {
+				defer wg.Done()
+				chainListen.Stop()
+			}(chainListen)
 		}
 	}
+	wg.Wait()
+	chainListens = nil
 }
 
 type ChainHandle interface {
 	GetExtendLatestHeight() (uint64, error)
 	GetLatestHeight() (uint64, error)
 	HandleNewBlock(height uint64) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error)
+	HandleNewBlocks(heightStart uint64, heightEnd uint64) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error)
 	GetChainListenSlot() uint64
 	GetChainId() uint64
 	GetChainName() string
 	GetDefer() uint64
+	GetBatchSize() uint64
+}
+
+// FailedUnlockHandle is implemented by chain handles which can find the reverted executions of poly
+// transactions on the chain.
+type FailedUnlockHandle interface {
+	HandleFailedUnlocks(heightStart uint64, heightEnd uint64) ([]*models.FailedDstTransaction, error)
+}
+
+// QuorumHandle is implemented by chain handles which can handle the blocks again on independent nodes,
+// the events are stored only when both agree. HandleQuorumBlocks returns an empty hash of the last block
+// if the chain handle does not track block hashes.
+type QuorumHandle interface {
+	QuorumEnabled() bool
+	HandleQuorumBlocks(heightStart uint64, heightEnd uint64) (string, []*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error)
+}
+
+// ReorgHandle is implemented by chain handles whose blocks can be replaced by a reorganization.
+type ReorgHandle interface {
+	GetBlockHash(height uint64) (hash string, parentHash string, err error)
+}
+
+// HeadHandle is implemented by chain handles which can push the heights of new blocks as they arrive.
+// StartHeads returns nil when pushing is not configured. A pushed head only triggers the listener, the
+// blocks are handled up to the height of the selected node which PollLatestHeight gets from the nodes.
+type HeadHandle interface {
+	StartHeads() <-chan uint64
+	HeadsConnected() bool
+	StopHeads()
+	PollLatestHeight() (uint64, error)
+}
+
+// how many processed blocks are remembered for reorganization detection
+const reorgTrackDepth = 128
+
+// while new heads are pushed, only one in so many ticks polls the chain
+const headsPollInterval = 10
+
+// how long a chain whose rollback failed is held before it is checked again, doubled on every failure
+const (
+	rollbackBackoff    = time.Second * 10
+	rollbackBackoffMax = time.Minute * 10
+)
+
+// blockRecord is a handled block, the hash is empty for the blocks handled in a range but the last one.
+// After a restart only the hash of the last handled block is known, which is kept in the chains table.
+type blockRecord struct {
+	hash       string
+	parentHash string
+	srcHashes  []string
+	polyHashes []string
+	dstHashes  []string
 }
 
 func NewChainHandle(chainListenConfig *conf.ChainListenConfig) ChainHandle {
-	if chainListenConfig.ChainId == basedef.ETHEREUM_CROSSCHAIN_ID {
+	switch basedef.GetChainFamily(chainListenConfig.ChainId) {
+	case basedef.CHAIN_FAMILY_EVM:
 		return ethereumlisten.NewEthereumChainListen(chainListenConfig)
-	} else if chainListenConfig.ChainId == basedef.POLY_CROSSCHAIN_ID {
+	case basedef.CHAIN_FAMILY_POLY:
 		return polylisten.NewPolyChainListen(chainListenConfig)
-	} else if chainListenConfig.ChainId == basedef.NEO_CROSSCHAIN_ID {
+	case basedef.CHAIN_FAMILY_NEO:
 		return neolisten.NewNeoChainListen(chainListenConfig)
-	} else if chainListenConfig.ChainId == basedef.BSC_CROSSCHAIN_ID {
-		return ethereumlisten.NewEthereumChainListen(chainListenConfig)
-	} else if chainListenConfig.ChainId == basedef.HECO_CROSSCHAIN_ID {
-		return ethereumlisten.NewEthereumChainListen(chainListenConfig)
-	} else if chainListenConfig.ChainId == basedef.ONT_CROSSCHAIN_ID {
+	case basedef.CHAIN_FAMILY_ONTOLOGY:
 		return ontologylisten.NewOntologyChainListen(chainListenConfig)
-	} else if chainListenConfig.ChainId == basedef.OK_CROSSCHAIN_ID {
-		return ethereumlisten.NewEthereumChainListen(chainListenConfig)
-	} else {
+	default:
 		return nil
 	}
 }
 
 type CrossChainListen struct {
-	handle ChainHandle
-	db     crosschaindao.CrossChainDao
-	exit   chan bool
-	height uint64
+	admin.Switch
+	handle       ChainHandle
+	db           crosschaindao.CrossChainDao
+	supervisor   *supervisor.Supervisor
+	blocks       map[uint64]*blockRecord
+	batchSize    uint64
+	heads        <-chan uint64
+	stateLock    sync.Mutex
+	height       uint64 // the listen height to be set
+	skips        map[uint64]bool
+	listenHeight uint64
+	latestHeight uint64
+	rollbacks    uint      // the failed rollbacks in a row
+	holdUntil    time.Time // the chain is not handled before it after a failed rollback
 }
 
 func NewCrossChainListen(handle ChainHandle, db crosschaindao.CrossChainDao) *CrossChainListen {
 	crossChainListen := &CrossChainListen{
 		handle: handle,
 		db:     db,
-		exit:   make(chan bool, 0),
+		blocks: make(map[uint64]*blockRecord),
+		skips:  make(map[uint64]bool),
 	}
+	crossChainListen.supervisor = supervisor.NewSupervisor(crossChainListen.ServiceName())
+	crossChainListen.batchSize = crossChainListen.handle.GetBatchSize()
 	return crossChainListen
 }
 
+func (ccl *CrossChainListen) ServiceName() string {
+	return fmt.Sprintf("listen_%d", ccl.handle.GetChainId())
+}
+
+// SetHeight sets the listen height before the next block is handled, the blocks after it are handled
+// again when it is lower.
 func (ccl *CrossChainListen) SetHeight(height uint64) {
+	ccl.stateLock.Lock()
+	defer ccl.stateLock.Unlock()
 	ccl.height = height
 }
 
+// SkipBlock marks the block handled without its events when the listen height reaches it.
+func (ccl *CrossChainListen) SkipBlock(height uint64) error {
+	ccl.stateLock.Lock()
+	defer ccl.stateLock.Unlock()
+	if height <= ccl.listenHeight {
+		return fmt.Errorf("block %d is handled already, listen height: %d", height, ccl.listenHeight)
+	}
+	ccl.skips[height] = true
+	return nil
+}
+
+func (ccl *CrossChainListen) Status() *admin.Status {
+	status := ccl.Switch.Status()
+	ccl.stateLock.Lock()
+	defer ccl.stateLock.Unlock()
+	status.Height = ccl.listenHeight
+	status.LatestHeight = ccl.latestHeight
+	status.PendingHeight = ccl.height
+	for height := range ccl.skips {
+		status.Skips = append(status.Skips, height)
+	}
+	sort.Slice(status.Skips, func(i, j int) bool { return status.Skips[i] < status.Skips[j] })
+	return status
+}
+
+// applyHeight moves the chain to the height which is set, it returns false if no height is set
+func (ccl *CrossChainListen) applyHeight(chain *models.Chain) bool {
+	ccl.stateLock.Lock()
+	height := ccl.height
+	ccl.height = 0
+	ccl.stateLock.Unlock()
+	if height == 0 {
+		return false
+	}
+	logs.Warn("ListenChain - chain %s listen height is set from %d to %d", ccl.handle.GetChainName(), chain.Height, height)
+	chain.Height = height
+	ccl.blocks = make(map[uint64]*blockRecord)
+	ccl.rollbacks, ccl.holdUntil = 0, time.Time{}
+	if err := ccl.db.UpdateChain(chain); err != nil {
+		logs.Error("UpdateChain %s err: %v", ccl.handle.GetChainName(), err)
+	}
+	return true
+}
+
+// skipBlock marks the next block handled if it is to be skipped
+func (ccl *CrossChainListen) skipBlock(chain *models.Chain) (bool, error) {
+	ccl.stateLock.Lock()
+	skip := ccl.skips[chain.Height+1]
+	ccl.stateLock.Unlock()
+	if !skip {
+		return false, nil
+	}
+	logs.Warn("ListenChain - chain %s skip block %d", ccl.handle.GetChainName(), chain.Height+1)
+	chain.Height += 1
+	if err := ccl.db.UpdateChain(chain); err != nil {
+		chain.Height -= 1
+		return true, err
+	}
+	delete(ccl.blocks, chain.Height)
+	ccl.stateLock.Lock()
+	delete(ccl.skips, chain.Height)
+	ccl.stateLock.Unlock()
+	return true, nil
+}
+
+func (ccl *CrossChainListen) hasSkip(start uint64, end uint64) bool {
+	ccl.stateLock.Lock()
+	defer ccl.stateLock.Unlock()
+	for height := range ccl.skips {
+		if height >= start && height <= end {
+			return true
+		}
+	}
+	return false
+}
+
+func (ccl *CrossChainListen) updateStatus(chain *models.Chain, height uint64) {
+	ccl.Ran(nil)
+	ccl.stateLock.Lock()
+	defer ccl.stateLock.Unlock()
+	ccl.listenHeight = chain.Height
+	ccl.latestHeight = height
+}
+
 func (ccl *CrossChainListen) Start() {
 	logs.Info("start cross chain listen: %s", ccl.handle.GetChainName())
-	go ccl.ListenChain()
+	if headHandle, ok := ccl.handle.(HeadHandle); ok {
+		ccl.heads = headHandle.StartHeads()
+	}
+	ccl.supervisor.Go(ccl.listenChain)
 }
 
 func (ccl *CrossChainListen) Stop() {
-	ccl.exit <- true
+	ccl.supervisor.Stop()
+	if headHandle, ok := ccl.handle.(HeadHandle); ok {
+		headHandle.StopHeads()
+	}
 	logs.Info("stop cross chain listen: %s", ccl.handle.GetChainName())
 }
 
+// ListenChain listens the chain until the listen is stopped
 func (ccl *CrossChainListen) ListenChain() {
-	for {
-		exit := ccl.listenChain()
-		if exit {
-			close(ccl.exit)
-			break
-		}
-		time.Sleep(time.Second * 5)
-	}
+	ccl.Start()
+	ccl.supervisor.Wait()
 }
 
-func (ccl *CrossChainListen) listenChain() (exit bool) {
-	defer func() {
-		if r := recover(); r != nil {
-			logs.Error("service start, recover info: %s", string(debug.Stack()))
-			exit = false
-		}
-	}()
+func (ccl *CrossChainListen) listenChain(ctx context.Context) error {
 	chain, err := ccl.db.GetChain(ccl.handle.GetChainId())
 	if err != nil {
-		panic(err)
+		return err
 	}
 	height, err := ccl.handle.GetLatestHeight()
 	if err != nil || height == 0 {
-		panic(err)
+		return fmt.Errorf("cannot get chain %s height, err: %v", ccl.handle.GetChainName(), err)
 	}
 	if chain.Height == 0 {
 		chain.Height = height
 	}
 	ccl.db.UpdateChain(chain)
-	if ccl.height != 0 {
-		chain.Height = ccl.height
+	ccl.applyHeight(chain)
+	if _, ok := ccl.blocks[chain.Height]; !ok && chain.BlockHash != "" && chain.BlockHeight == chain.Height {
+		ccl.blocks[chain.Height] = &blockRecord{hash: chain.BlockHash}
 	}
 	logs.Info("cross chain listen, chain: %s, dao: %s......", ccl.handle.GetChainName(), ccl.db.Name())
 	ticker := time.NewTicker(time.Second * time.Duration(ccl.handle.GetChainListenSlot()))
+	defer ticker.Stop()
+	ticks := 0
 	for {
 		select {
+		case <-ccl.heads:
+			height, err := ccl.handle.(HeadHandle).PollLatestHeight()
+			if err != nil || height == 0 || height == math.MaxUint64 {
+				logs.Error("listenChain - cannot poll chain %s height, err: %s", ccl.handle.GetChainName(), err)
+				continue
+			}
+			ccl.catchUp(chain, height)
 		case <-ticker.C:
+			ticks++
+			if headHandle, ok := ccl.handle.(HeadHandle); ok && headHandle.HeadsConnected() && ticks%headsPollInterval != 0 {
+				continue
+			}
 			var height, err = ccl.handle.GetLatestHeight()
 			if err != nil || height == 0 || height == math.MaxUint64 {
 				logs.Error("listenChain - cannot get chain %s height, err: %s", ccl.handle.GetChainName(), err)
+				alert.Fire(&alert.Alert{
+					Key:      fmt.Sprintf("chain_height_%d", ccl.handle.GetChainId()),
+					Severity: alert.SEVERITY_WARNING,
+					Source:   "crosschainlisten",
+					Title:    fmt.Sprintf("cannot get chain %s height", ccl.handle.GetChainName()),
+					Message:  fmt.Sprintf("%v", err),
+				})
 				continue
 			}
+			alert.Resolve(fmt.Sprintf("chain_height_%d", ccl.handle.GetChainId()), fmt.Sprintf("chain %s height is %d", ccl.handle.GetChainName(), height))
+			metrics.SetListenHeight(ccl.handle.GetChainId(), chain.Height, height)
 			extendHeight, err := ccl.handle.GetExtendLatestHeight()
 			if err != nil || extendHeight == 0 {
 				logs.Error("ListenChain - cannot get chain %s extend height, err: %s", ccl.handle.GetChainName(), err)
 			} else if extendHeight >= height+21 {
 				logs.Error("ListenChain - chain %s node is too slow, node height: %d, really height: %d", ccl.handle.GetChainName(), height, extendHeight)
+				alert.Fire(&alert.Alert{
+					Key:      fmt.Sprintf("chain_node_slow_%d", ccl.handle.GetChainId()),
+					Severity: alert.SEVERITY_WARNING,
+					Source:   "crosschainlisten",
+					Title:    fmt.Sprintf("chain %s node is too slow", ccl.handle.GetChainName()),
+					Fields:   map[string]string{"node height": fmt.Sprintf("%d", height), "really height": fmt.Sprintf("%d", extendHeight)},
+				})
+			} else {
+				alert.Resolve(fmt.Sprintf("chain_node_slow_%d", ccl.handle.GetChainId()), fmt.Sprintf("chain %s node height is %d, really height: %d", ccl.handle.GetChainName(), height, extendHeight))
 			}
-			if chain.Height >= height-ccl.handle.GetDefer() {
-				continue
+			if err == nil && extendHeight != 0 {
+				metrics.SetExtendNodeHeight(ccl.handle.GetChainId(), extendHeight)
 			}
-			logs.Info("ListenChain - chain %s latest height is %d, listen height: %d", ccl.handle.GetChainName(), height, chain.Height)
-			for chain.Height < height-ccl.handle.GetDefer() {
-				wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, err := ccl.handle.HandleNewBlock(chain.Height + 1)
-				if len(wrapperTransactions) != len(srcTransactions) {
-					logs.Error("Possible inconsistent chain %d height %d wrapper %d src %d", chain.ChainId, chain.Height, len(wrapperTransactions), len(srcTransactions))
-				}
-				if err != nil {
-					logs.Error("HandleNewBlock %d err: %v", chain.Height+1, err)
-					break
-				}
-				chain.Height += 1
-				err = ccl.db.UpdateEvents(chain, wrapperTransactions, srcTransactions, polyTransactions, dstTransactions)
-				if err != nil {
-					logs.Error("UpdateEvents on block %d err: %v", chain.Height+1, err)
-					chain.Height -= 1
-					break
-				}
-			}
-		case <-ccl.exit:
+			ccl.catchUp(chain, height)
+		case <-ctx.Done():
 			logs.Info("cross chain listen exit, chain: %s, dao: %s......", ccl.handle.GetChainName(), ccl.db.Name())
-			return true
+			return nil
+		}
+	}
+}
+
+// catchUp handles the blocks from the listen height up to the given chain height less the defer.
+func (ccl *CrossChainListen) catchUp(chain *models.Chain, height uint64) {
+	defer ccl.updateStatus(chain, height)
+	ccl.applyHeight(chain)
+	if ccl.Paused() || chain.Height >= height-ccl.handle.GetDefer() || time.Now().Before(ccl.holdUntil) {
+		return
+	}
+	logs.Info("ListenChain - chain %s latest height is %d, listen height: %d", ccl.handle.GetChainName(), height, chain.Height)
+	for chain.Height < height-ccl.handle.GetDefer() && !ccl.Paused() && !ccl.supervisor.Stopping() {
+		if ccl.applyHeight(chain) {
+			continue
+		}
+		if skipped, err := ccl.skipBlock(chain); err != nil {
+			logs.Error("skip block %d err: %v", chain.Height+1, err)
+			break
+		} else if skipped {
+			continue
+		}
+		if ccl.handle.GetBatchSize() > 1 && height-ccl.handle.GetDefer()-chain.Height > ccl.handle.GetBatchSize() &&
+			!ccl.hasSkip(chain.Height+1, chain.Height+ccl.batchSize) {
+			if !ccl.handleNewBlocks(chain, height-ccl.handle.GetDefer()) {
+				break
+			}
+			continue
+		}
+		var block *blockRecord
+		if reorgHandle, ok := ccl.handle.(ReorgHandle); ok {
+			hash, parentHash, err := reorgHandle.GetBlockHash(chain.Height + 1)
+			if err != nil {
+				logs.Error("GetBlockHash %d err: %v", chain.Height+1, err)
+				break
+			}
+			if reorganized, err := ccl.reorganized(reorgHandle, chain, parentHash); err != nil {
+				ccl.hold(chain, err)
+				break
+			} else if reorganized {
+				break
+			}
+			block = &blockRecord{hash: hash, parentHash: parentHash}
+		}
+		handleStart := time.Now()
+		wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, err := ccl.handle.HandleNewBlock(chain.Height + 1)
+		metrics.ObserveHandleBlock(ccl.handle.GetChainId(), "block", handleStart, err)
+		if len(wrapperTransactions) != len(srcTransactions) {
+			logs.Error("Possible inconsistent chain %d height %d wrapper %d src %d", chain.ChainId, chain.Height, len(wrapperTransactions), len(srcTransactions))
+		}
+		if err != nil {
+			logs.Error("HandleNewBlock %d err: %v", chain.Height+1, err)
+			break
+		}
+		failedDstTransactions, err := ccl.handleFailedUnlocks(chain.Height+1, chain.Height+1)
+		if err != nil {
+			logs.Error("HandleFailedUnlocks %d err: %v", chain.Height+1, err)
+			break
+		}
+		hash := ""
+		if block != nil {
+			hash = block.hash
+		}
+		if !ccl.verifyQuorum(chain.Height+1, chain.Height+1, hash, wrapperTransactions, srcTransactions, polyTransactions, dstTransactions) {
+			break
+		}
+		lastHeight, lastHash := chain.BlockHeight, chain.BlockHash
+		chain.Height += 1
+		if block != nil {
+			chain.BlockHeight, chain.BlockHash = chain.Height, block.hash
 		}
+		updateStart := time.Now()
+		err = ccl.updateEvents(chain, wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, failedDstTransactions)
+		metrics.ObserveUpdateEvents(ccl.handle.GetChainId(), updateStart, err)
+		if err != nil {
+			logs.Error("UpdateEvents on block %d err: %v", chain.Height+1, err)
+			chain.Height -= 1
+			chain.BlockHeight, chain.BlockHash = lastHeight, lastHash
+			break
+		}
+		if block != nil {
+			ccl.addBlock(chain.Height, block, wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, failedDstTransactions)
+		}
+	}
+	metrics.SetListenHeight(ccl.handle.GetChainId(), chain.Height, height)
+}
+
+// handleNewBlocks indexes a range of blocks at once while the listener is far behind the chain.
+// The range shrinks when the node fails to serve it and grows back after every success.
+func (ccl *CrossChainListen) handleNewBlocks(chain *models.Chain, target uint64) bool {
+	start := chain.Height + 1
+	end := chain.Height + ccl.batchSize
+	if end > target {
+		end = target
+	}
+	reorgHandle, tracked := ccl.handle.(ReorgHandle)
+	hash := ""
+	if tracked {
+		_, parentHash, err := reorgHandle.GetBlockHash(start)
+		if err != nil {
+			logs.Error("GetBlockHash %d err: %v", start, err)
+			return false
+		}
+		if reorganized, err := ccl.reorganized(reorgHandle, chain, parentHash); err != nil {
+			ccl.hold(chain, err)
+			return false
+		} else if reorganized {
+			return false
+		}
+		hash, _, err = reorgHandle.GetBlockHash(end)
+		if err != nil {
+			logs.Error("GetBlockHash %d err: %v", end, err)
+			return false
+		}
+	}
+	logs.Info("ListenChain - chain %s handle blocks from %d to %d", ccl.handle.GetChainName(), start, end)
+	handleStart := time.Now()
+	wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, err := ccl.handle.HandleNewBlocks(start, end)
+	metrics.ObserveHandleBlock(ccl.handle.GetChainId(), "range", handleStart, err)
+	if err != nil {
+		logs.Error("HandleNewBlocks %d-%d err: %v", start, end, err)
+		if ccl.batchSize > 1 {
+			ccl.batchSize /= 2
+		}
+		return false
+	}
+	failedDstTransactions, err := ccl.handleFailedUnlocks(start, end)
+	if err != nil {
+		logs.Error("HandleFailedUnlocks %d-%d err: %v", start, end, err)
+		return false
+	}
+	if !ccl.verifyQuorum(start, end, "", wrapperTransactions, srcTransactions, polyTransactions, dstTransactions) {
+		return false
+	}
+	lastHeight, lastHash := chain.BlockHeight, chain.BlockHash
+	chain.Height = end
+	if tracked {
+		chain.BlockHeight, chain.BlockHash = end, hash
+	}
+	updateStart := time.Now()
+	err = ccl.updateEvents(chain, wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, failedDstTransactions)
+	metrics.ObserveUpdateEvents(ccl.handle.GetChainId(), updateStart, err)
+	if err != nil {
+		logs.Error("UpdateEvents on blocks %d-%d err: %v", start, end, err)
+		chain.Height = start - 1
+		chain.BlockHeight, chain.BlockHash = lastHeight, lastHash
+		return false
+	}
+	if tracked {
+		ccl.addBlocks(start, end, hash, wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, failedDstTransactions)
+	}
+	ccl.batchSize *= 2
+	if ccl.batchSize > ccl.handle.GetBatchSize() {
+		ccl.batchSize = ccl.handle.GetBatchSize()
+	}
+	return true
+}
+
+func (ccl *CrossChainListen) addBlock(height uint64, block *blockRecord, wrapperTransactions []*models.WrapperTransaction, srcTransactions []*models.SrcTransaction, polyTransactions []*models.PolyTransaction, dstTransactions []*models.DstTransaction, failedDstTransactions []*models.FailedDstTransaction) {
+	for _, item := range wrapperTransactions {
+		block.srcHashes = append(block.srcHashes, item.Hash)
+	}
+	for _, item := range srcTransactions {
+		block.srcHashes = append(block.srcHashes, item.Hash)
+	}
+	for _, item := range polyTransactions {
+		block.polyHashes = append(block.polyHashes, item.Hash)
+	}
+	for _, item := range dstTransactions {
+		block.dstHashes = append(block.dstHashes, item.Hash)
+	}
+	for _, item := range failedDstTransactions {
+		block.dstHashes = append(block.dstHashes, item.Hash)
+	}
+	ccl.blocks[height] = block
+	if height > reorgTrackDepth {
+		delete(ccl.blocks, height-reorgTrackDepth)
+	}
+}
+
+// addBlocks tracks the last blocks of a range with their events, only the hash of the last block is known.
+func (ccl *CrossChainListen) addBlocks(start uint64, end uint64, hash string, wrapperTransactions []*models.WrapperTransaction, srcTransactions []*models.SrcTransaction, polyTransactions []*models.PolyTransaction, dstTransactions []*models.DstTransaction, failedDstTransactions []*models.FailedDstTransaction) {
+	first := start
+	if end >= reorgTrackDepth && end-reorgTrackDepth+1 > first {
+		first = end - reorgTrackDepth + 1
+	}
+	for height := range ccl.blocks {
+		if height+reorgTrackDepth <= end {
+			delete(ccl.blocks, height)
+		}
+	}
+	for height := first; height <= end; height++ {
+		ccl.blocks[height] = &blockRecord{}
+	}
+	ccl.blocks[end].hash = hash
+	for _, item := range wrapperTransactions {
+		if block, ok := ccl.blocks[item.BlockHeight]; ok && item.BlockHeight >= first {
+			block.srcHashes = append(block.srcHashes, item.Hash)
+		}
+	}
+	for _, item := range srcTransactions {
+		if block, ok := ccl.blocks[item.Height]; ok && item.Height >= first {
+			block.srcHashes = append(block.srcHashes, item.Hash)
+		}
+	}
+	for _, item := range polyTransactions {
+		if block, ok := ccl.blocks[item.Height]; ok && item.Height >= first {
+			block.polyHashes = append(block.polyHashes, item.Hash)
+		}
+	}
+	for _, item := range dstTransactions {
+		if block, ok := ccl.blocks[item.Height]; ok && item.Height >= first {
+			block.dstHashes = append(block.dstHashes, item.Hash)
+		}
+	}
+	for _, item := range failedDstTransactions {
+		if block, ok := ccl.blocks[item.Height]; ok && item.Height >= first {
+			block.dstHashes = append(block.dstHashes, item.Hash)
+		}
+	}
+}
+
+// reorganized checks the parent hash of the next block against the hash of the last handled block, and rolls
+// the chain back when they differ. The error of the rollback is returned, the chain is not rewound then.
+func (ccl *CrossChainListen) reorganized(reorgHandle ReorgHandle, chain *models.Chain, parentHash string) (bool, error) {
+	last, ok := ccl.blocks[chain.Height]
+	if !ok || last.hash == "" || last.hash == parentHash {
+		return false, nil
+	}
+	logs.Error("ListenChain - chain %s reorganized, parent hash of block %d is %s, expect %s", ccl.handle.GetChainName(), chain.Height+1, parentHash, last.hash)
+	alert.Fire(&alert.Alert{
+		Key:      fmt.Sprintf("chain_reorg_%d", ccl.handle.GetChainId()),
+		Severity: alert.SEVERITY_INFO,
+		Source:   "crosschainlisten",
+		Title:    fmt.Sprintf("chain %s reorganized at block %d", ccl.handle.GetChainName(), chain.Height+1),
+		Fields:   map[string]string{"parent hash": parentHash, "expect": last.hash},
+	})
+	if err := ccl.rollback(reorgHandle, chain); err != nil {
+		return true, err
+	}
+	if ccl.rollbacks > 0 {
+		ccl.rollbacks, ccl.holdUntil = 0, time.Time{}
+		alert.Resolve(fmt.Sprintf("chain_rollback_%d", ccl.handle.GetChainId()), fmt.Sprintf("chain %s is rolled back to %d", ccl.handle.GetChainName(), chain.Height))
+	}
+	return true, nil
+}
+
+// hold stops handling the chain for a backoff which doubles with every failed rollback in a row. The chain
+// is checked again after it, or at once when the listen height is set by the admin.
+func (ccl *CrossChainListen) hold(chain *models.Chain, err error) {
+	backoff := rollbackBackoff << ccl.rollbacks
+	if backoff > rollbackBackoffMax || backoff <= 0 {
+		backoff = rollbackBackoffMax
+	}
+	ccl.rollbacks++
+	ccl.holdUntil = time.Now().Add(backoff)
+	logs.Error("rollback chain %s from block %d err: %v, hold for %v", ccl.handle.GetChainName(), chain.Height, err, backoff)
+	alert.Fire(&alert.Alert{
+		Key:      fmt.Sprintf("chain_rollback_%d", ccl.handle.GetChainId()),
+		Severity: alert.SEVERITY_CRITICAL,
+		Source:   "crosschainlisten",
+		Title:    fmt.Sprintf("chain %s cannot be rolled back from block %d", ccl.handle.GetChainName(), chain.Height),
+		Message:  fmt.Sprintf("%v", err),
+		Fields:   map[string]string{"failures": fmt.Sprintf("%d", ccl.rollbacks), "hold": backoff.String()},
+	})
+}
+
+// rollback walks back from chain.Height to the last block which is still on the canonical chain,
+// removes the events of the orphaned blocks and rewinds the chain height to the fork point. The blocks
+// of a range whose hashes are unknown are removed until a block with a known hash is canonical. Nothing
+// is removed when no tracked block is canonical, the fork point is unknown then.
+func (ccl *CrossChainListen) rollback(reorgHandle ReorgHandle, chain *models.Chain) error {
+	srcHashes, polyHashes, dstHashes := make([]string, 0), make([]string, 0), make([]string, 0)
+	height := chain.Height
+	for ; ; height-- {
+		block, ok := ccl.blocks[height]
+		if !ok || height == 0 {
+			return fmt.Errorf("reorganization is deeper than the tracked blocks from %d to %d", height+1, chain.Height)
+		}
+		if block.hash != "" {
+			hash, _, err := reorgHandle.GetBlockHash(height)
+			if err != nil {
+				return err
+			}
+			if hash == block.hash {
+				break
+			}
+		}
+		srcHashes = append(srcHashes, block.srcHashes...)
+		polyHashes = append(polyHashes, block.polyHashes...)
+		dstHashes = append(dstHashes, block.dstHashes...)
+	}
+	if height == chain.Height {
+		return fmt.Errorf("block %d is not reorganized", height)
+	}
+	logs.Info("rollback - chain %s from %d to %d, src: %v, poly: %v, dst: %v", ccl.handle.GetChainName(), chain.Height, height, srcHashes, polyHashes, dstHashes)
+	err := ccl.db.RemoveEvents(srcHashes, polyHashes, dstHashes)
+	if err != nil {
+		return err
+	}
+	for i := height + 1; i <= chain.Height; i++ {
+		delete(ccl.blocks, i)
+	}
+	chain.Height = height
+	if block, ok := ccl.blocks[height]; ok && block.hash != "" {
+		chain.BlockHeight, chain.BlockHash = height, block.hash
+	}
+	return ccl.db.UpdateChain(chain)
+}
+
+// handleFailedUnlocks finds the reverted executions of poly transactions in the blocks when both the
+// handle and the dao support them.
+func (ccl *CrossChainListen) handleFailedUnlocks(start uint64, end uint64) ([]*models.FailedDstTransaction, error) {
+	failedUnlockHandle, ok := ccl.handle.(FailedUnlockHandle)
+	if !ok {
+		return nil, nil
+	}
+	if _, ok := ccl.db.(crosschaindao.FailedDstDao); !ok {
+		return nil, nil
+	}
+	failedDstTransactions, err := failedUnlockHandle.HandleFailedUnlocks(start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, failed := range failedDstTransactions {
+		logs.Warn("(failed unlock) to chain: %s, txhash: %s, poly hash: %s, reason: %s", ccl.handle.GetChainName(), failed.Hash, failed.PolyHash, failed.Reason)
+	}
+	return failedDstTransactions, nil
+}
+
+// updateEvents stores the events and the reverted executions of the blocks in one transaction.
+func (ccl *CrossChainListen) updateEvents(chain *models.Chain, wrapperTransactions []*models.WrapperTransaction, srcTransactions []*models.SrcTransaction, polyTransactions []*models.PolyTransaction, dstTransactions []*models.DstTransaction, failedDstTransactions []*models.FailedDstTransaction) error {
+	if failedDstDao, ok := ccl.db.(crosschaindao.FailedDstDao); ok {
+		return failedDstDao.UpdateEventsWithFailedDst(chain, wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, failedDstTransactions)
 	}
+	return ccl.db.UpdateEvents(chain, wrapperTransactions, srcTransactions, polyTransactions, dstTransactions)
 }
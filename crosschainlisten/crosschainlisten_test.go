@@ -0,0 +1,454 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package crosschainlisten
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"poly-bridge/crosschaindao/memorydao"
+	"poly-bridge/crosschaindao/stakedao"
+	"poly-bridge/models"
+)
+
+type testReorgHandle struct {
+	ChainHandle
+	hashes map[uint64]string
+}
+
+func (h *testReorgHandle) GetChainName() string {
+	return "test"
+}
+
+func (h *testReorgHandle) GetChainId() uint64 {
+	return 6
+}
+
+func (h *testReorgHandle) GetBatchSize() uint64 {
+	return 0
+}
+
+func (h *testReorgHandle) GetBlockHash(height uint64) (string, string, error) {
+	return h.hashes[height], h.hashes[height-1], nil
+}
+
+type testRemoveDao struct {
+	*stakedao.StakeDao
+	srcHashes  []string
+	polyHashes []string
+	dstHashes  []string
+}
+
+func (dao *testRemoveDao) RemoveEvents(srcHashes []string, polyHashes []string, dstHashes []string) error {
+	dao.srcHashes = append(dao.srcHashes, srcHashes...)
+	dao.polyHashes = append(dao.polyHashes, polyHashes...)
+	dao.dstHashes = append(dao.dstHashes, dstHashes...)
+	return nil
+}
+
+func TestCrossChainListen_Rollback(t *testing.T) {
+	dao := &testRemoveDao{StakeDao: stakedao.NewStakeDao()}
+	handle := &testReorgHandle{hashes: map[uint64]string{99: "a99", 100: "a100", 101: "b101", 102: "b102", 103: "b103"}}
+	ccl := NewCrossChainListen(handle, dao)
+	ccl.addBlock(100, &blockRecord{hash: "a100"}, nil, nil, nil, nil, nil)
+	ccl.addBlock(101, &blockRecord{hash: "a101"}, []*models.WrapperTransaction{{Hash: "w101"}}, []*models.SrcTransaction{{Hash: "s101"}}, nil, nil, nil)
+	ccl.addBlock(102, &blockRecord{hash: "a102"}, nil, nil, nil, []*models.DstTransaction{{Hash: "d102"}}, []*models.FailedDstTransaction{{Hash: "f102"}})
+
+	chain := &models.Chain{ChainId: new(uint64), Height: 102}
+	*chain.ChainId = 6
+	assert.NoError(t, ccl.rollback(handle, chain))
+	assert.Equal(t, uint64(100), chain.Height)
+	assert.ElementsMatch(t, []string{"w101", "s101"}, dao.srcHashes)
+	assert.ElementsMatch(t, []string{"d102", "f102"}, dao.dstHashes)
+	assert.Empty(t, dao.polyHashes)
+	assert.Len(t, ccl.blocks, 1)
+
+	dao.srcHashes, dao.dstHashes = nil, nil
+	chain.Height = 100
+	assert.Error(t, ccl.rollback(handle, chain))
+	assert.Empty(t, dao.srcHashes)
+
+	// no tracked block is canonical, the chain is not rewound to an unknown fork point
+	ccl.blocks = make(map[uint64]*blockRecord)
+	ccl.addBlock(101, &blockRecord{hash: "a101"}, nil, []*models.SrcTransaction{{Hash: "s101"}}, nil, nil, nil)
+	ccl.addBlock(102, &blockRecord{hash: "a102"}, nil, nil, nil, nil, nil)
+	chain.Height = 102
+	assert.Error(t, ccl.rollback(handle, chain))
+	assert.Equal(t, uint64(102), chain.Height)
+	assert.Empty(t, dao.srcHashes)
+	assert.Len(t, ccl.blocks, 2)
+}
+
+func TestCrossChainListen_Reorganized(t *testing.T) {
+	dao := &testRemoveDao{StakeDao: stakedao.NewStakeDao()}
+	handle := &testReorgHandle{hashes: map[uint64]string{99: "a99", 100: "a100", 101: "a101"}}
+	ccl := NewCrossChainListen(handle, dao)
+	ccl.addBlock(100, &blockRecord{hash: "a100"}, nil, nil, nil, nil, nil)
+	ccl.addBlock(101, &blockRecord{hash: "a101"}, nil, nil, nil, nil, nil)
+	chain := &models.Chain{ChainId: new(uint64), Height: 101}
+	*chain.ChainId = 6
+
+	reorganized, err := ccl.reorganized(handle, chain, "a101")
+	assert.NoError(t, err)
+	assert.False(t, reorganized)
+
+	// another node does not see the reorganization, the rollback fails and the chain is held
+	reorganized, err = ccl.reorganized(handle, chain, "b101")
+	assert.Error(t, err)
+	assert.True(t, reorganized)
+	assert.Equal(t, uint64(101), chain.Height)
+	ccl.hold(chain, err)
+	ccl.hold(chain, err)
+	assert.Equal(t, uint(2), ccl.rollbacks)
+	assert.True(t, ccl.holdUntil.After(time.Now().Add(rollbackBackoff)))
+	ccl.rollbacks = 20
+	ccl.hold(chain, err)
+	assert.False(t, ccl.holdUntil.After(time.Now().Add(rollbackBackoffMax)))
+
+	handle.hashes[101] = "b101"
+	reorganized, err = ccl.reorganized(handle, chain, "b101")
+	assert.NoError(t, err)
+	assert.True(t, reorganized)
+	assert.Equal(t, uint64(100), chain.Height)
+	assert.Equal(t, uint(0), ccl.rollbacks)
+	assert.True(t, ccl.holdUntil.IsZero())
+}
+
+type testBatchHandle struct {
+	ChainHandle
+	maxRange uint64
+	ranges   [][2]uint64
+}
+
+func (h *testBatchHandle) GetChainName() string {
+	return "test"
+}
+
+func (h *testBatchHandle) GetChainId() uint64 {
+	return 0
+}
+
+func (h *testBatchHandle) GetBatchSize() uint64 {
+	return 100
+}
+
+func (h *testBatchHandle) HandleNewBlocks(heightStart uint64, heightEnd uint64) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error) {
+	h.ranges = append(h.ranges, [2]uint64{heightStart, heightEnd})
+	if heightEnd-heightStart+1 > h.maxRange {
+		return nil, nil, nil, nil, fmt.Errorf("range too large")
+	}
+	return nil, nil, nil, nil, nil
+}
+
+func TestCrossChainListen_HandleNewBlocks(t *testing.T) {
+	handle := &testBatchHandle{maxRange: 30}
+	ccl := NewCrossChainListen(handle, stakedao.NewStakeDao())
+	chain := &models.Chain{ChainId: new(uint64), Height: 1000}
+	assert.False(t, ccl.handleNewBlocks(chain, 2000))
+	assert.False(t, ccl.handleNewBlocks(chain, 2000))
+	assert.Equal(t, uint64(1000), chain.Height)
+	assert.True(t, ccl.handleNewBlocks(chain, 2000))
+	assert.Equal(t, uint64(1025), chain.Height)
+	assert.False(t, ccl.handleNewBlocks(chain, 2000))
+	assert.True(t, ccl.handleNewBlocks(chain, 2000))
+	assert.Equal(t, uint64(1050), chain.Height)
+	assert.Equal(t, [2]uint64{1026, 1050}, handle.ranges[len(handle.ranges)-1])
+
+	handle.maxRange = 1000
+	assert.True(t, ccl.handleNewBlocks(chain, 1060))
+	assert.Equal(t, uint64(1060), chain.Height)
+	assert.Equal(t, uint64(100), ccl.batchSize)
+}
+
+type testReorgBatchHandle struct {
+	testBatchHandle
+	hashes map[uint64]string
+}
+
+func (h *testReorgBatchHandle) HandleNewBlocks(heightStart uint64, heightEnd uint64) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error) {
+	srcTransactions := make([]*models.SrcTransaction, 0)
+	for height := heightStart; height <= heightEnd; height++ {
+		srcTransactions = append(srcTransactions, &models.SrcTransaction{Hash: fmt.Sprintf("s%d", height), Height: height})
+	}
+	return nil, srcTransactions, nil, nil, nil
+}
+
+func (h *testReorgBatchHandle) GetBlockHash(height uint64) (string, string, error) {
+	return h.hashes[height], h.hashes[height-1], nil
+}
+
+func TestCrossChainListen_HandleNewBlocksReorg(t *testing.T) {
+	handle := &testReorgBatchHandle{testBatchHandle: testBatchHandle{maxRange: 1000}, hashes: make(map[uint64]string)}
+	for height := uint64(900); height <= 1100; height++ {
+		handle.hashes[height] = fmt.Sprintf("a%d", height)
+	}
+	dao := memorydao.NewMemoryDao()
+	ccl := NewCrossChainListen(handle, dao)
+	chain := &models.Chain{ChainId: new(uint64), Height: 900}
+	assert.True(t, ccl.handleNewBlocks(chain, 1000))
+	assert.True(t, ccl.handleNewBlocks(chain, 1050))
+	assert.Equal(t, uint64(1050), chain.Height)
+	assert.Equal(t, uint64(1050), chain.BlockHeight)
+	assert.Equal(t, "a1050", chain.BlockHash)
+	assert.Equal(t, "a1000", ccl.blocks[1000].hash)
+	assert.Equal(t, []string{"s1020"}, ccl.blocks[1020].srcHashes)
+	assert.Len(t, ccl.blocks, reorgTrackDepth)
+
+	for height := uint64(1040); height <= 1100; height++ {
+		handle.hashes[height] = fmt.Sprintf("b%d", height)
+	}
+	assert.False(t, ccl.handleNewBlocks(chain, 1100))
+	assert.Equal(t, uint64(1000), chain.Height)
+	assert.Equal(t, "a1000", chain.BlockHash)
+	assert.Len(t, dao.SrcTransactions(), 100)
+	assert.True(t, ccl.handleNewBlocks(chain, 1100))
+	assert.Equal(t, "b1100", chain.BlockHash)
+	assert.Len(t, dao.SrcTransactions(), 200)
+}
+
+type testFailedUnlockHandle struct {
+	testBatchHandle
+	failed map[uint64]*models.FailedDstTransaction
+}
+
+func (h *testFailedUnlockHandle) HandleFailedUnlocks(heightStart uint64, heightEnd uint64) ([]*models.FailedDstTransaction, error) {
+	failedDstTransactions := make([]*models.FailedDstTransaction, 0)
+	for height := heightStart; height <= heightEnd; height++ {
+		if failed, ok := h.failed[height]; ok {
+			failedDstTransactions = append(failedDstTransactions, failed)
+		}
+	}
+	return failedDstTransactions, nil
+}
+
+func TestCrossChainListen_HandleFailedUnlocks(t *testing.T) {
+	handle := &testFailedUnlockHandle{testBatchHandle: testBatchHandle{maxRange: 1000}, failed: map[uint64]*models.FailedDstTransaction{
+		1010: {Hash: "d1010", PolyHash: "p1010", Height: 1010},
+		2010: {Hash: "d2010", PolyHash: "p2010", Height: 2010},
+	}}
+	dao := memorydao.NewMemoryDao()
+	ccl := NewCrossChainListen(handle, dao)
+	chain := &models.Chain{ChainId: new(uint64), Height: 1000}
+	assert.True(t, ccl.handleNewBlocks(chain, 1050))
+	assert.Equal(t, uint64(1050), chain.Height)
+	failed := dao.FailedDstTransactions()
+	assert.Equal(t, 1, len(failed))
+	assert.Equal(t, "p1010", failed["d1010"].PolyHash)
+	assert.Nil(t, dao.RemoveEvents(nil, nil, []string{"d1010"}))
+	assert.Empty(t, dao.FailedDstTransactions())
+}
+
+type testQuorumHandle struct {
+	testBatchHandle
+	hash       string
+	quorumHash string
+	quorumDst  []*models.DstTransaction
+	dst        []*models.DstTransaction
+}
+
+func (h *testQuorumHandle) HandleNewBlocks(heightStart uint64, heightEnd uint64) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error) {
+	return nil, nil, nil, h.dst, nil
+}
+
+func (h *testQuorumHandle) GetBlockHash(height uint64) (string, string, error) {
+	return h.hash, "", nil
+}
+
+func (h *testQuorumHandle) QuorumEnabled() bool {
+	return true
+}
+
+func (h *testQuorumHandle) HandleQuorumBlocks(heightStart uint64, heightEnd uint64) (string, []*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error) {
+	return h.quorumHash, nil, nil, nil, h.quorumDst, nil
+}
+
+func TestCrossChainListen_VerifyQuorum(t *testing.T) {
+	dst := &models.DstTransaction{Hash: "d1010", PolyHash: "p1010", DstTransfer: &models.DstTransfer{Asset: "a", To: "u", Amount: models.NewBigInt(big.NewInt(100))}}
+	forged := &models.DstTransaction{Hash: "d1010", PolyHash: "p1010", DstTransfer: &models.DstTransfer{Asset: "a", To: "u", Amount: models.NewBigInt(big.NewInt(10000))}}
+	handle := &testQuorumHandle{testBatchHandle: testBatchHandle{maxRange: 1000}, hash: "h1050", quorumHash: "h1050",
+		dst: []*models.DstTransaction{forged}, quorumDst: []*models.DstTransaction{dst}}
+	dao := memorydao.NewMemoryDao()
+	ccl := NewCrossChainListen(handle, dao)
+	chain := &models.Chain{ChainId: new(uint64), Height: 1000}
+	assert.False(t, ccl.handleNewBlocks(chain, 1050))
+	assert.Equal(t, uint64(1000), chain.Height)
+	assert.Equal(t, 0, len(dao.DstTransactions()))
+
+	handle.dst = []*models.DstTransaction{}
+	assert.False(t, ccl.handleNewBlocks(chain, 1050))
+	assert.Equal(t, uint64(1000), chain.Height)
+
+	handle.dst = []*models.DstTransaction{dst}
+	handle.quorumHash = "g1050"
+	assert.False(t, ccl.handleNewBlocks(chain, 1050))
+	assert.Equal(t, uint64(1000), chain.Height)
+
+	handle.quorumHash = "h1050"
+	assert.True(t, ccl.handleNewBlocks(chain, 1050))
+	assert.Equal(t, uint64(1050), chain.Height)
+	assert.Equal(t, 1, len(dao.DstTransactions()))
+}
+
+func TestQuorumDiff(t *testing.T) {
+	assert.Equal(t, "", quorumDiff([]string{"a", "b"}, []string{"a", "b"}))
+	assert.Equal(t, "b, quorum c", quorumDiff([]string{"a", "b"}, []string{"a", "c"}))
+	assert.Equal(t, "missing b", quorumDiff([]string{"a"}, []string{"a", "b"}))
+	assert.Equal(t, "unexpected b", quorumDiff([]string{"a", "b"}, []string{"a"}))
+}
+
+type testBlockHandle struct {
+	ChainHandle
+	handled []uint64
+}
+
+func (h *testBlockHandle) GetChainName() string {
+	return "test"
+}
+
+func (h *testBlockHandle) GetChainId() uint64 {
+	return 6
+}
+
+func (h *testBlockHandle) GetBatchSize() uint64 {
+	return 0
+}
+
+func (h *testBlockHandle) GetDefer() uint64 {
+	return 0
+}
+
+func (h *testBlockHandle) HandleNewBlock(height uint64) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error) {
+	h.handled = append(h.handled, height)
+	return nil, nil, nil, nil, nil
+}
+
+func TestCrossChainListen_Admin(t *testing.T) {
+	handle := &testBlockHandle{}
+	dao := memorydao.NewMemoryDao()
+	ccl := NewCrossChainListen(handle, dao)
+	assert.Equal(t, "listen_6", ccl.ServiceName())
+	chain := &models.Chain{ChainId: new(uint64), Height: 100}
+	*chain.ChainId = 6
+
+	assert.NoError(t, ccl.SkipBlock(102))
+	ccl.catchUp(chain, 104)
+	assert.Equal(t, []uint64{101, 103, 104}, handle.handled)
+	assert.Equal(t, uint64(104), chain.Height)
+	assert.Error(t, ccl.SkipBlock(103))
+
+	handle.handled = nil
+	ccl.SetHeight(101)
+	assert.Equal(t, uint64(101), ccl.Status().PendingHeight)
+	ccl.catchUp(chain, 104)
+	assert.Equal(t, []uint64{102, 103, 104}, handle.handled)
+
+	handle.handled = nil
+	ccl.Pause()
+	ccl.SetHeight(90)
+	assert.NoError(t, ccl.SkipBlock(105))
+	ccl.catchUp(chain, 106)
+	assert.Empty(t, handle.handled)
+	stored, err := dao.GetChain(6)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(90), stored.Height)
+	status := ccl.Status()
+	assert.True(t, status.Paused)
+	assert.Equal(t, uint64(90), status.Height)
+	assert.Equal(t, uint64(106), status.LatestHeight)
+	assert.Equal(t, []uint64{105}, status.Skips)
+
+	ccl.Resume()
+	ccl.SetHeight(103)
+	ccl.catchUp(chain, 106)
+	assert.Equal(t, []uint64{104, 106}, handle.handled)
+	assert.Empty(t, ccl.Status().Skips)
+}
+
+type testHeadHandle struct {
+	ChainHandle
+	heads   chan uint64
+	handled chan uint64
+	stopped bool
+}
+
+func (h *testHeadHandle) GetChainName() string {
+	return "test"
+}
+
+func (h *testHeadHandle) GetChainId() uint64 {
+	return 6
+}
+
+func (h *testHeadHandle) GetBatchSize() uint64 {
+	return 0
+}
+
+func (h *testHeadHandle) GetDefer() uint64 {
+	return 1
+}
+
+func (h *testHeadHandle) GetChainListenSlot() uint64 {
+	return 3600
+}
+
+func (h *testHeadHandle) GetLatestHeight() (uint64, error) {
+	return 5698327, nil
+}
+
+func (h *testHeadHandle) HandleNewBlock(height uint64) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error) {
+	h.handled <- height
+	return nil, nil, nil, nil, nil
+}
+
+func (h *testHeadHandle) StartHeads() <-chan uint64 {
+	return h.heads
+}
+
+func (h *testHeadHandle) HeadsConnected() bool {
+	return true
+}
+
+func (h *testHeadHandle) StopHeads() {
+	h.stopped = true
+}
+
+func (h *testHeadHandle) PollLatestHeight() (uint64, error) {
+	return 5698330, nil
+}
+
+func TestCrossChainListen_Heads(t *testing.T) {
+	handle := &testHeadHandle{heads: make(chan uint64, 1), handled: make(chan uint64, 10)}
+	ccl := NewCrossChainListen(handle, stakedao.NewStakeDao())
+	ccl.Start()
+	// the pushed height is ahead of the polled height of the selected node
+	handle.heads <- 5698340
+	for _, height := range []uint64{5698328, 5698329} {
+		select {
+		case handled := <-handle.handled:
+			assert.Equal(t, height, handled)
+		case <-time.After(time.Second * 5):
+			t.Fatalf("block %d is not handled", height)
+		}
+	}
+	ccl.Stop()
+	assert.True(t, handle.stopped)
+	assert.Empty(t, handle.handled)
+}
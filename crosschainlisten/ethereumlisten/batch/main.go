@@ -136,7 +136,7 @@ func startServer(ctx *cli.Context) {
 		panic("chain is invalid")
 	}
 
-	chainHandler := ethereumlisten.NewEthereumChainListenBatch(chainListenConfig)
+	chainHandler := ethereumlisten.NewEthereumChainListen(chainListenConfig)
 	if chainHandler == nil {
 		panic("chain handler is invalid")
 	}
@@ -172,7 +172,7 @@ func startServer(ctx *cli.Context) {
 					end = height - chainHandler.GetDefer()
 				}
 				logs.Info("start handle block: %d, %d", start, end)
-				wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, err := chainHandler.HandleNewBlock(start, end)
+				wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, err := chainHandler.HandleNewBlocks(start, end)
 				if err != nil {
 					logs.Error("HandleNewBlock %d err: %v", start, err)
 					break
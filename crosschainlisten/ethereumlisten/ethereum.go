@@ -18,24 +18,20 @@
 package ethereumlisten
 
 import (
-	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"net/http"
 	"net/url"
 	"poly-bridge/basedef"
 	"poly-bridge/chainsdk"
 	"poly-bridge/conf"
-	"poly-bridge/go_abi/eccm_abi"
-	"poly-bridge/go_abi/lock_proxy_abi"
-	"poly-bridge/go_abi/wrapper_abi"
 	"poly-bridge/models"
-	"strings"
 
 	"github.com/astaxie/beego/logs"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 )
@@ -48,17 +44,30 @@ const (
 )
 
 type EthereumChainListen struct {
-	ethCfg *conf.ChainListenConfig
-	ethSdk *chainsdk.EthereumSdkPro
+	ethCfg     *conf.ChainListenConfig
+	ethSdk     *chainsdk.EthereumSdkPro
+	heads      *chainsdk.EthereumHeads
+	quorum     *EthereumChainListen
+	contracts  map[common.Address][]*eventContract
+	eventQuery ethereum.FilterQuery
 }
 
 func NewEthereumChainListen(cfg *conf.ChainListenConfig) *EthereumChainListen {
 	ethListen := &EthereumChainListen{}
 	ethListen.ethCfg = cfg
+	ethListen.contracts = newEventContracts(cfg)
+	ethListen.eventQuery = newEventQuery(ethListen.contracts)
 	//
 	urls := cfg.GetNodesUrl()
 	sdk := chainsdk.NewEthereumSdkPro(urls, cfg.ListenSlot, cfg.ChainId)
+	sdk.SetCacheConfirmations(cfg.Defer)
 	ethListen.ethSdk = sdk
+	if wsUrls := cfg.GetWsNodesUrl(); len(wsUrls) > 0 {
+		ethListen.heads = chainsdk.NewEthereumHeads(wsUrls, cfg.ChainId)
+	}
+	if quorumCfg := cfg.GetQuorumConfig(); quorumCfg != nil {
+		ethListen.quorum = NewEthereumChainListen(quorumCfg)
+	}
 	return ethListen
 }
 
@@ -82,54 +91,85 @@ func (this *EthereumChainListen) GetDefer() uint64 {
 	return this.ethCfg.Defer
 }
 
-func (this *EthereumChainListen) HandleNewBlock(height uint64) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error) {
-	blockHeader, err := this.ethSdk.GetHeaderByNumber(height)
+func (this *EthereumChainListen) GetBlockHash(height uint64) (string, string, error) {
+	hash, parentHash, err := this.ethSdk.GetBlockHashByNumber(height)
 	if err != nil {
-		return nil, nil, nil, nil, err
+		return "", "", err
 	}
-	if blockHeader == nil {
-		return nil, nil, nil, nil, fmt.Errorf("there is no ethereum block!")
+	return hash.String(), parentHash.String(), nil
+}
+
+func (this *EthereumChainListen) StartHeads() <-chan uint64 {
+	if this.heads == nil {
+		return nil
 	}
-	tt := blockHeader.Time
+	this.heads.Start()
+	return this.heads.Heads()
+}
 
-	wrapperTransactions := make([]*models.WrapperTransaction, 0)
-	erc20WrapperTransactions, err := this.getWrapperEventByBlockNumber(this.ethCfg.WrapperContract, height, height)
-	if err != nil {
-		return nil, nil, nil, nil, err
+func (this *EthereumChainListen) HeadsConnected() bool {
+	return this.heads != nil && this.heads.Connected()
+}
+
+func (this *EthereumChainListen) StopHeads() {
+	if this.heads != nil {
+		this.heads.Stop()
 	}
-	nftWrapperTransactions, err := this.getNFTWrapperEventByBlockNumber(this.ethCfg.NFTWrapperContract, height, height)
+}
+
+func (this *EthereumChainListen) PollLatestHeight() (uint64, error) {
+	return this.ethSdk.PollLatestHeight()
+}
+
+func (this *EthereumChainListen) GetBatchSize() uint64 {
+	return this.ethCfg.BatchSize
+}
+
+func (this *EthereumChainListen) QuorumEnabled() bool {
+	return this.quorum != nil
+}
+
+// HandleQuorumBlocks handles the blocks again on the quorum nodes, and returns the hash of the last one
+func (this *EthereumChainListen) HandleQuorumBlocks(heightStart uint64, heightEnd uint64) (string, []*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error) {
+	hash, _, err := this.quorum.GetBlockHash(heightEnd)
 	if err != nil {
-		return nil, nil, nil, nil, err
+		return "", nil, nil, nil, nil, err
 	}
-	wrapperTransactions = append(wrapperTransactions, erc20WrapperTransactions...)
-	wrapperTransactions = append(wrapperTransactions, nftWrapperTransactions...)
+	wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, err := this.quorum.HandleNewBlocks(heightStart, heightEnd)
+	return hash, wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, err
+}
 
-	for _, item := range wrapperTransactions {
-		logs.Info("(wrapper) from chain: %s, txhash: %s", this.GetChainName(), item.Hash)
-		item.Time = tt
-		item.SrcChainId = this.GetChainId()
-		item.Status = basedef.STATE_SOURCE_DONE
-	}
-	eccmLockEvents, eccmUnLockEvents, err := this.getECCMEventByBlockNumber(this.ethCfg.CCMContract, height, height)
+func (this *EthereumChainListen) HandleNewBlock(height uint64) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error) {
+	return this.HandleNewBlocks(height, height)
+}
+
+func (this *EthereumChainListen) HandleNewBlocks(heightStart uint64, heightEnd uint64) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error) {
+	blockTimes := make(map[uint64]uint64)
+	_, err := this.getBlockTime(heightEnd, blockTimes)
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
 
-	proxyLockEvents, proxyUnlockEvents := make([]*models.ProxyLockEvent, 0), make([]*models.ProxyUnlockEvent, 0)
-	erc20ProxyLockEvents, erc20ProxyUnlockEvents, err := this.getProxyEventByBlockNumber(this.ethCfg.ProxyContract, height, height)
+	events, err := this.getEventsByBlockNumber(heightStart, heightEnd)
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
-
-	nftProxyLockEvents, nftProxyUnlockEvents, err := this.getNFTProxyEventByBlockNumber(this.ethCfg.NFTProxyContract, height, height)
-	if err != nil {
+	if err := this.getBlockTimes(events, blockTimes); err != nil {
 		return nil, nil, nil, nil, err
 	}
-
-	proxyLockEvents = append(proxyLockEvents, erc20ProxyLockEvents...)
-	proxyUnlockEvents = append(proxyUnlockEvents, erc20ProxyUnlockEvents...)
-	proxyLockEvents = append(proxyLockEvents, nftProxyLockEvents...)
-	proxyUnlockEvents = append(proxyUnlockEvents, nftProxyUnlockEvents...)
+	wrapperTransactions := events.wrapperTransactions
+	for _, item := range wrapperTransactions {
+		logs.Info("(wrapper) from chain: %s, txhash: %s", this.GetChainName(), item.Hash)
+		tt, err := this.getBlockTime(item.BlockHeight, blockTimes)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		item.Time = tt
+		item.SrcChainId = this.GetChainId()
+		item.Status = basedef.STATE_SOURCE_DONE
+	}
+	eccmLockEvents, eccmUnLockEvents := events.eccmLockEvents, events.eccmUnlockEvents
+	proxyLockEvents, proxyUnlockEvents := events.proxyLockEvents, events.proxyUnlockEvents
 
 	//
 	srcTransactions := make([]*models.SrcTransaction, 0)
@@ -137,11 +177,17 @@ func (this *EthereumChainListen) HandleNewBlock(height uint64) ([]*models.Wrappe
 	for _, lockEvent := range eccmLockEvents {
 		if lockEvent.Method == _eth_crosschainlock {
 			logs.Info("(lock) from chain: %s, txhash: %s, txid: %s", this.GetChainName(), lockEvent.TxHash, lockEvent.Txid)
+			tt, err := this.getBlockTime(lockEvent.Height, blockTimes)
+			if err != nil {
+				return nil, nil, nil, nil, err
+			}
 			srcTransaction := &models.SrcTransaction{}
 			srcTransaction.ChainId = this.GetChainId()
 			srcTransaction.Hash = lockEvent.TxHash
 			srcTransaction.State = 1
-			srcTransaction.Fee = models.NewBigIntFromInt(int64(lockEvent.Fee))
+			srcTransaction.Fee = models.NewBigInt(lockEvent.Fee)
+			srcTransaction.GasUsed = lockEvent.GasUsed
+			srcTransaction.GasPrice = models.NewBigInt(lockEvent.GasPrice)
 			srcTransaction.Time = tt
 			srcTransaction.Height = lockEvent.Height
 			srcTransaction.User = lockEvent.User
@@ -163,6 +209,7 @@ func (this *EthereumChainListen) HandleNewBlock(height uint64) ([]*models.Wrappe
 					srcTransfer.DstChainId = uint64(v.ToChainId)
 					srcTransfer.DstAsset = toAssetHash
 					srcTransfer.DstUser = v.ToAddress
+					srcTransfer.Proxy = v.Proxy
 					srcTransaction.SrcTransfer = srcTransfer
 					if this.isNFTECCMLockEvent(lockEvent) {
 						srcTransaction.Standard = models.TokenTypeErc721
@@ -171,20 +218,31 @@ func (this *EthereumChainListen) HandleNewBlock(height uint64) ([]*models.Wrappe
 					break
 				}
 			}
-			if srcTransaction.SrcTransfer != nil {
-				srcTransactions = append(srcTransactions, srcTransaction)
+			if srcTransaction.SrcTransfer == nil {
+				srcTransaction.MessageType = models.MessageTypeCall
+			}
+			srcTransaction.MakeTxParam, err = models.DecodeMakeTxParam(srcTransaction.Hash, srcTransaction.Param, srcTransaction.Standard)
+			if err != nil {
+				logs.Warn("decode param of chain: %s, txhash: %s, err: %v", this.GetChainName(), srcTransaction.Hash, err)
 			}
+			srcTransactions = append(srcTransactions, srcTransaction)
 		}
 	}
 	// save unLockEvent to db
 	for _, unLockEvent := range eccmUnLockEvents {
 		if unLockEvent.Method == _eth_crosschainunlock {
 			logs.Info("(unlock) to chain: %s, txhash: %s", this.GetChainName(), unLockEvent.TxHash)
+			tt, err := this.getBlockTime(unLockEvent.Height, blockTimes)
+			if err != nil {
+				return nil, nil, nil, nil, err
+			}
 			dstTransaction := &models.DstTransaction{}
 			dstTransaction.ChainId = this.GetChainId()
 			dstTransaction.Hash = unLockEvent.TxHash
 			dstTransaction.State = 1
-			dstTransaction.Fee = models.NewBigIntFromInt(int64(unLockEvent.Fee))
+			dstTransaction.Fee = models.NewBigInt(unLockEvent.Fee)
+			dstTransaction.GasUsed = unLockEvent.GasUsed
+			dstTransaction.GasPrice = models.NewBigInt(unLockEvent.GasPrice)
 			dstTransaction.Time = tt
 			dstTransaction.Height = unLockEvent.Height
 			dstTransaction.SrcChainId = uint64(unLockEvent.FChainId)
@@ -200,6 +258,7 @@ func (this *EthereumChainListen) HandleNewBlock(height uint64) ([]*models.Wrappe
 					dstTransfer.To = v.ToAddress
 					dstTransfer.Asset = v.ToAssetHash
 					dstTransfer.Amount = models.NewBigInt(v.Amount)
+					dstTransfer.Proxy = v.Proxy
 					dstTransaction.DstTransfer = dstTransfer
 					if this.isNFTECCMUnlockEvent(unLockEvent) {
 						dstTransaction.Standard = models.TokenTypeErc721
@@ -208,194 +267,74 @@ func (this *EthereumChainListen) HandleNewBlock(height uint64) ([]*models.Wrappe
 					break
 				}
 			}
-			if dstTransaction.DstTransfer != nil {
-				dstTransactions = append(dstTransactions, dstTransaction)
+			if dstTransaction.DstTransfer == nil {
+				dstTransaction.MessageType = models.MessageTypeCall
 			}
+			dstTransactions = append(dstTransactions, dstTransaction)
 		}
 	}
 	return wrapperTransactions, srcTransactions, nil, dstTransactions, nil
 }
 
-func (this *EthereumChainListen) getWrapperEventByBlockNumber(contractAddrs []string, startHeight uint64, endHeight uint64) ([]*models.WrapperTransaction, error) {
-	txs := make([]*models.WrapperTransaction, 0)
-	for i, contract := range contractAddrs {
-		aaa, err := this.getWrapperEventByBlockNumber1(contract, startHeight, endHeight, i)
-		if err != nil {
-			return nil, err
-		}
-		txs = append(txs, aaa...)
-	}
-	return txs, nil
-}
-
-func (this *EthereumChainListen) getWrapperEventByBlockNumber1(contractAddr string, startHeight uint64, endHeight uint64, index int) ([]*models.WrapperTransaction, error) {
-	if len(contractAddr) == 0 {
-		return nil, nil
-	}
-	wrapperAddress := common.HexToAddress(contractAddr)
-	wrapperContract, err := wrapper_abi.NewIPolyWrapper(wrapperAddress, this.ethSdk.GetClient())
-	if err != nil {
-		return nil, fmt.Errorf("GetSmartContractEventByBlock, error: %s", err.Error())
-	}
-	opt := &bind.FilterOpts{
-		Start:   startHeight,
-		End:     &endHeight,
-		Context: context.Background(),
-	}
-	// get ethereum lock events from given block
-	wrapperTransactions := make([]*models.WrapperTransaction, 0)
-	lockEvents, err := wrapperContract.FilterPolyWrapperLock(opt, nil, nil)
-	if err != nil {
-		return nil, fmt.Errorf("GetSmartContractEventByBlock, filter lock events :%s", err.Error())
-	}
-	for lockEvents.Next() {
-		evt := lockEvents.Event
-		wrapperTransactions = append(wrapperTransactions, &models.WrapperTransaction{
-			Hash:         evt.Raw.TxHash.String()[2:],
-			User:         strings.ToLower(evt.Sender.String()[2:]),
-			DstChainId:   evt.ToChainId,
-			DstUser:      hex.EncodeToString(evt.ToAddress),
-			FeeTokenHash: strings.ToLower(evt.FromAsset.String()[2:]),
-			FeeAmount:    models.NewBigInt(evt.Fee),
-			ServerId:     evt.Id.Uint64(),
-			BlockHeight:  evt.Raw.BlockNumber,
-		})
+func (this *EthereumChainListen) getBlockTime(height uint64, blockTimes map[uint64]uint64) (uint64, error) {
+	if tt, ok := blockTimes[height]; ok {
+		return tt, nil
 	}
-	speedupEvents, err := wrapperContract.FilterPolyWrapperSpeedUp(opt, nil, nil, nil)
+	blockHeader, err := this.ethSdk.GetHeaderByNumber(height)
 	if err != nil {
-		return nil, fmt.Errorf("GetSmartContractEventByBlock, filter lock events :%s", err.Error())
-	}
-	for speedupEvents.Next() {
-		evt := speedupEvents.Event
-		wrapperTransactions = append(wrapperTransactions, &models.WrapperTransaction{
-			Hash:         evt.TxHash.String(),
-			User:         evt.Sender.String(),
-			FeeTokenHash: evt.FromAsset.String(),
-			FeeAmount:    models.NewBigInt(evt.Efee),
-		})
+		return 0, err
 	}
-	if index == 1 {
-		for _, tx := range wrapperTransactions {
-			tx.FeeTokenHash = "0000000000000000000000000000000000000000"
-		}
+	if blockHeader == nil {
+		return 0, fmt.Errorf("there is no ethereum block!")
 	}
-	return wrapperTransactions, nil
+	blockTimes[height] = blockHeader.Time
+	return blockHeader.Time, nil
 }
 
-func (this *EthereumChainListen) getECCMEventByBlockNumber(contractAddr string, startHeight uint64, endHeight uint64) ([]*models.ECCMLockEvent, []*models.ECCMUnlockEvent, error) {
-	eccmContractAddress := common.HexToAddress(contractAddr)
-	eccmContract, err := eccm_abi.NewEthCrossChainManager(eccmContractAddress, this.ethSdk.GetClient())
-	if err != nil {
-		return nil, nil, fmt.Errorf("GetSmartContractEventByBlock, error: %s", err.Error())
-	}
-	opt := &bind.FilterOpts{
-		Start:   startHeight,
-		End:     &endHeight,
-		Context: context.Background(),
-	}
-	// get ethereum lock events from given block
-	eccmLockEvents := make([]*models.ECCMLockEvent, 0)
-	crossChainEvents, err := eccmContract.FilterCrossChainEvent(opt, nil)
-	if err != nil {
-		return nil, nil, fmt.Errorf("GetSmartContractEventByBlock, filter lock events :%s", err.Error())
+// getBlockTimes gets the times of the blocks of the events which are not in blockTimes in batch requests
+func (this *EthereumChainListen) getBlockTimes(events *blockEvents, blockTimes map[uint64]uint64) error {
+	heights := make([]uint64, 0)
+	added := make(map[uint64]bool)
+	add := func(height uint64) {
+		if _, ok := blockTimes[height]; !ok && !added[height] {
+			added[height] = true
+			heights = append(heights, height)
+		}
 	}
-	for crossChainEvents.Next() {
-		evt := crossChainEvents.Event
-		Fee := this.GetConsumeGas(evt.Raw.TxHash)
-		eccmLockEvents = append(eccmLockEvents, &models.ECCMLockEvent{
-			Method:   _eth_crosschainlock,
-			Txid:     hex.EncodeToString(evt.TxId),
-			TxHash:   evt.Raw.TxHash.String()[2:],
-			User:     strings.ToLower(evt.Sender.String()[2:]),
-			Tchain:   uint32(evt.ToChainId),
-			Contract: strings.ToLower(evt.ProxyOrAssetContract.String()[2:]),
-			Value:    evt.Rawdata,
-			Height:   evt.Raw.BlockNumber,
-			Fee:      Fee,
-		})
+	for _, item := range events.wrapperTransactions {
+		add(item.BlockHeight)
 	}
-	// ethereum unlock events from given block
-	eccmUnlockEvents := make([]*models.ECCMUnlockEvent, 0)
-	executeTxEvent, err := eccmContract.FilterVerifyHeaderAndExecuteTxEvent(opt)
-	if err != nil {
-		return nil, nil, fmt.Errorf("GetSmartContractEventByBlock, filter unlock events :%s", err.Error())
-	}
-
-	for executeTxEvent.Next() {
-		evt := executeTxEvent.Event
-		Fee := this.GetConsumeGas(evt.Raw.TxHash)
-		eccmUnlockEvents = append(eccmUnlockEvents, &models.ECCMUnlockEvent{
-			Method:   _eth_crosschainunlock,
-			TxHash:   evt.Raw.TxHash.String()[2:],
-			RTxHash:  basedef.HexStringReverse(hex.EncodeToString(evt.CrossChainTxHash)),
-			Contract: hex.EncodeToString(evt.ToContract),
-			FChainId: uint32(evt.FromChainID),
-			Height:   evt.Raw.BlockNumber,
-			Fee:      Fee,
-		})
+	for _, item := range events.eccmLockEvents {
+		add(item.Height)
 	}
-	return eccmLockEvents, eccmUnlockEvents, nil
-}
-
-func (this *EthereumChainListen) getProxyEventByBlockNumber(contractAddr string, startHeight uint64, endHeight uint64) ([]*models.ProxyLockEvent, []*models.ProxyUnlockEvent, error) {
-	proxyAddress := common.HexToAddress(contractAddr)
-	proxyContract, err := lock_proxy_abi.NewLockProxy(proxyAddress, this.ethSdk.GetClient())
-	if err != nil {
-		return nil, nil, fmt.Errorf("GetSmartContractEventByBlock, error: %s", err.Error())
+	for _, item := range events.eccmUnlockEvents {
+		add(item.Height)
 	}
-	opt := &bind.FilterOpts{
-		Start:   startHeight,
-		End:     &endHeight,
-		Context: context.Background(),
+	if len(heights) == 0 {
+		return nil
 	}
-	// get ethereum lock events from given block
-	proxyLockEvents := make([]*models.ProxyLockEvent, 0)
-	lockEvents, err := proxyContract.FilterLockEvent(opt)
+	headers, err := this.ethSdk.GetHeadersByNumber(heights)
 	if err != nil {
-		return nil, nil, fmt.Errorf("GetSmartContractEventByBlock, filter lock events :%s", err.Error())
+		return err
 	}
-	for lockEvents.Next() {
-		evt := lockEvents.Event
-		proxyLockEvents = append(proxyLockEvents, &models.ProxyLockEvent{
-			Method:        _eth_lock,
-			TxHash:        evt.Raw.TxHash.String()[2:],
-			FromAddress:   evt.FromAddress.String()[2:],
-			FromAssetHash: strings.ToLower(evt.FromAssetHash.String()[2:]),
-			ToChainId:     uint32(evt.ToChainId),
-			ToAssetHash:   hex.EncodeToString(evt.ToAssetHash),
-			ToAddress:     hex.EncodeToString(evt.ToAddress),
-			Amount:        evt.Amount,
-		})
+	for i, header := range headers {
+		blockTimes[heights[i]] = header.Time
 	}
+	return nil
+}
 
-	// ethereum unlock events from given block
-	proxyUnlockEvents := make([]*models.ProxyUnlockEvent, 0)
-	unlockEvents, err := proxyContract.FilterUnlockEvent(opt)
+// GetConsumeGas returns the gas used and the effective gas price paid by the transaction
+func (this *EthereumChainListen) GetConsumeGas(hash common.Hash) (uint64, *big.Int) {
+	gasUsed, gasPrice, err := this.ethSdk.GetTransactionGas(hash)
 	if err != nil {
-		return nil, nil, fmt.Errorf("GetSmartContractEventByBlock, filter unlock events :%s", err.Error())
-	}
-	for unlockEvents.Next() {
-		evt := unlockEvents.Event
-		proxyUnlockEvents = append(proxyUnlockEvents, &models.ProxyUnlockEvent{
-			Method:      _eth_unlock,
-			TxHash:      evt.Raw.TxHash.String()[2:],
-			ToAssetHash: strings.ToLower(evt.ToAssetHash.String()[2:]),
-			ToAddress:   strings.ToLower(evt.ToAddress.String()[2:]),
-			Amount:      evt.Amount,
-		})
+		logs.Warn("get gas of chain: %s, txhash: %s, err: %v", this.GetChainName(), hash.String(), err)
+		return 0, big.NewInt(0)
 	}
-	return proxyLockEvents, proxyUnlockEvents, nil
+	return gasUsed, gasPrice
 }
-func (this *EthereumChainListen) GetConsumeGas(hash common.Hash) uint64 {
-	tx, err := this.ethSdk.GetTransactionByHash(hash)
-	if err != nil {
-		return 0
-	}
-	receipt, err := this.ethSdk.GetTransactionReceipt(hash)
-	if err != nil {
-		return 0
-	}
-	return tx.GasPrice().Uint64() * receipt.GasUsed
+
+func consumeFee(gasUsed uint64, gasPrice *big.Int) *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(gasUsed), gasPrice)
 }
 
 type ExtendHeightRsp struct {
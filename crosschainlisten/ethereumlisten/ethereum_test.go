@@ -0,0 +1,318 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ethereumlisten
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"poly-bridge/basedef"
+	"poly-bridge/chainsdk"
+	"poly-bridge/conf"
+	"poly-bridge/crosschaindao/memorydao"
+	"poly-bridge/models"
+	"poly-bridge/utils/rpcreplay"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// the synthetic fixture is a lock through the wrapper, an unlock, and a cross chain call and its execution
+// by another dapp on ethereum at height 12000000, it is served by utils/rpcreplay/fakenode and is not chain
+// data, do not edit it but record it again from the fake node
+func newFixtureChainListen(t *testing.T, fixture string) (*EthereumChainListen, *rpcreplay.Transport) {
+	transport, err := rpcreplay.NewTransport(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainsdk.SetRpcTransport(transport)
+	listen := NewEthereumChainListen(&conf.ChainListenConfig{
+		ChainName:       "Ethereum",
+		ChainId:         basedef.ETHEREUM_CROSSCHAIN_ID,
+		ListenSlot:      10,
+		Nodes:           []*conf.Restful{{Url: rpcreplay.NodeUrl("http://127.0.0.1:8545")}},
+		WrapperContract: []string{"2aA63cd0b28FB4C31fA8e4E95Ec11815Be07b9Ac", "d380450e9e373bDC389951C54616edb2EE653524"},
+		CCMContract:     "838bf9e95cb12dd76a54c9f9d2e3082eaf928270",
+		ProxyContract:   "250e76987d838a75310c34bf422ea9f1AC4Cc906",
+	})
+	return listen, transport
+}
+
+func TestEthereumChainListen_HandleSyntheticBlock(t *testing.T) {
+	listen, transport := newFixtureChainListen(t, "testdata/synthetic_ethereum_12000000.json")
+	defer chainsdk.SetRpcTransport(nil)
+	defer transport.Close()
+
+	wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, err := listen.HandleNewBlock(12000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, len(polyTransactions))
+
+	assert.Equal(t, 1, len(wrapperTransactions))
+	wrapper := wrapperTransactions[0]
+	assert.Equal(t, "412229ecb23679604be481ae483987293b13a25dcb35b2979ab05bc31375265c", wrapper.Hash)
+	assert.Equal(t, "2c7536e3605d9c16a7a3d7b1898e529396a65c23", wrapper.User)
+	assert.Equal(t, basedef.ETHEREUM_CROSSCHAIN_ID, wrapper.SrcChainId)
+	assert.Equal(t, basedef.BSC_CROSSCHAIN_ID, wrapper.DstChainId)
+	assert.Equal(t, "2c7536e3605d9c16a7a3d7b1898e529396a65c23", wrapper.DstUser)
+	assert.Equal(t, "0000000000000000000000000000000000000000", wrapper.FeeTokenHash)
+	assert.Equal(t, "2000000000000000", wrapper.FeeAmount.String())
+	assert.Equal(t, uint64(12000000), wrapper.BlockHeight)
+	assert.Equal(t, uint64(1615970497), wrapper.Time)
+	assert.Equal(t, uint64(basedef.STATE_SOURCE_DONE), wrapper.Status)
+
+	assert.Equal(t, 2, len(srcTransactions))
+	src := srcTransactions[0]
+	assert.Equal(t, models.MessageTypeTransfer, src.MessageType)
+	assert.Equal(t, "412229ecb23679604be481ae483987293b13a25dcb35b2979ab05bc31375265c", src.Hash)
+	assert.Equal(t, uint64(12000000), src.Height)
+	assert.Equal(t, basedef.BSC_CROSSCHAIN_ID, src.DstChainId)
+	assert.Equal(t, "250e76987d838a75310c34bf422ea9f1ac4cc906", src.Contract)
+	assert.Equal(t, "0000000000000000000000000000000000000000000000000000000000021c3f", src.Key)
+	// gas price 100 gwei and 186513 gas used
+	assert.Equal(t, "18651300000000000", src.Fee.String())
+	assert.Equal(t, uint64(186513), src.GasUsed)
+	assert.Equal(t, "100000000000", src.GasPrice.String())
+	assert.NotNil(t, src.SrcTransfer)
+	assert.Equal(t, "0000000000000000000000000000000000000000", src.SrcTransfer.Asset)
+	assert.Equal(t, "998000000000000000", src.SrcTransfer.Amount.String())
+	assert.Equal(t, "2170ed0880ac9a755fd29b2688956bd959f933f8", src.SrcTransfer.DstAsset)
+	assert.Equal(t, "2c7536e3605d9c16a7a3d7b1898e529396a65c23", src.SrcTransfer.DstUser)
+	assert.Equal(t, "250e76987d838a75310c34bf422ea9f1ac4cc906", src.SrcTransfer.Proxy)
+	assert.NotNil(t, src.MakeTxParam)
+	assert.Equal(t, src.Key, src.MakeTxParam.TxId)
+	assert.Equal(t, src.Contract, src.MakeTxParam.FromContract)
+	assert.Equal(t, basedef.BSC_CROSSCHAIN_ID, src.MakeTxParam.ToChainId)
+	assert.Equal(t, "2f7ac9436ba4b548f9582af91ca1ef02cd2f1f03", src.MakeTxParam.ToContract)
+	assert.Equal(t, models.MAKE_TX_METHOD_UNLOCK, src.MakeTxParam.Method)
+	assert.Equal(t, src.SrcTransfer.DstAsset, src.MakeTxParam.ToAsset)
+	assert.Equal(t, src.SrcTransfer.DstUser, src.MakeTxParam.ToAddress)
+	assert.Equal(t, "998000000000000000", src.MakeTxParam.Amount.String())
+
+	call := srcTransactions[1]
+	assert.Equal(t, "adba963dc8a0e9af688673f65a3eeb192139a6bd4f2a7e49004bf61046a91a24", call.Hash)
+	assert.Equal(t, models.MessageTypeCall, call.MessageType)
+	assert.Nil(t, call.SrcTransfer)
+	assert.Equal(t, "7d79d1d6e5b3c4a2918f0e7d6c5b4a3928170f6e", call.Contract)
+	assert.Equal(t, "2c7536e3605d9c16a7a3d7b1898e529396a65c23", call.User)
+	assert.Equal(t, basedef.BSC_CROSSCHAIN_ID, call.DstChainId)
+	assert.NotNil(t, call.MakeTxParam)
+	assert.Equal(t, "3b8e1a6c5d4f2e9b0a7c6d5e4f3a2b1c0d9e8f7a", call.MakeTxParam.ToContract)
+	assert.Equal(t, "receiveMessage", call.MakeTxParam.Method)
+	assert.Equal(t, "68656c6c6f20706f6c79", call.MakeTxParam.Args)
+	assert.Nil(t, call.MakeTxParam.Amount)
+
+	assert.Equal(t, 2, len(dstTransactions))
+	dst := dstTransactions[0]
+	assert.Equal(t, models.MessageTypeTransfer, dst.MessageType)
+	assert.Equal(t, "0948492380bf3498535db2a0c5b39c8a58bf30100e65ca2dc4b15db4d3d6d2c0", dst.Hash)
+	assert.Equal(t, basedef.BSC_CROSSCHAIN_ID, dst.SrcChainId)
+	assert.Equal(t, "2b3a4f5e6d7c8b9a0f1e2d3c4b5a6f7e8d9c0b1a2f3e4d5c6b8a9d2e7c0f1b3a", dst.PolyHash)
+	// gas price 80 gwei and 231074 gas used
+	assert.Equal(t, "14095514000000000", dst.Fee.String())
+	assert.Equal(t, uint64(231074), dst.GasUsed)
+	assert.Equal(t, "61000000000", dst.GasPrice.String())
+	assert.NotNil(t, dst.DstTransfer)
+	assert.Equal(t, "dac17f958d2ee523a2206206994597c13d831ec7", dst.DstTransfer.Asset)
+	assert.Equal(t, "5a51e2ebf8d136926b9ca7b59b60464e7c44d2eb", dst.DstTransfer.To)
+	assert.Equal(t, "2500000000", dst.DstTransfer.Amount.String())
+	assert.Equal(t, "250e76987d838a75310c34bf422ea9f1ac4cc906", dst.DstTransfer.Proxy)
+
+	execute := dstTransactions[1]
+	assert.Equal(t, "acb2f23022e23e9c15aa58fff35cb12e83d23870e3de36b364b3f31c6db52ee5", execute.Hash)
+	assert.Equal(t, models.MessageTypeCall, execute.MessageType)
+	assert.Nil(t, execute.DstTransfer)
+	assert.Equal(t, "7d79d1d6e5b3c4a2918f0e7d6c5b4a3928170f6e", execute.Contract)
+
+	dao := memorydao.NewMemoryDao()
+	assert.Nil(t, dao.UpdateEvents(nil, wrapperTransactions, srcTransactions, polyTransactions, dstTransactions))
+	assert.Equal(t, 1, len(dao.WrapperTransactions()))
+	assert.Equal(t, 2, len(dao.SrcTransactions()))
+	assert.Equal(t, 2, len(dao.DstTransactions()))
+}
+
+func TestEthereumChainListen_HandleSyntheticFailedUnlocks(t *testing.T) {
+	listen, transport := newFixtureChainListen(t, "testdata/synthetic_ethereum_12000000_failed.json")
+	defer chainsdk.SetRpcTransport(nil)
+	defer transport.Close()
+
+	// the failed unlock is in the first block of a catch up range
+	failedDstTransactions, err := listen.HandleFailedUnlocks(12000000, 12000031)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(failedDstTransactions))
+	failed := failedDstTransactions[0]
+	assert.Equal(t, "287c24a8a7c77d201bbcec2371b349ae85a1e2fdae5a191f56fe9fd3c12fecac", failed.Hash)
+	assert.Equal(t, basedef.ETHEREUM_CROSSCHAIN_ID, failed.ChainId)
+	assert.Equal(t, uint64(12000000), failed.Height)
+	assert.Equal(t, uint64(1615970497), failed.Time)
+	assert.Equal(t, basedef.BSC_CROSSCHAIN_ID, failed.SrcChainId)
+	assert.Equal(t, "8293a4b5c6d7e8f9a0b1c2d3e4f5061728394a5b6c7d8e9f0a1b2c3d4e5f6a7b", failed.PolyHash)
+	assert.Equal(t, "2c7536e3605d9c16a7a3d7b1898e529396a65c23", failed.Submitter)
+	assert.Equal(t, "EthCrossChain: the transaction has been executed!", failed.Reason)
+	// gas price 70 gwei and 98213 gas used
+	assert.Equal(t, uint64(98213), failed.GasUsed)
+	assert.Equal(t, "70000000000", failed.GasPrice.String())
+	assert.Equal(t, "6874910000000000", failed.Fee.String())
+}
+
+func TestEthereumChainListen_HandleSyntheticQuorumBlocks(t *testing.T) {
+	transport, err := rpcreplay.NewTransport("testdata/synthetic_ethereum_12000000.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainsdk.SetRpcTransport(transport)
+	defer chainsdk.SetRpcTransport(nil)
+	defer transport.Close()
+	listen := NewEthereumChainListen(&conf.ChainListenConfig{
+		ChainName:       "Ethereum",
+		ChainId:         basedef.ETHEREUM_CROSSCHAIN_ID,
+		ListenSlot:      10,
+		Nodes:           []*conf.Restful{{Url: rpcreplay.NodeUrl("http://127.0.0.1:8545")}},
+		QuorumNodes:     []*conf.Restful{{Url: rpcreplay.NodeUrl("http://127.0.0.1:8546")}},
+		WrapperContract: []string{"2aA63cd0b28FB4C31fA8e4E95Ec11815Be07b9Ac", "d380450e9e373bDC389951C54616edb2EE653524"},
+		CCMContract:     "838bf9e95cb12dd76a54c9f9d2e3082eaf928270",
+		ProxyContract:   "250e76987d838a75310c34bf422ea9f1AC4Cc906",
+	})
+	assert.True(t, listen.QuorumEnabled())
+
+	hash, _, err := listen.GetBlockHash(12000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quorumHash, _, srcTransactions, _, dstTransactions, err := listen.HandleQuorumBlocks(12000000, 12000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, hash, quorumHash)
+	assert.Equal(t, 2, len(srcTransactions))
+	assert.Equal(t, 2, len(dstTransactions))
+	assert.Equal(t, "0948492380bf3498535db2a0c5b39c8a58bf30100e65ca2dc4b15db4d3d6d2c0", dstTransactions[0].Hash)
+}
+
+// the recorded fixtures are blocks of the real ethereum and bsc chains with a lock and an unlock of the
+// mainnet contracts, record one with
+// RPC_RECORD=1 RPC_NODE=<node> RPC_CHAIN=<chain id> RPC_HEIGHT=<height> go test -tags mainnet -run TestEthereumChainListen_HandleRecordedBlocks .
+func TestEthereumChainListen_HandleRecordedBlocks(t *testing.T) {
+	config := conf.NewConfig("../../conf/config_mainnet.json")
+	if config == nil {
+		t.Fatal("read the mainnet config failed")
+	}
+	if os.Getenv(rpcreplay.ENV_RECORD) != "" {
+		var chainId, height uint64
+		if _, err := fmt.Sscanf(os.Getenv("RPC_CHAIN")+" "+os.Getenv("RPC_HEIGHT"), "%d %d", &chainId, &height); err != nil {
+			t.Fatalf("RPC_CHAIN and RPC_HEIGHT are required to record a block: %v", err)
+		}
+		checkRecordedBlock(t, config, chainId, height, fmt.Sprintf("testdata/recorded_%d_%d.json", chainId, height))
+		return
+	}
+	fixtures, err := filepath.Glob("testdata/recorded_*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Skip("no block of a real node is recorded, see doc/offline_test.md")
+	}
+	for _, fixture := range fixtures {
+		var chainId, height uint64
+		if _, err := fmt.Sscanf(filepath.Base(fixture), "recorded_%d_%d.json", &chainId, &height); err != nil {
+			t.Fatalf("fixture %s: %v", fixture, err)
+		}
+		t.Run(filepath.Base(fixture), func(t *testing.T) {
+			checkRecordedBlock(t, config, chainId, height, fixture)
+		})
+	}
+}
+
+func checkRecordedBlock(t *testing.T, config *conf.Config, chainId uint64, height uint64, fixture string) {
+	chainListenConfig := config.GetChainListenConfig(chainId)
+	if chainListenConfig == nil {
+		t.Fatalf("chain %d is not in the mainnet config", chainId)
+	}
+	transport, err := rpcreplay.NewTransport(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainsdk.SetRpcTransport(transport)
+	defer chainsdk.SetRpcTransport(nil)
+	defer transport.Close()
+	listenConfig := *chainListenConfig
+	listenConfig.Nodes = []*conf.Restful{{Url: rpcreplay.NodeUrl("http://127.0.0.1:8545")}}
+	listenConfig.ExtendNodes = nil
+	listenConfig.WsNodes = nil
+	listenConfig.QuorumNodes = nil
+	listen := NewEthereumChainListen(&listenConfig)
+
+	wrapperTransactions, srcTransactions, _, dstTransactions, err := listen.HandleNewBlock(height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, len(srcTransactions)+len(dstTransactions) > 0, "no lock or unlock at height %d", height)
+	srcHashes := make(map[string]bool)
+	for _, src := range srcTransactions {
+		srcHashes[src.Hash] = true
+		assert.Equal(t, chainId, src.ChainId)
+		assert.Equal(t, height, src.Height)
+		assert.NotEqual(t, uint64(0), src.DstChainId)
+		assert.NotNil(t, src.MakeTxParam)
+		assert.True(t, src.GasUsed > 0)
+		assert.Equal(t, consumeFee(src.GasUsed, &src.GasPrice.Int).String(), src.Fee.String())
+	}
+	for _, wrapper := range wrapperTransactions {
+		assert.Equal(t, chainId, wrapper.SrcChainId)
+		assert.Equal(t, height, wrapper.BlockHeight)
+		assert.True(t, srcHashes[wrapper.Hash], "wrapper %s has no source transaction", wrapper.Hash)
+	}
+	for _, dst := range dstTransactions {
+		assert.Equal(t, chainId, dst.ChainId)
+		assert.Equal(t, height, dst.Height)
+		assert.NotEqual(t, uint64(0), dst.SrcChainId)
+		assert.Equal(t, 64, len(dst.PolyHash))
+		assert.True(t, dst.GasUsed > 0)
+		assert.Equal(t, consumeFee(dst.GasUsed, &dst.GasPrice.Int).String(), dst.Fee.String())
+	}
+}
+
+func TestNewEventQuery(t *testing.T) {
+	contracts := newEventContracts(&conf.ChainListenConfig{
+		WrapperContract:    []string{"2aA63cd0b28FB4C31fA8e4E95Ec11815Be07b9Ac", "d380450e9e373bDC389951C54616edb2EE653524"},
+		NFTWrapperContract: "0000000000000000000000000000000000000000",
+		CCMContract:        "838bf9e95cb12dd76a54c9f9d2e3082eaf928270",
+		ProxyContract:      "250e76987d838a75310c34bf422ea9f1AC4Cc906",
+	})
+	assert.Equal(t, 4, len(contracts))
+	wrapper := contracts[common.HexToAddress("d380450e9e373bDC389951C54616edb2EE653524")]
+	assert.Equal(t, 1, len(wrapper))
+	assert.Equal(t, _contract_wrapper, wrapper[0].kind)
+	assert.Equal(t, 1, wrapper[0].index)
+
+	query := newEventQuery(contracts)
+	assert.Equal(t, []common.Address{
+		common.HexToAddress("250e76987d838a75310c34bf422ea9f1AC4Cc906"),
+		common.HexToAddress("2aA63cd0b28FB4C31fA8e4E95Ec11815Be07b9Ac"),
+		common.HexToAddress("838bf9e95cb12dd76a54c9f9d2e3082eaf928270"),
+		common.HexToAddress("d380450e9e373bDC389951C54616edb2EE653524"),
+	}, query.Addresses)
+	// lock and speed up of the wrapper, the cross chain and execution events of the ccm, lock and unlock of the proxy
+	assert.Equal(t, 1, len(query.Topics))
+	assert.Equal(t, 6, len(query.Topics[0]))
+	assert.Equal(t, query, newEventQuery(contracts))
+}
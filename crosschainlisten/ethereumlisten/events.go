@@ -0,0 +1,391 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package ethereumlisten
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"poly-bridge/chainsdk"
+	"poly-bridge/conf"
+	"poly-bridge/go_abi/eccm_abi"
+	"poly-bridge/go_abi/lock_proxy_abi"
+	nftlp "poly-bridge/go_abi/nft_lock_proxy_abi"
+	nftwp "poly-bridge/go_abi/nft_wrap_abi"
+	"poly-bridge/go_abi/wrapper_abi"
+	"poly-bridge/models"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// the kinds of the contracts whose events are listened
+const (
+	_contract_wrapper = iota
+	_contract_nft_wrapper
+	_contract_eccm
+	_contract_proxy
+	_contract_nft_proxy
+)
+
+// eventContract is a listened contract, index is the position of the contract in its config list
+type eventContract struct {
+	kind  int
+	index int
+}
+
+// blockEvents are the events decoded from the logs of the blocks
+type blockEvents struct {
+	wrapperTransactions []*models.WrapperTransaction
+	eccmLockEvents      []*models.ECCMLockEvent
+	eccmUnlockEvents    []*models.ECCMUnlockEvent
+	proxyLockEvents     []*models.ProxyLockEvent
+	proxyUnlockEvents   []*models.ProxyUnlockEvent
+	gases               map[common.Hash]*chainsdk.TransactionGas
+}
+
+// eventDecoder decodes a log of the contract into the events
+type eventDecoder func(this *EthereumChainListen, contract *eventContract, log types.Log, events *blockEvents) error
+
+// eventDecoders are the decoders of each kind of contract by the event id, which is the first topic of the log
+var eventDecoders = make(map[int]map[common.Hash]eventDecoder)
+
+var (
+	wrapperFilterer    *wrapper_abi.IPolyWrapperFilterer
+	nftWrapperFilterer *nftwp.PolyNFTWrapperFilterer
+	eccmFilterer       *eccm_abi.EthCrossChainManagerFilterer
+	proxyFilterer      *lock_proxy_abi.LockProxyFilterer
+	nftProxyFilterer   *nftlp.PolyNFTLockProxyFilterer
+)
+
+func init() {
+	var err error
+	if wrapperFilterer, err = wrapper_abi.NewIPolyWrapperFilterer(common.Address{}, nil); err != nil {
+		panic(err)
+	}
+	if nftWrapperFilterer, err = nftwp.NewPolyNFTWrapperFilterer(common.Address{}, nil); err != nil {
+		panic(err)
+	}
+	if eccmFilterer, err = eccm_abi.NewEthCrossChainManagerFilterer(common.Address{}, nil); err != nil {
+		panic(err)
+	}
+	if proxyFilterer, err = lock_proxy_abi.NewLockProxyFilterer(common.Address{}, nil); err != nil {
+		panic(err)
+	}
+	if nftProxyFilterer, err = nftlp.NewPolyNFTLockProxyFilterer(common.Address{}, nil); err != nil {
+		panic(err)
+	}
+	registerEventDecoder(_contract_wrapper, wrapper_abi.IPolyWrapperABI, "PolyWrapperLock", decodeWrapperLock)
+	registerEventDecoder(_contract_wrapper, wrapper_abi.IPolyWrapperABI, "PolyWrapperSpeedUp", decodeWrapperSpeedUp)
+	registerEventDecoder(_contract_nft_wrapper, nftwp.PolyNFTWrapperABI, "PolyWrapperLock", decodeNFTWrapperLock)
+	registerEventDecoder(_contract_nft_wrapper, nftwp.PolyNFTWrapperABI, "PolyWrapperSpeedUp", decodeNFTWrapperSpeedUp)
+	registerEventDecoder(_contract_eccm, eccm_abi.EthCrossChainManagerABI, "CrossChainEvent", decodeCrossChainEvent)
+	registerEventDecoder(_contract_eccm, eccm_abi.EthCrossChainManagerABI, "VerifyHeaderAndExecuteTxEvent", decodeVerifyHeaderAndExecuteTxEvent)
+	registerEventDecoder(_contract_proxy, lock_proxy_abi.LockProxyABI, _eth_lock, decodeProxyLock)
+	registerEventDecoder(_contract_proxy, lock_proxy_abi.LockProxyABI, _eth_unlock, decodeProxyUnlock)
+	registerEventDecoder(_contract_nft_proxy, nftlp.PolyNFTLockProxyABI, _eth_lock, decodeNFTProxyLock)
+	registerEventDecoder(_contract_nft_proxy, nftlp.PolyNFTLockProxyABI, _eth_unlock, decodeNFTProxyUnlock)
+}
+
+// registerEventDecoder registers the decoder of the event in the abi for the kind of contracts, the logs of
+// the event are then fetched together with the others and dispatched to the decoder.
+func registerEventDecoder(kind int, contractAbi string, name string, decoder eventDecoder) {
+	parsed, err := abi.JSON(strings.NewReader(contractAbi))
+	if err != nil {
+		panic(err)
+	}
+	event, ok := parsed.Events[name]
+	if !ok {
+		panic(fmt.Sprintf("event %s is not in the abi", name))
+	}
+	decoders, ok := eventDecoders[kind]
+	if !ok {
+		decoders = make(map[common.Hash]eventDecoder)
+		eventDecoders[kind] = decoders
+	}
+	decoders[event.ID] = decoder
+}
+
+// newEventContracts gets the listened contracts of the chain by their addresses
+func newEventContracts(cfg *conf.ChainListenConfig) map[common.Address][]*eventContract {
+	contracts := make(map[common.Address][]*eventContract)
+	add := func(address string, kind int, index int) {
+		if !isContract(address) {
+			return
+		}
+		contract := common.HexToAddress(address)
+		contracts[contract] = append(contracts[contract], &eventContract{kind: kind, index: index})
+	}
+	for i, wrapper := range cfg.WrapperContract {
+		add(wrapper, _contract_wrapper, i)
+	}
+	add(cfg.NFTWrapperContract, _contract_nft_wrapper, 0)
+	add(cfg.CCMContract, _contract_eccm, 0)
+	for i, proxy := range cfg.GetProxyContracts() {
+		add(proxy.Address, _contract_proxy, i)
+	}
+	for i, proxy := range cfg.GetNFTProxyContracts() {
+		add(proxy.Address, _contract_nft_proxy, i)
+	}
+	return contracts
+}
+
+// newEventQuery is the query of all the events of the contracts, the addresses and the topics are sorted
+// so that the query is the same every time.
+func newEventQuery(contracts map[common.Address][]*eventContract) ethereum.FilterQuery {
+	addresses := make([]common.Address, 0, len(contracts))
+	topics := make([]common.Hash, 0)
+	added := make(map[common.Hash]bool)
+	for address, items := range contracts {
+		addresses = append(addresses, address)
+		for _, contract := range items {
+			for topic := range eventDecoders[contract.kind] {
+				if !added[topic] {
+					added[topic] = true
+					topics = append(topics, topic)
+				}
+			}
+		}
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return strings.Compare(addresses[i].Hex(), addresses[j].Hex()) < 0
+	})
+	sort.Slice(topics, func(i, j int) bool {
+		return strings.Compare(topics[i].Hex(), topics[j].Hex()) < 0
+	})
+	return ethereum.FilterQuery{Addresses: addresses, Topics: [][]common.Hash{topics}}
+}
+
+// getEventsByBlockNumber gets the logs of all the listened contracts in one call and decodes them
+func (this *EthereumChainListen) getEventsByBlockNumber(startHeight uint64, endHeight uint64) (*blockEvents, error) {
+	events := &blockEvents{
+		wrapperTransactions: make([]*models.WrapperTransaction, 0),
+		eccmLockEvents:      make([]*models.ECCMLockEvent, 0),
+		eccmUnlockEvents:    make([]*models.ECCMUnlockEvent, 0),
+		proxyLockEvents:     make([]*models.ProxyLockEvent, 0),
+		proxyUnlockEvents:   make([]*models.ProxyUnlockEvent, 0),
+	}
+	if len(this.contracts) == 0 {
+		return events, nil
+	}
+	query := this.eventQuery
+	query.FromBlock = new(big.Int).SetUint64(startHeight)
+	query.ToBlock = new(big.Int).SetUint64(endHeight)
+	eventLogs, err := this.ethSdk.FilterLogs(query)
+	if err != nil {
+		return nil, fmt.Errorf("GetSmartContractEventByBlock, filter logs :%s", err.Error())
+	}
+	events.gases = this.getConsumeGases(eventLogs)
+	for _, log := range eventLogs {
+		if log.Removed || len(log.Topics) == 0 {
+			continue
+		}
+		for _, contract := range this.contracts[log.Address] {
+			decoder, ok := eventDecoders[contract.kind][log.Topics[0]]
+			if !ok {
+				continue
+			}
+			if err := decoder(this, contract, log, events); err != nil {
+				return nil, fmt.Errorf("GetSmartContractEventByBlock, decode log %d of tx %s :%s", log.Index, log.TxHash.String(), err.Error())
+			}
+		}
+	}
+	return events, nil
+}
+
+// getConsumeGases gets the gas of the transactions of the eccm events in batch requests, the gas of a
+// transaction missing here is got by GetConsumeGas when its event is decoded
+func (this *EthereumChainListen) getConsumeGases(eventLogs []types.Log) map[common.Hash]*chainsdk.TransactionGas {
+	gases := make(map[common.Hash]*chainsdk.TransactionGas)
+	hashes := make([]common.Hash, 0)
+	added := make(map[common.Hash]bool)
+	for _, log := range eventLogs {
+		if log.Removed || added[log.TxHash] {
+			continue
+		}
+		for _, contract := range this.contracts[log.Address] {
+			if contract.kind == _contract_eccm {
+				added[log.TxHash] = true
+				hashes = append(hashes, log.TxHash)
+				break
+			}
+		}
+	}
+	if len(hashes) == 0 {
+		return gases
+	}
+	result, err := this.ethSdk.GetTransactionsGas(hashes)
+	if err != nil {
+		logs.Warn("get gas of chain: %s, txs: %d, err: %v", this.GetChainName(), len(hashes), err)
+		return gases
+	}
+	for i, gas := range result {
+		gases[hashes[i]] = gas
+	}
+	return gases
+}
+
+func (this *EthereumChainListen) consumeGas(events *blockEvents, hash common.Hash) (uint64, *big.Int) {
+	if gas, ok := events.gases[hash]; ok {
+		return gas.GasUsed, gas.GasPrice
+	}
+	return this.GetConsumeGas(hash)
+}
+
+func decodeWrapperLock(this *EthereumChainListen, contract *eventContract, log types.Log, events *blockEvents) error {
+	evt, err := wrapperFilterer.ParsePolyWrapperLock(log)
+	if err != nil {
+		return err
+	}
+	wrapperTransaction := &models.WrapperTransaction{
+		Hash:         log.TxHash.String()[2:],
+		User:         strings.ToLower(evt.Sender.String()[2:]),
+		DstChainId:   evt.ToChainId,
+		DstUser:      hex.EncodeToString(evt.ToAddress),
+		FeeTokenHash: strings.ToLower(evt.FromAsset.String()[2:]),
+		FeeAmount:    models.NewBigInt(evt.Fee),
+		ServerId:     evt.Id.Uint64(),
+		BlockHeight:  log.BlockNumber,
+	}
+	if contract.index == 1 {
+		wrapperTransaction.FeeTokenHash = "0000000000000000000000000000000000000000"
+	}
+	events.wrapperTransactions = append(events.wrapperTransactions, wrapperTransaction)
+	return nil
+}
+
+func decodeWrapperSpeedUp(this *EthereumChainListen, contract *eventContract, log types.Log, events *blockEvents) error {
+	evt, err := wrapperFilterer.ParsePolyWrapperSpeedUp(log)
+	if err != nil {
+		return err
+	}
+	wrapperTransaction := &models.WrapperTransaction{
+		Hash:         evt.TxHash.String(),
+		User:         evt.Sender.String(),
+		FeeTokenHash: evt.FromAsset.String(),
+		FeeAmount:    models.NewBigInt(evt.Efee),
+		BlockHeight:  log.BlockNumber,
+	}
+	if contract.index == 1 {
+		wrapperTransaction.FeeTokenHash = "0000000000000000000000000000000000000000"
+	}
+	events.wrapperTransactions = append(events.wrapperTransactions, wrapperTransaction)
+	return nil
+}
+
+func decodeNFTWrapperLock(this *EthereumChainListen, contract *eventContract, log types.Log, events *blockEvents) error {
+	evt, err := nftWrapperFilterer.ParsePolyWrapperLock(log)
+	if err != nil {
+		return err
+	}
+	evt.Raw = log
+	events.wrapperTransactions = append(events.wrapperTransactions, wrapLockEvent2WrapTx(evt))
+	return nil
+}
+
+func decodeNFTWrapperSpeedUp(this *EthereumChainListen, contract *eventContract, log types.Log, events *blockEvents) error {
+	evt, err := nftWrapperFilterer.ParsePolyWrapperSpeedUp(log)
+	if err != nil {
+		return err
+	}
+	evt.Raw = log
+	events.wrapperTransactions = append(events.wrapperTransactions, wrapSpeedUpEvent2WrapTx(evt))
+	return nil
+}
+
+func decodeCrossChainEvent(this *EthereumChainListen, contract *eventContract, log types.Log, events *blockEvents) error {
+	evt, err := eccmFilterer.ParseCrossChainEvent(log)
+	if err != nil {
+		return err
+	}
+	evt.Raw = log
+	gasUsed, gasPrice := this.consumeGas(events, log.TxHash)
+	events.eccmLockEvents = append(events.eccmLockEvents, crossChainEvent2ProxyLockEvent(evt, gasUsed, gasPrice))
+	return nil
+}
+
+func decodeVerifyHeaderAndExecuteTxEvent(this *EthereumChainListen, contract *eventContract, log types.Log, events *blockEvents) error {
+	evt, err := eccmFilterer.ParseVerifyHeaderAndExecuteTxEvent(log)
+	if err != nil {
+		return err
+	}
+	evt.Raw = log
+	gasUsed, gasPrice := this.consumeGas(events, log.TxHash)
+	events.eccmUnlockEvents = append(events.eccmUnlockEvents, verifyAndExecuteEvent2ProxyUnlockEvent(evt, gasUsed, gasPrice))
+	return nil
+}
+
+func decodeProxyLock(this *EthereumChainListen, contract *eventContract, log types.Log, events *blockEvents) error {
+	evt, err := proxyFilterer.ParseLockEvent(log)
+	if err != nil {
+		return err
+	}
+	events.proxyLockEvents = append(events.proxyLockEvents, &models.ProxyLockEvent{
+		Method:        _eth_lock,
+		TxHash:        log.TxHash.String()[2:],
+		FromAddress:   evt.FromAddress.String()[2:],
+		FromAssetHash: strings.ToLower(evt.FromAssetHash.String()[2:]),
+		ToChainId:     uint32(evt.ToChainId),
+		ToAssetHash:   hex.EncodeToString(evt.ToAssetHash),
+		ToAddress:     hex.EncodeToString(evt.ToAddress),
+		Amount:        evt.Amount,
+		Proxy:         strings.ToLower(log.Address.String()[2:]),
+	})
+	return nil
+}
+
+func decodeProxyUnlock(this *EthereumChainListen, contract *eventContract, log types.Log, events *blockEvents) error {
+	evt, err := proxyFilterer.ParseUnlockEvent(log)
+	if err != nil {
+		return err
+	}
+	events.proxyUnlockEvents = append(events.proxyUnlockEvents, &models.ProxyUnlockEvent{
+		Method:      _eth_unlock,
+		TxHash:      log.TxHash.String()[2:],
+		ToAssetHash: strings.ToLower(evt.ToAssetHash.String()[2:]),
+		ToAddress:   strings.ToLower(evt.ToAddress.String()[2:]),
+		Amount:      evt.Amount,
+		Proxy:       strings.ToLower(log.Address.String()[2:]),
+	})
+	return nil
+}
+
+func decodeNFTProxyLock(this *EthereumChainListen, contract *eventContract, log types.Log, events *blockEvents) error {
+	evt, err := nftProxyFilterer.ParseLockEvent(log)
+	if err != nil {
+		return err
+	}
+	evt.Raw = log
+	events.proxyLockEvents = append(events.proxyLockEvents, convertLockProxyEvent(evt))
+	return nil
+}
+
+func decodeNFTProxyUnlock(this *EthereumChainListen, contract *eventContract, log types.Log, events *blockEvents) error {
+	evt, err := nftProxyFilterer.ParseUnlockEvent(log)
+	if err != nil {
+		return err
+	}
+	evt.Raw = log
+	events.proxyUnlockEvents = append(events.proxyUnlockEvents, convertUnlockProxyEvent(evt))
+	return nil
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ethereumlisten
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"poly-bridge/basedef"
+	"poly-bridge/chainsdk"
+	"poly-bridge/go_abi/eccm_abi"
+	"poly-bridge/models"
+
+	"github.com/astaxie/beego/logs"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	polycm "github.com/polynetwork/poly/common"
+)
+
+const (
+	_eth_verify_and_execute = "verifyHeaderAndExecuteTx"
+	_max_revert_reason      = 1024
+)
+
+var eccmAbi abi.ABI
+
+func init() {
+	var err error
+	eccmAbi, err = abi.JSON(strings.NewReader(eccm_abi.EthCrossChainManagerABI))
+	if err != nil {
+		panic(err)
+	}
+}
+
+// HandleFailedUnlocks finds the reverted calls to verifyHeaderAndExecuteTx of the ccm contract, which
+// emit no events, by checking the receipts of the calls in the blocks. The blocks of the range and the
+// receipts of the calls are got in batch requests, so a catch up range is scanned as a whole.
+func (this *EthereumChainListen) HandleFailedUnlocks(heightStart uint64, heightEnd uint64) ([]*models.FailedDstTransaction, error) {
+	numbers := make([]uint64, 0, heightEnd-heightStart+1)
+	for height := heightStart; height <= heightEnd; height++ {
+		numbers = append(numbers, height)
+	}
+	blocks, err := this.ethSdk.GetBlocksTransactions(numbers)
+	if err != nil {
+		return nil, err
+	}
+	ccmContract := common.HexToAddress(this.ethCfg.CCMContract)
+	method := eccmAbi.Methods[_eth_verify_and_execute]
+	calls, hashes := make([]*chainsdk.RpcTransaction, 0), make([]common.Hash, 0)
+	heights, times := make(map[common.Hash]uint64), make(map[common.Hash]uint64)
+	for i, block := range blocks {
+		for _, tx := range block.Transactions {
+			if tx.To == nil || *tx.To != ccmContract || !bytes.HasPrefix(tx.Input, method.ID) {
+				continue
+			}
+			calls, hashes = append(calls, tx), append(hashes, tx.Hash)
+			heights[tx.Hash], times[tx.Hash] = numbers[i], uint64(block.Timestamp)
+		}
+	}
+	failedDstTransactions := make([]*models.FailedDstTransaction, 0)
+	if len(calls) == 0 {
+		return failedDstTransactions, nil
+	}
+	receipts, err := this.ethSdk.GetTransactionReceipts(hashes)
+	if err != nil {
+		return nil, err
+	}
+	failedCalls, failedHashes := make([]*chainsdk.RpcTransaction, 0), make([]common.Hash, 0)
+	for i, receipt := range receipts {
+		if receipt.Status == types.ReceiptStatusFailed {
+			failedCalls, failedHashes = append(failedCalls, calls[i]), append(failedHashes, hashes[i])
+		}
+	}
+	if len(failedCalls) == 0 {
+		return failedDstTransactions, nil
+	}
+	gases, err := this.ethSdk.GetTransactionsGas(failedHashes)
+	if err != nil {
+		return nil, err
+	}
+	for i, tx := range failedCalls {
+		height := heights[tx.Hash]
+		logs.Info("(failed unlock) to chain: %s, txhash: %s", this.GetChainName(), tx.Hash.String())
+		failedDstTransaction := &models.FailedDstTransaction{
+			Hash:      tx.Hash.String()[2:],
+			ChainId:   this.GetChainId(),
+			Time:      times[tx.Hash],
+			Height:    height,
+			Submitter: strings.ToLower(tx.From.String()[2:]),
+		}
+		failedDstTransaction.PolyHash, failedDstTransaction.SrcChainId, err = decodeVerifyAndExecuteTx(method, tx.Input)
+		if err != nil {
+			logs.Warn("decode unlock of chain: %s, txhash: %s, err: %v", this.GetChainName(), tx.Hash.String(), err)
+		}
+		failedDstTransaction.Reason, err = this.ethSdk.GetRevertReason(tx, height-1)
+		if err != nil {
+			return nil, err
+		}
+		if len(failedDstTransaction.Reason) > _max_revert_reason {
+			failedDstTransaction.Reason = failedDstTransaction.Reason[:_max_revert_reason]
+		}
+		failedDstTransaction.GasUsed = gases[i].GasUsed
+		failedDstTransaction.GasPrice = models.NewBigInt(gases[i].GasPrice)
+		failedDstTransaction.Fee = models.NewBigInt(consumeFee(gases[i].GasUsed, gases[i].GasPrice))
+		failedDstTransactions = append(failedDstTransactions, failedDstTransaction)
+	}
+	return failedDstTransactions, nil
+}
+
+// decodeVerifyAndExecuteTx returns the poly hash and the source chain of the cross chain transaction
+// carried by the proof of a verifyHeaderAndExecuteTx call.
+func decodeVerifyAndExecuteTx(method abi.Method, input []byte) (string, uint64, error) {
+	values, err := method.Inputs.UnpackValues(input[len(method.ID):])
+	if err != nil {
+		return "", 0, err
+	}
+	proof, ok := values[0].([]byte)
+	if !ok {
+		return "", 0, fmt.Errorf("proof is not bytes")
+	}
+	value, eof := polycm.NewZeroCopySource(proof).NextVarBytes()
+	if eof {
+		return "", 0, fmt.Errorf("decode proof value error")
+	}
+	source := polycm.NewZeroCopySource(value)
+	polyHash, eof := source.NextVarBytes()
+	if eof {
+		return "", 0, fmt.Errorf("decode poly hash error")
+	}
+	fromChainId, eof := source.NextUint64()
+	if eof {
+		return "", 0, fmt.Errorf("decode from chain id error")
+	}
+	return basedef.HexStringReverse(hex.EncodeToString(polyHash)), fromChainId, nil
+}
@@ -1,16 +1,9 @@
 package ethereumlisten
 
 import (
-	"bytes"
-	"context"
-	"fmt"
-	"poly-bridge/go_abi/eccm_abi"
-	nftlp "poly-bridge/go_abi/nft_lock_proxy_abi"
-	nftwp "poly-bridge/go_abi/nft_wrap_abi"
 	"poly-bridge/models"
 	"strings"
 
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 )
 
@@ -45,15 +38,11 @@ func isContract(addr string) bool {
 }
 
 func (e *EthereumChainListen) isNFTECCMLockEvent(event *models.ECCMLockEvent) bool {
-	addr1 := common.HexToAddress(event.Contract)
-	addr2 := common.HexToAddress(e.ethCfg.NFTProxyContract)
-	return bytes.Equal(addr1.Bytes(), addr2.Bytes())
+	return e.ethCfg.IsNFTProxyContract(event.Contract)
 }
 
 func (e *EthereumChainListen) isNFTECCMUnlockEvent(event *models.ECCMUnlockEvent) bool {
-	addr1 := common.HexToAddress(event.Contract)
-	addr2 := common.HexToAddress(e.ethCfg.NFTProxyContract)
-	return bytes.Equal(addr1.Bytes(), addr2.Bytes())
+	return e.ethCfg.IsNFTProxyContract(event.Contract)
 }
 
 func (e *EthereumChainListen) NFTWrapperAddress() common.Address {
@@ -175,137 +164,3 @@ func (e *EthereumChainListen) NFTProxyAddress() common.Address {
 //
 //	return wrapperTransactions, srcTransactions, dstTransactions, nil
 //}
-
-func (e *EthereumChainListen) getNFTWrapperEventByBlockNumber(
-	wrapAddrStr string,
-	startHeight, endHeight uint64) (
-	[]*models.WrapperTransaction,
-	error,
-) {
-	if !isContract(wrapAddrStr) {
-		return nil, nil
-	}
-
-	wrapAddr := common.HexToAddress(wrapAddrStr)
-	wrapperContract, err := nftwp.NewPolyNFTWrapper(wrapAddr, e.ethSdk.GetClient())
-	if err != nil {
-		return nil, fmt.Errorf("GetSmartContractEventByBlock, error: %s", err.Error())
-	}
-	opt := &bind.FilterOpts{
-		Start:   startHeight,
-		End:     &endHeight,
-		Context: context.Background(),
-	}
-
-	// get ethereum lock events from given block
-	wrapperTransactions := make([]*models.WrapperTransaction, 0)
-	lockEvents, err := wrapperContract.FilterPolyWrapperLock(opt, nil, nil)
-	if err != nil {
-		return nil, fmt.Errorf("GetSmartContractEventByBlock, filter lock events :%s", err.Error())
-	}
-	for lockEvents.Next() {
-		evt := lockEvents.Event
-		wtx := wrapLockEvent2WrapTx(evt)
-		wrapperTransactions = append(wrapperTransactions, wtx)
-	}
-	speedupEvents, err := wrapperContract.FilterPolyWrapperSpeedUp(opt, nil, nil, nil)
-	if err != nil {
-		return nil, fmt.Errorf("GetSmartContractEventByBlock, filter lock events :%s", err.Error())
-	}
-	for speedupEvents.Next() {
-		evt := speedupEvents.Event
-		wtx := wrapSpeedUpEvent2WrapTx(evt)
-		wrapperTransactions = append(wrapperTransactions, wtx)
-	}
-	return wrapperTransactions, nil
-}
-
-func (e *EthereumChainListen) getNFTECCMEventByBlockNumber(
-	eccmAddr common.Address,
-	startHeight, endHeight uint64) (
-	[]*models.ECCMLockEvent,
-	[]*models.ECCMUnlockEvent,
-	error,
-) {
-
-	eccmContract, err := eccm_abi.NewEthCrossChainManager(eccmAddr, e.ethSdk.GetClient())
-	if err != nil {
-		return nil, nil, fmt.Errorf("GetSmartContractEventByBlock, error: %s", err.Error())
-	}
-	opt := &bind.FilterOpts{
-		Start:   startHeight,
-		End:     &endHeight,
-		Context: context.Background(),
-	}
-	// get ethereum lock events from given block
-	eccmLockEvents := make([]*models.ECCMLockEvent, 0)
-	crossChainEvents, err := eccmContract.FilterCrossChainEvent(opt, nil)
-	if err != nil {
-		return nil, nil, fmt.Errorf("GetSmartContractEventByBlock, filter lock events :%s", err.Error())
-	}
-	for crossChainEvents.Next() {
-		evt := crossChainEvents.Event
-		Fee := e.GetConsumeGas(evt.Raw.TxHash)
-		eccmLockEvent := crossChainEvent2ProxyLockEvent(evt, Fee)
-		eccmLockEvents = append(eccmLockEvents, eccmLockEvent)
-	}
-	// ethereum unlock events from given block
-	eccmUnlockEvents := make([]*models.ECCMUnlockEvent, 0)
-	executeTxEvent, err := eccmContract.FilterVerifyHeaderAndExecuteTxEvent(opt)
-	if err != nil {
-		return nil, nil, fmt.Errorf("GetSmartContractEventByBlock, filter unlock events :%s", err.Error())
-	}
-
-	for executeTxEvent.Next() {
-		evt := executeTxEvent.Event
-		Fee := e.GetConsumeGas(evt.Raw.TxHash)
-		eccmUnlockEvent := verifyAndExecuteEvent2ProxyUnlockEvent(evt, Fee)
-		eccmUnlockEvents = append(eccmUnlockEvents, eccmUnlockEvent)
-	}
-	return eccmLockEvents, eccmUnlockEvents, nil
-}
-
-func (e *EthereumChainListen) getNFTProxyEventByBlockNumber(
-	proxyAddrStr string,
-	startHeight, endHeight uint64) (
-	[]*models.ProxyLockEvent,
-	[]*models.ProxyUnlockEvent,
-	error,
-) {
-	if !isContract(proxyAddrStr) {
-		return nil, nil, nil
-	}
-
-	proxyAddr := common.HexToAddress(proxyAddrStr)
-	proxyContract, err := nftlp.NewPolyNFTLockProxy(proxyAddr, e.ethSdk.GetClient())
-	if err != nil {
-		return nil, nil, fmt.Errorf("GetSmartContractEventByBlock, error: %s", err.Error())
-	}
-	opt := &bind.FilterOpts{
-		Start:   startHeight,
-		End:     &endHeight,
-		Context: context.Background(),
-	}
-	// get ethereum lock events from given block
-	proxyLockEvents := make([]*models.ProxyLockEvent, 0)
-	lockEvents, err := proxyContract.FilterLockEvent(opt)
-	if err != nil {
-		return nil, nil, fmt.Errorf("GetSmartContractEventByBlock, filter lock events :%s", err.Error())
-	}
-	for lockEvents.Next() {
-		proxyLockEvent := convertLockProxyEvent(lockEvents.Event)
-		proxyLockEvents = append(proxyLockEvents, proxyLockEvent)
-	}
-
-	// ethereum unlock events from given block
-	proxyUnlockEvents := make([]*models.ProxyUnlockEvent, 0)
-	unlockEvents, err := proxyContract.FilterUnlockEvent(opt)
-	if err != nil {
-		return nil, nil, fmt.Errorf("GetSmartContractEventByBlock, filter unlock events :%s", err.Error())
-	}
-	for unlockEvents.Next() {
-		proxyUnlockEvent := convertUnlockProxyEvent(unlockEvents.Event)
-		proxyUnlockEvents = append(proxyUnlockEvents, proxyUnlockEvent)
-	}
-	return proxyLockEvents, proxyUnlockEvents, nil
-}
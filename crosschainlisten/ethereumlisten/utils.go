@@ -2,6 +2,7 @@ package ethereumlisten
 
 import (
 	"encoding/hex"
+	"math/big"
 	"strings"
 
 	"poly-bridge/basedef"
@@ -21,7 +22,9 @@ func assembleSrcTransaction(
 	srcTransaction.ChainId = chainID
 	srcTransaction.Hash = eccmLockEvent.TxHash
 	srcTransaction.State = 1
-	srcTransaction.Fee = models.NewBigIntFromInt(int64(eccmLockEvent.Fee))
+	srcTransaction.Fee = models.NewBigInt(eccmLockEvent.Fee)
+	srcTransaction.GasUsed = eccmLockEvent.GasUsed
+	srcTransaction.GasPrice = models.NewBigInt(eccmLockEvent.GasPrice)
 	if tt > 0 {
 		srcTransaction.Time = tt
 	}
@@ -65,7 +68,9 @@ func assembleDstTransaction(
 	dstTransaction.ChainId = chainID
 	dstTransaction.Hash = eccmUnlockEvent.TxHash
 	dstTransaction.State = 1
-	dstTransaction.Fee = models.NewBigIntFromInt(int64(eccmUnlockEvent.Fee))
+	dstTransaction.Fee = models.NewBigInt(eccmUnlockEvent.Fee)
+	dstTransaction.GasUsed = eccmUnlockEvent.GasUsed
+	dstTransaction.GasPrice = models.NewBigInt(eccmUnlockEvent.GasPrice)
 	if tt > 0 {
 		dstTransaction.Time = tt
 	}
@@ -113,13 +118,15 @@ func wrapSpeedUpEvent2WrapTx(evt *nftwp.PolyNFTWrapperPolyWrapperSpeedUp) *model
 		User:         evt.Sender.String(),
 		FeeTokenHash: evt.FeeToken.String(),
 		FeeAmount:    models.NewBigInt(evt.Efee),
+		BlockHeight:  evt.Raw.BlockNumber,
 		Standard:     models.TokenTypeErc721,
 	}
 }
 
 func crossChainEvent2ProxyLockEvent(
 	evt *eccm_abi.EthCrossChainManagerCrossChainEvent,
-	fee uint64,
+	gasUsed uint64,
+	gasPrice *big.Int,
 ) *models.ECCMLockEvent {
 
 	return &models.ECCMLockEvent{
@@ -131,7 +138,9 @@ func crossChainEvent2ProxyLockEvent(
 		Contract: strings.ToLower(evt.ProxyOrAssetContract.String()[2:]),
 		Value:    evt.Rawdata,
 		Height:   evt.Raw.BlockNumber,
-		Fee:      fee,
+		GasUsed:  gasUsed,
+		GasPrice: gasPrice,
+		Fee:      consumeFee(gasUsed, gasPrice),
 	}
 }
 
@@ -145,12 +154,14 @@ func convertLockProxyEvent(evt *nftlp.PolyNFTLockProxyLockEvent) *models.ProxyLo
 		ToAssetHash:   hex.EncodeToString(evt.ToAssetHash),
 		ToAddress:     hex.EncodeToString(evt.ToAddress),
 		Amount:        evt.TokenId,
+		Proxy:         strings.ToLower(evt.Raw.Address.String()[2:]),
 	}
 }
 
 func verifyAndExecuteEvent2ProxyUnlockEvent(
 	evt *eccm_abi.EthCrossChainManagerVerifyHeaderAndExecuteTxEvent,
-	fee uint64,
+	gasUsed uint64,
+	gasPrice *big.Int,
 ) *models.ECCMUnlockEvent {
 
 	return &models.ECCMUnlockEvent{
@@ -160,7 +171,9 @@ func verifyAndExecuteEvent2ProxyUnlockEvent(
 		Contract: hex.EncodeToString(evt.ToContract),
 		FChainId: uint32(evt.FromChainID),
 		Height:   evt.Raw.BlockNumber,
-		Fee:      fee,
+		GasUsed:  gasUsed,
+		GasPrice: gasPrice,
+		Fee:      consumeFee(gasUsed, gasPrice),
 	}
 }
 
@@ -171,6 +184,7 @@ func convertUnlockProxyEvent(evt *nftlp.PolyNFTLockProxyUnlockEvent) *models.Pro
 		ToAssetHash: strings.ToLower(evt.ToAssetHash.String()[2:]),
 		ToAddress:   strings.ToLower(evt.ToAddress.String()[2:]),
 		Amount:      evt.TokenId,
+		Proxy:       strings.ToLower(evt.Raw.Address.String()[2:]),
 	}
 }
 
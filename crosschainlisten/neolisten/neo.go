@@ -30,6 +30,9 @@ import (
 	"poly-bridge/conf"
 	"poly-bridge/models"
 	"strings"
+
+	neomodels "github.com/joeqian10/neo-gogogo/rpc/models"
+	"github.com/shopspring/decimal"
 )
 
 const (
@@ -42,9 +45,13 @@ const (
 	_poly_wrapper_lock    = "PolyWrapperLock"
 )
 
+// Neo prices the opcodes in GAS, so the gas used of a transaction is the GAS consumed in fixed8 at a price of 1
+const _neo_gas_price = 1
+
 type NeoChainListen struct {
 	neoCfg *conf.ChainListenConfig
 	neoSdk *chainsdk.NeoSdkPro
+	quorum *NeoChainListen
 }
 
 func NewNeoChainListen(cfg *conf.ChainListenConfig) *NeoChainListen {
@@ -53,6 +60,9 @@ func NewNeoChainListen(cfg *conf.ChainListenConfig) *NeoChainListen {
 	urls := cfg.GetNodesUrl()
 	sdk := chainsdk.NewNeoSdkPro(urls, cfg.ListenSlot, cfg.ChainId)
 	ethListen.neoSdk = sdk
+	if quorumCfg := cfg.GetQuorumConfig(); quorumCfg != nil {
+		ethListen.quorum = NewNeoChainListen(quorumCfg)
+	}
 	return ethListen
 }
 
@@ -90,6 +100,38 @@ func (this *NeoChainListen) isListeningContract(contract string, contracts []str
 	return false
 }
 
+func (this *NeoChainListen) GetBatchSize() uint64 {
+	return this.neoCfg.BatchSize
+}
+
+func (this *NeoChainListen) QuorumEnabled() bool {
+	return this.quorum != nil
+}
+
+// HandleQuorumBlocks handles the blocks again on the quorum nodes, the block hash is not tracked
+func (this *NeoChainListen) HandleQuorumBlocks(heightStart uint64, heightEnd uint64) (string, []*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error) {
+	wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, err := this.quorum.HandleNewBlocks(heightStart, heightEnd)
+	return "", wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, err
+}
+
+func (this *NeoChainListen) HandleNewBlocks(heightStart uint64, heightEnd uint64) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error) {
+	wrapperTransactions := make([]*models.WrapperTransaction, 0)
+	srcTransactions := make([]*models.SrcTransaction, 0)
+	polyTransactions := make([]*models.PolyTransaction, 0)
+	dstTransactions := make([]*models.DstTransaction, 0)
+	for height := heightStart; height <= heightEnd; height++ {
+		wrappers, srcs, polys, dsts, err := this.HandleNewBlock(height)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		wrapperTransactions = append(wrapperTransactions, wrappers...)
+		srcTransactions = append(srcTransactions, srcs...)
+		polyTransactions = append(polyTransactions, polys...)
+		dstTransactions = append(dstTransactions, dsts...)
+	}
+	return wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, nil
+}
+
 func (this *NeoChainListen) HandleNewBlock(height uint64) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error) {
 	block, err := this.neoSdk.GetBlockByIndex(height)
 	if err != nil {
@@ -187,6 +229,7 @@ func (this *NeoChainListen) HandleNewBlock(height uint64) ([]*models.WrapperTran
 								}
 								fctransfer.ChainId = this.GetChainId()
 								fctransfer.TxHash = tx.Txid[2:]
+								fctransfer.Proxy = strings.TrimPrefix(notifynew.Contract, "0x")
 								fctransfer.Time = uint64(tt)
 								fctransfer.From = notifynew.State.Value[2].Value
 								fctransfer.To = notify.State.Value[2].Value
@@ -217,7 +260,9 @@ func (this *NeoChainListen) HandleNewBlock(height uint64) ([]*models.WrapperTran
 						fctx.ChainId = this.GetChainId()
 						fctx.Hash = tx.Txid[2:]
 						fctx.State = 1
-						fctx.Fee = models.NewBigInt(big.NewInt(int64(basedef.String2Float64(exeitem.GasConsumed))))
+						fctx.Fee = models.NewBigInt(neoTxFee(&tx))
+						fctx.GasUsed = neoFixed8(exeitem.GasConsumed).Uint64()
+						fctx.GasPrice = models.NewBigIntFromInt(_neo_gas_price)
 						fctx.Time = uint64(tt)
 						fctx.Height = height
 						fctx.User = fctransfer.From
@@ -231,7 +276,15 @@ func (this *NeoChainListen) HandleNewBlock(height uint64) ([]*models.WrapperTran
 						fctx.Contract = notify.State.Value[2].Value
 						fctx.Key = notify.State.Value[4].Value
 						fctx.Param = notify.State.Value[5].Value
-						fctx.SrcTransfer = fctransfer
+						if fctransfer.TxHash != "" {
+							fctx.SrcTransfer = fctransfer
+						} else {
+							fctx.MessageType = models.MessageTypeCall
+						}
+						fctx.MakeTxParam, err = models.DecodeMakeTxParam(fctx.Hash, fctx.Param, fctx.Standard)
+						if err != nil {
+							logs.Warn("decode param of chain: %s, txhash: %s, err: %v", this.GetChainName(), fctx.Hash, err)
+						}
 						srcTransactions = append(srcTransactions, fctx)
 					case _neo_crosschainunlock:
 						logs.Info("(unlock) to chain: %s, txhash: %s", this.GetChainName(), tx.Txid[2:])
@@ -247,6 +300,7 @@ func (this *NeoChainListen) HandleNewBlock(height uint64) ([]*models.WrapperTran
 								}
 								tctransfer.ChainId = this.GetChainId()
 								tctransfer.TxHash = tx.Txid[2:]
+								tctransfer.Proxy = strings.TrimPrefix(notifynew.Contract, "0x")
 								tctransfer.Time = uint64(tt)
 								tctransfer.From = notify.State.Value[2].Value
 								tctransfer.To = notifynew.State.Value[2].Value
@@ -265,7 +319,9 @@ func (this *NeoChainListen) HandleNewBlock(height uint64) ([]*models.WrapperTran
 						tctx.ChainId = this.GetChainId()
 						tctx.Hash = tx.Txid[2:]
 						tctx.State = 1
-						tctx.Fee = models.NewBigInt(big.NewInt(int64(basedef.String2Float64(exeitem.GasConsumed))))
+						tctx.Fee = models.NewBigInt(neoTxFee(&tx))
+						tctx.GasUsed = neoFixed8(exeitem.GasConsumed).Uint64()
+						tctx.GasPrice = models.NewBigIntFromInt(_neo_gas_price)
 						tctx.Time = uint64(tt)
 						tctx.Height = height
 						fChainId := big.NewInt(0)
@@ -277,7 +333,11 @@ func (this *NeoChainListen) HandleNewBlock(height uint64) ([]*models.WrapperTran
 						tctx.SrcChainId = fChainId.Uint64()
 						tctx.Contract = basedef.HexStringReverse(notify.State.Value[2].Value)
 						tctx.PolyHash = basedef.HexStringReverse(notify.State.Value[3].Value)
-						tctx.DstTransfer = tctransfer
+						if tctransfer.TxHash != "" {
+							tctx.DstTransfer = tctransfer
+						} else {
+							tctx.MessageType = models.MessageTypeCall
+						}
 						dstTransactions = append(dstTransactions, tctx)
 					default:
 						logs.Warn("ignore method: %s", contractMethod)
@@ -340,3 +400,16 @@ func (this *NeoChainListen) getExtendLatestHeight(node int) (uint64, error) {
 	}
 	return extendHeight.LastHeight, nil
 }
+
+// neoTxFee returns the system fee and network fee paid by the transaction in fixed8 GAS
+func neoTxFee(tx *neomodels.RpcTransaction) *big.Int {
+	return new(big.Int).Add(neoFixed8(tx.SysFee), neoFixed8(tx.NetFee))
+}
+
+func neoFixed8(value string) *big.Int {
+	amount, err := decimal.NewFromString(value)
+	if err != nil {
+		return big.NewInt(0)
+	}
+	return amount.Shift(8).BigInt()
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package neolisten
+
+import (
+	"poly-bridge/basedef"
+	"poly-bridge/chainsdk"
+	"poly-bridge/conf"
+	"poly-bridge/crosschaindao/memorydao"
+	"poly-bridge/models"
+	"poly-bridge/utils/rpcreplay"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// the synthetic fixture is a lock through the wrapper and an unlock on neo at height 7000000, it is served
+// by utils/rpcreplay/fakenode and is not chain data, do not edit it but record it again from the fake node
+func TestNeoChainListen_HandleSyntheticBlock(t *testing.T) {
+	transport, err := rpcreplay.NewTransport("testdata/synthetic_neo_7000000.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainsdk.SetRpcTransport(transport)
+	defer chainsdk.SetRpcTransport(nil)
+	defer transport.Close()
+	listen := NewNeoChainListen(&conf.ChainListenConfig{
+		ChainName:       "NEO",
+		ChainId:         basedef.NEO_CROSSCHAIN_ID,
+		ListenSlot:      10,
+		Nodes:           []*conf.Restful{{Url: rpcreplay.NodeUrl("http://127.0.0.1:10332")}},
+		WrapperContract: []string{"125c83403763670c215f9c7c815ef759b258a41b"},
+		CCMContract:     "82a3401fb9a60db42c6fa2ea2b6d62e872d6257f",
+		ProxyContract:   "e7fb2e1d937e71dbbb512e6375746181127282e7",
+	})
+
+	wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, err := listen.HandleNewBlock(7000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, len(polyTransactions))
+
+	assert.Equal(t, 1, len(wrapperTransactions))
+	wrapper := wrapperTransactions[0]
+	assert.Equal(t, "4fe8d6b2a1c0f9e8d7c6b5a49382716050f4e3d2c1b0a9f8e7d6c5b4a3928170", wrapper.Hash)
+	assert.Equal(t, "d2c0f6c2f3a6e4d1b3a29281706f5e4d3c2b1a09", wrapper.User)
+	assert.Equal(t, basedef.NEO_CROSSCHAIN_ID, wrapper.SrcChainId)
+	assert.Equal(t, basedef.ETHEREUM_CROSSCHAIN_ID, wrapper.DstChainId)
+	assert.Equal(t, "5a51e2ebf8d136926b9ca7b59b60464e7c44d2eb", wrapper.DstUser)
+	assert.Equal(t, "17da3881ab2d050fea414c80b3fa8324d756f60e", wrapper.FeeTokenHash)
+	assert.Equal(t, "10000000", wrapper.FeeAmount.String())
+	assert.Equal(t, uint64(5), wrapper.ServerId)
+	assert.Equal(t, uint64(1615970812), wrapper.Time)
+
+	assert.Equal(t, 1, len(srcTransactions))
+	src := srcTransactions[0]
+	assert.Equal(t, models.MessageTypeTransfer, src.MessageType)
+	assert.Equal(t, "4fe8d6b2a1c0f9e8d7c6b5a49382716050f4e3d2c1b0a9f8e7d6c5b4a3928170", src.Hash)
+	assert.Equal(t, uint64(7000000), src.Height)
+	// gas consumed is 3.862 gas
+	assert.Equal(t, "100000", src.Fee.String())
+	assert.Equal(t, uint64(386200000), src.GasUsed)
+	assert.Equal(t, "1", src.GasPrice.String())
+	assert.Equal(t, "d2c0f6c2f3a6e4d1b3a29281706f5e4d3c2b1a09", src.User)
+	assert.Equal(t, basedef.ETHEREUM_CROSSCHAIN_ID, src.DstChainId)
+	assert.Equal(t, "e782721281617475632e51bbdb717e931d2efbe7", src.Contract)
+	assert.Equal(t, "0e8a", src.Key)
+	assert.NotNil(t, src.SrcTransfer)
+	assert.Equal(t, "17da3881ab2d050fea414c80b3fa8324d756f60e", src.SrcTransfer.Asset)
+	assert.Equal(t, "2000000000", src.SrcTransfer.Amount.String())
+	assert.Equal(t, basedef.ETHEREUM_CROSSCHAIN_ID, src.SrcTransfer.DstChainId)
+	assert.Equal(t, "b7b1af3a8b26f5f8a5c81d9d1b35d2e5f1c6e7a0", src.SrcTransfer.DstAsset)
+	assert.Equal(t, "5a51e2ebf8d136926b9ca7b59b60464e7c44d2eb", src.SrcTransfer.DstUser)
+	assert.Equal(t, "e7fb2e1d937e71dbbb512e6375746181127282e7", src.SrcTransfer.Proxy)
+	assert.NotNil(t, src.MakeTxParam)
+	assert.Equal(t, src.Key, src.MakeTxParam.TxId)
+	assert.Equal(t, src.Contract, src.MakeTxParam.FromContract)
+	assert.Equal(t, basedef.ETHEREUM_CROSSCHAIN_ID, src.MakeTxParam.ToChainId)
+	assert.Equal(t, "b7b1af3a8b26f5f8a5c81d9d1b35d2e5f1c6e7a0", src.MakeTxParam.ToContract)
+	assert.Equal(t, models.MAKE_TX_METHOD_UNLOCK, src.MakeTxParam.Method)
+	assert.Equal(t, "5a51e2ebf8d136926b9ca7b59b60464e7c44d2eb", src.MakeTxParam.ToAddress)
+	assert.Equal(t, "2000000000", src.MakeTxParam.Amount.String())
+
+	assert.Equal(t, 1, len(dstTransactions))
+	dst := dstTransactions[0]
+	assert.Equal(t, models.MessageTypeTransfer, dst.MessageType)
+	assert.Equal(t, "0b1c2d3e4f5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0", dst.Hash)
+	assert.Equal(t, "100250000", dst.Fee.String())
+	assert.Equal(t, uint64(541700000), dst.GasUsed)
+	assert.Equal(t, "1", dst.GasPrice.String())
+	assert.Equal(t, basedef.ETHEREUM_CROSSCHAIN_ID, dst.SrcChainId)
+	assert.Equal(t, "e7fb2e1d937e71dbbb512e6375746181127282e7", dst.Contract)
+	assert.Equal(t, "5e0f2c7d9b3a6e1f4c8d2b5a9e3f7c1d6b4a8e2f5c9d3b7a7b2a6f3e9c1d4b8a", dst.PolyHash)
+	assert.NotNil(t, dst.DstTransfer)
+	assert.Equal(t, "d2c0f6c2f3a6e4d1b3a29281706f5e4d3c2b1a09", dst.DstTransfer.To)
+	assert.Equal(t, "17da3881ab2d050fea414c80b3fa8324d756f60e", dst.DstTransfer.Asset)
+	assert.Equal(t, "1500000000", dst.DstTransfer.Amount.String())
+	assert.Equal(t, "e7fb2e1d937e71dbbb512e6375746181127282e7", dst.DstTransfer.Proxy)
+
+	dao := memorydao.NewMemoryDao()
+	assert.Nil(t, dao.UpdateEvents(nil, wrapperTransactions, srcTransactions, polyTransactions, dstTransactions))
+	assert.Equal(t, 1, len(dao.WrapperTransactions()))
+	assert.Equal(t, 1, len(dao.SrcTransactions()))
+	assert.Equal(t, 1, len(dao.DstTransactions()))
+}
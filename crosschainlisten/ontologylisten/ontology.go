@@ -38,6 +38,7 @@ const (
 type OntologyChainListen struct {
 	ontCfg *conf.ChainListenConfig
 	ontSdk *chainsdk.OntologySdkPro
+	quorum *OntologyChainListen
 }
 
 func NewOntologyChainListen(cfg *conf.ChainListenConfig) *OntologyChainListen {
@@ -46,6 +47,9 @@ func NewOntologyChainListen(cfg *conf.ChainListenConfig) *OntologyChainListen {
 	urls := cfg.GetNodesUrl()
 	sdk := chainsdk.NewOntologySdkPro(urls, cfg.ListenSlot, cfg.ChainId)
 	ontListen.ontSdk = sdk
+	if quorumCfg := cfg.GetQuorumConfig(); quorumCfg != nil {
+		ontListen.quorum = NewOntologyChainListen(quorumCfg)
+	}
 	return ontListen
 }
 
@@ -83,12 +87,49 @@ func (this *OntologyChainListen) isListeningContract(contract string, contracts
 	return false
 }
 
+func (this *OntologyChainListen) GetBatchSize() uint64 {
+	return this.ontCfg.BatchSize
+}
+
+func (this *OntologyChainListen) QuorumEnabled() bool {
+	return this.quorum != nil
+}
+
+// HandleQuorumBlocks handles the blocks again on the quorum nodes, the block hash is not tracked
+func (this *OntologyChainListen) HandleQuorumBlocks(heightStart uint64, heightEnd uint64) (string, []*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error) {
+	wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, err := this.quorum.HandleNewBlocks(heightStart, heightEnd)
+	return "", wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, err
+}
+
+func (this *OntologyChainListen) HandleNewBlocks(heightStart uint64, heightEnd uint64) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error) {
+	wrapperTransactions := make([]*models.WrapperTransaction, 0)
+	srcTransactions := make([]*models.SrcTransaction, 0)
+	polyTransactions := make([]*models.PolyTransaction, 0)
+	dstTransactions := make([]*models.DstTransaction, 0)
+	for height := heightStart; height <= heightEnd; height++ {
+		wrappers, srcs, polys, dsts, err := this.HandleNewBlock(height)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		wrapperTransactions = append(wrapperTransactions, wrappers...)
+		srcTransactions = append(srcTransactions, srcs...)
+		polyTransactions = append(polyTransactions, polys...)
+		dstTransactions = append(dstTransactions, dsts...)
+	}
+	return wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, nil
+}
+
 func (this *OntologyChainListen) HandleNewBlock(height uint64) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error) {
 	block, err := this.ontSdk.GetBlockByHeight(uint32(height))
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
 	tt := uint64(block.Header.Timestamp)
+	gasPrices := make(map[string]uint64, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		hash := tx.Hash()
+		gasPrices[hash.ToHexString()] = tx.GasPrice
+	}
 	events, err := this.ontSdk.GetSmartContractEventByBlock(uint32(height))
 	if err != nil {
 		return nil, nil, nil, nil, err
@@ -156,6 +197,7 @@ func (this *OntologyChainListen) HandleNewBlock(height uint64) ([]*models.Wrappe
 							}
 							srcTransfer.ChainId = this.GetChainId()
 							srcTransfer.TxHash = event.TxHash
+							srcTransfer.Proxy = notifyNew.ContractAddress
 							srcTransfer.Time = tt
 							srcTransfer.From = statesNew[2].(string)
 							srcTransfer.To = states[5].(string)
@@ -186,6 +228,7 @@ func (this *OntologyChainListen) HandleNewBlock(height uint64) ([]*models.Wrappe
 					srcTransaction.Hash = event.TxHash
 					srcTransaction.State = uint64(event.State)
 					srcTransaction.Fee = models.NewBigIntFromInt(int64(event.GasConsumed))
+					srcTransaction.GasUsed, srcTransaction.GasPrice = ontGas(event.GasConsumed, gasPrices[event.TxHash])
 					srcTransaction.Time = tt
 					srcTransaction.Height = height
 					srcTransaction.User = srcTransfer.From
@@ -193,7 +236,15 @@ func (this *OntologyChainListen) HandleNewBlock(height uint64) ([]*models.Wrappe
 					srcTransaction.Contract = basedef.HexStringReverse(states[5].(string))
 					srcTransaction.Key = states[4].(string)
 					srcTransaction.Param = states[6].(string)
-					srcTransaction.SrcTransfer = srcTransfer
+					if srcTransfer.TxHash != "" {
+						srcTransaction.SrcTransfer = srcTransfer
+					} else {
+						srcTransaction.MessageType = models.MessageTypeCall
+					}
+					srcTransaction.MakeTxParam, err = models.DecodeMakeTxParam(srcTransaction.Hash, srcTransaction.Param, srcTransaction.Standard)
+					if err != nil {
+						logs.Warn("decode param of chain: %s, txhash: %s, err: %v", this.GetChainName(), srcTransaction.Hash, err)
+					}
 					srcTransactions = append(srcTransactions, srcTransaction)
 				case _ont_crosschainunlock:
 					logs.Info("(unlock) to chain: %s, txhash: %s", this.GetChainName(), event.TxHash)
@@ -214,6 +265,7 @@ func (this *OntologyChainListen) HandleNewBlock(height uint64) ([]*models.Wrappe
 							}
 							dstTransfer.ChainId = this.GetChainId()
 							dstTransfer.TxHash = event.TxHash
+							dstTransfer.Proxy = notifyNew.ContractAddress
 							dstTransfer.Time = tt
 							dstTransfer.From = states[5].(string)
 							dstTransfer.To = statesNew[2].(string)
@@ -231,12 +283,17 @@ func (this *OntologyChainListen) HandleNewBlock(height uint64) ([]*models.Wrappe
 					dstTransaction.Hash = event.TxHash
 					dstTransaction.State = uint64(event.State)
 					dstTransaction.Fee = models.NewBigIntFromInt(int64(event.GasConsumed))
+					dstTransaction.GasUsed, dstTransaction.GasPrice = ontGas(event.GasConsumed, gasPrices[event.TxHash])
 					dstTransaction.Time = tt
 					dstTransaction.Height = height
 					dstTransaction.SrcChainId = uint64(states[3].(float64))
 					dstTransaction.Contract = basedef.HexStringReverse(states[5].(string))
 					dstTransaction.PolyHash = basedef.HexStringReverse(states[1].(string))
-					dstTransaction.DstTransfer = dstTransfer
+					if dstTransfer.TxHash != "" {
+						dstTransaction.DstTransfer = dstTransfer
+					} else {
+						dstTransaction.MessageType = models.MessageTypeCall
+					}
 					dstTransactions = append(dstTransactions, dstTransaction)
 				default:
 					logs.Warn("ignore method: %s", contractMethod)
@@ -253,3 +310,11 @@ func (this *OntologyChainListen) GetExtendLatestHeight() (uint64, error) {
 	}
 	return this.GetLatestHeight()
 }
+
+// ontGas returns the gas used and gas price of a transaction, GasConsumed of the event is the ong paid
+func ontGas(gasConsumed uint64, gasPrice uint64) (uint64, *models.BigInt) {
+	if gasPrice == 0 {
+		return 0, models.NewBigIntFromInt(0)
+	}
+	return gasConsumed / gasPrice, models.NewBigInt(new(big.Int).SetUint64(gasPrice))
+}
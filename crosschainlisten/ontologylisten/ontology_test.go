@@ -0,0 +1,118 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package ontologylisten
+
+import (
+	"poly-bridge/basedef"
+	"poly-bridge/chainsdk"
+	"poly-bridge/conf"
+	"poly-bridge/crosschaindao/memorydao"
+	"poly-bridge/models"
+	"poly-bridge/utils/rpcreplay"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// the synthetic fixture is a lock through the wrapper and an unlock on ontology at height 13000000, it is
+// served by utils/rpcreplay/fakenode and is not chain data, do not edit it but record it again from the
+// fake node
+func TestOntologyChainListen_HandleSyntheticBlock(t *testing.T) {
+	transport, err := rpcreplay.NewTransport("testdata/synthetic_ontology_13000000.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainsdk.SetRpcTransport(transport)
+	defer chainsdk.SetRpcTransport(nil)
+	defer transport.Close()
+	listen := NewOntologyChainListen(&conf.ChainListenConfig{
+		ChainName:       "Ontology",
+		ChainId:         basedef.ONT_CROSSCHAIN_ID,
+		ListenSlot:      10,
+		Nodes:           []*conf.Restful{{Url: rpcreplay.NodeUrl("http://127.0.0.1:20336")}},
+		WrapperContract: []string{"c93837e82178d406af8c84e1841c6960af251cb5"},
+		CCMContract:     "0900000000000000000000000000000000000000",
+		ProxyContract:   "86b4ab5d99037113867247a1e68f70e348c07597",
+	})
+
+	wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, err := listen.HandleNewBlock(13000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, len(polyTransactions))
+
+	assert.Equal(t, 1, len(wrapperTransactions))
+	wrapper := wrapperTransactions[0]
+	assert.Equal(t, "aeb2c00f1eebd4ae77f0c54f0836edd31c4e0863d14a5408c4b4aa81d602eb4b", wrapper.Hash)
+	assert.Equal(t, "AQf4Mzu1YJrhz9f3aRkkwSm9n3qhXGSh4p", wrapper.User)
+	assert.Equal(t, basedef.ONT_CROSSCHAIN_ID, wrapper.SrcChainId)
+	assert.Equal(t, basedef.ETHEREUM_CROSSCHAIN_ID, wrapper.DstChainId)
+	assert.Equal(t, "2b5d9a6d8e4f3c1b0a7e6f5d4c3b2a1908f7e6d5", wrapper.DstUser)
+	assert.Equal(t, "0200000000000000000000000000000000000000", wrapper.FeeTokenHash)
+	assert.Equal(t, "50000000", wrapper.FeeAmount.String())
+	assert.Equal(t, uint64(3), wrapper.ServerId)
+	assert.Equal(t, uint64(1615970701), wrapper.Time)
+
+	assert.Equal(t, 1, len(srcTransactions))
+	src := srcTransactions[0]
+	assert.Equal(t, models.MessageTypeTransfer, src.MessageType)
+	assert.Equal(t, "aeb2c00f1eebd4ae77f0c54f0836edd31c4e0863d14a5408c4b4aa81d602eb4b", src.Hash)
+	assert.Equal(t, uint64(13000000), src.Height)
+	assert.Equal(t, "10000000", src.Fee.String())
+	assert.Equal(t, uint64(4000), src.GasUsed)
+	assert.Equal(t, "2500", src.GasPrice.String())
+	assert.Equal(t, "AQf4Mzu1YJrhz9f3aRkkwSm9n3qhXGSh4p", src.User)
+	assert.Equal(t, basedef.ETHEREUM_CROSSCHAIN_ID, src.DstChainId)
+	assert.Equal(t, "9775c048e3708fe6a1477286137103995dabb486", src.Contract)
+	assert.Equal(t, "e3ab07a1", src.Key)
+	assert.NotNil(t, src.SrcTransfer)
+	assert.Equal(t, "0100000000000000000000000000000000000000", src.SrcTransfer.Asset)
+	assert.Equal(t, "150", src.SrcTransfer.Amount.String())
+	assert.Equal(t, basedef.ETHEREUM_CROSSCHAIN_ID, src.SrcTransfer.DstChainId)
+	assert.Equal(t, "f37c3f9e4ab2bf43a5d8dd49a3d2c9c1a2dfbf0c", src.SrcTransfer.DstAsset)
+	assert.Equal(t, "2b5d9a6d8e4f3c1b0a7e6f5d4c3b2a1908f7e6d5", src.SrcTransfer.DstUser)
+	assert.Equal(t, "86b4ab5d99037113867247a1e68f70e348c07597", src.SrcTransfer.Proxy)
+	assert.NotNil(t, src.MakeTxParam)
+	assert.Equal(t, src.Key, src.MakeTxParam.TxId)
+	assert.Equal(t, basedef.ETHEREUM_CROSSCHAIN_ID, src.MakeTxParam.ToChainId)
+	assert.Equal(t, models.MAKE_TX_METHOD_UNLOCK, src.MakeTxParam.Method)
+	assert.Equal(t, "2b5d9a6d8e4f3c1b0a7e6f5d4c3b2a1908f7e6d5", src.MakeTxParam.ToAddress)
+	assert.Equal(t, "150", src.MakeTxParam.Amount.String())
+
+	assert.Equal(t, 1, len(dstTransactions))
+	dst := dstTransactions[0]
+	assert.Equal(t, models.MessageTypeTransfer, dst.MessageType)
+	assert.Equal(t, "486ef5766430901311ff310463c1ec9cc1e40dffab97a4e27bba793aee5a6e64", dst.Hash)
+	assert.Equal(t, "20000000", dst.Fee.String())
+	assert.Equal(t, uint64(40000), dst.GasUsed)
+	assert.Equal(t, "500", dst.GasPrice.String())
+	assert.Equal(t, basedef.ETHEREUM_CROSSCHAIN_ID, dst.SrcChainId)
+	assert.Equal(t, "9775c048e3708fe6a1477286137103995dabb486", dst.Contract)
+	assert.Equal(t, "ac7d9d6d7bf5b2a1c0e8f7d6c5b4a39281706f5e4d3c2b1a0918f7e6d5c4b3a2", dst.PolyHash)
+	assert.NotNil(t, dst.DstTransfer)
+	assert.Equal(t, "ARGK44mXXZfU6vcdSfFKMzjaabWxyog1qb", dst.DstTransfer.To)
+	assert.Equal(t, "0100000000000000000000000000000000000000", dst.DstTransfer.Asset)
+	assert.Equal(t, "75", dst.DstTransfer.Amount.String())
+	assert.Equal(t, "86b4ab5d99037113867247a1e68f70e348c07597", dst.DstTransfer.Proxy)
+
+	dao := memorydao.NewMemoryDao()
+	assert.Nil(t, dao.UpdateEvents(nil, wrapperTransactions, srcTransactions, polyTransactions, dstTransactions))
+	assert.Equal(t, 1, len(dao.WrapperTransactions()))
+	assert.Equal(t, 1, len(dao.SrcTransactions()))
+	assert.Equal(t, 1, len(dao.DstTransactions()))
+}
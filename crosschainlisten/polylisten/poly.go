@@ -30,6 +30,7 @@ import (
 type PolyChainListen struct {
 	polyCfg *conf.ChainListenConfig
 	polySdk *chainsdk.PolySDKPro
+	quorum  *PolyChainListen
 }
 
 func NewPolyChainListen(cfg *conf.ChainListenConfig) *PolyChainListen {
@@ -38,6 +39,9 @@ func NewPolyChainListen(cfg *conf.ChainListenConfig) *PolyChainListen {
 	urls := cfg.GetNodesUrl()
 	sdk := chainsdk.NewPolySDKPro(urls, cfg.ListenSlot, cfg.ChainId)
 	polyListen.polySdk = sdk
+	if quorumCfg := cfg.GetQuorumConfig(); quorumCfg != nil {
+		polyListen.quorum = NewPolyChainListen(quorumCfg)
+	}
 	return polyListen
 }
 
@@ -61,6 +65,38 @@ func (this *PolyChainListen) GetDefer() uint64 {
 	return this.polyCfg.Defer
 }
 
+func (this *PolyChainListen) GetBatchSize() uint64 {
+	return this.polyCfg.BatchSize
+}
+
+func (this *PolyChainListen) QuorumEnabled() bool {
+	return this.quorum != nil
+}
+
+// HandleQuorumBlocks handles the blocks again on the quorum nodes, the block hash is not tracked
+func (this *PolyChainListen) HandleQuorumBlocks(heightStart uint64, heightEnd uint64) (string, []*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error) {
+	wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, err := this.quorum.HandleNewBlocks(heightStart, heightEnd)
+	return "", wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, err
+}
+
+func (this *PolyChainListen) HandleNewBlocks(heightStart uint64, heightEnd uint64) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error) {
+	wrapperTransactions := make([]*models.WrapperTransaction, 0)
+	srcTransactions := make([]*models.SrcTransaction, 0)
+	polyTransactions := make([]*models.PolyTransaction, 0)
+	dstTransactions := make([]*models.DstTransaction, 0)
+	for height := heightStart; height <= heightEnd; height++ {
+		wrappers, srcs, polys, dsts, err := this.HandleNewBlock(height)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		wrapperTransactions = append(wrapperTransactions, wrappers...)
+		srcTransactions = append(srcTransactions, srcs...)
+		polyTransactions = append(polyTransactions, polys...)
+		dstTransactions = append(dstTransactions, dsts...)
+	}
+	return wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, nil
+}
+
 func (this *PolyChainListen) HandleNewBlock(height uint64) ([]*models.WrapperTransaction, []*models.SrcTransaction, []*models.PolyTransaction, []*models.DstTransaction, error) {
 	block, err := this.polySdk.GetBlockByHeight(height)
 	if err != nil {
@@ -98,7 +134,7 @@ func (this *PolyChainListen) HandleNewBlock(height uint64) ([]*models.WrapperTra
 				mctx.Height = height
 				mctx.SrcChainId = uint64(fchainid)
 				mctx.DstChainId = uint64(tchainid)
-				if uint64(fchainid) == basedef.ETHEREUM_CROSSCHAIN_ID || uint64(fchainid) == basedef.BSC_CROSSCHAIN_ID || uint64(fchainid) == basedef.HECO_CROSSCHAIN_ID || uint64(fchainid) == basedef.O3_CROSSCHAIN_ID || uint64(fchainid) == basedef.OK_CROSSCHAIN_ID {
+				if !basedef.IsLittleEndianChain(uint64(fchainid)) {
 					mctx.SrcHash = states[3].(string)
 				} else {
 					mctx.SrcHash = basedef.HexStringReverse(states[3].(string))
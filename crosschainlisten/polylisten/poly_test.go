@@ -0,0 +1,78 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package polylisten
+
+import (
+	"poly-bridge/basedef"
+	"poly-bridge/chainsdk"
+	"poly-bridge/conf"
+	"poly-bridge/crosschaindao/memorydao"
+	"poly-bridge/utils/rpcreplay"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// the synthetic fixture is a poly block at height 15000000 relaying an ethereum, a neo and a btc
+// transaction, it is served by utils/rpcreplay/fakenode and is not chain data, do not edit it but record it
+// again from the fake node
+func TestPolyChainListen_HandleSyntheticBlock(t *testing.T) {
+	transport, err := rpcreplay.NewTransport("testdata/synthetic_poly_15000000.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainsdk.SetRpcTransport(transport)
+	defer chainsdk.SetRpcTransport(nil)
+	defer transport.Close()
+	listen := NewPolyChainListen(&conf.ChainListenConfig{
+		ChainName:   "Poly",
+		ChainId:     basedef.POLY_CROSSCHAIN_ID,
+		ListenSlot:  10,
+		Nodes:       []*conf.Restful{{Url: rpcreplay.NodeUrl("http://127.0.0.1:20336")}},
+		CCMContract: "0300000000000000000000000000000000000000",
+	})
+
+	wrapperTransactions, srcTransactions, polyTransactions, dstTransactions, err := listen.HandleNewBlock(15000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, len(wrapperTransactions))
+	assert.Equal(t, 0, len(srcTransactions))
+	assert.Equal(t, 0, len(dstTransactions))
+	assert.Equal(t, 3, len(polyTransactions))
+	for _, polyTransaction := range polyTransactions {
+		assert.Equal(t, basedef.POLY_CROSSCHAIN_ID, polyTransaction.ChainId)
+		assert.Equal(t, uint64(15000000), polyTransaction.Height)
+		assert.Equal(t, uint64(1615970620), polyTransaction.Time)
+		assert.Equal(t, uint64(1), polyTransaction.State)
+	}
+	// the hash of a big endian chain is kept
+	assert.Equal(t, "c7a1e0b2d3f4a5968778695a4b3c2d1e0f1a2b3c4d5e6f708192a3b4c5d6e7f8", polyTransactions[0].Hash)
+	assert.Equal(t, basedef.ETHEREUM_CROSSCHAIN_ID, polyTransactions[0].SrcChainId)
+	assert.Equal(t, basedef.BSC_CROSSCHAIN_ID, polyTransactions[0].DstChainId)
+	assert.Equal(t, "a4b8d6c3e1f2091827364554637281900a1b2c3d4e5f60718293a4b5c6d7e8f9", polyTransactions[0].SrcHash)
+	// the hash of a little endian chain is reversed
+	assert.Equal(t, basedef.NEO_CROSSCHAIN_ID, polyTransactions[1].SrcChainId)
+	assert.Equal(t, basedef.ETHEREUM_CROSSCHAIN_ID, polyTransactions[1].DstChainId)
+	assert.Equal(t, "a5b4c3d2e1f0192837465564738291000a1b2c3d4e5f60718293a4b5c6d7e8f9", polyTransactions[1].SrcHash)
+	assert.Equal(t, uint64(1), polyTransactions[2].SrcChainId)
+
+	dao := memorydao.NewMemoryDao()
+	assert.Nil(t, dao.UpdateEvents(nil, wrapperTransactions, srcTransactions, polyTransactions, dstTransactions))
+	assert.Equal(t, 3, len(dao.PolyTransactions()))
+}
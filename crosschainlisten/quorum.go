@@ -0,0 +1,132 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package crosschainlisten
+
+import (
+	"fmt"
+	"poly-bridge/alert"
+	"poly-bridge/metrics"
+	"poly-bridge/models"
+
+	"github.com/astaxie/beego/logs"
+)
+
+// verifyQuorum handles the blocks again on the quorum nodes of the chain and compares the block hash and
+// the events with those of the listen nodes. The blocks are held while they disagree or the quorum nodes
+// cannot be reached, and handled again on the next round.
+func (ccl *CrossChainListen) verifyQuorum(start uint64, end uint64, hash string, wrapperTransactions []*models.WrapperTransaction, srcTransactions []*models.SrcTransaction, polyTransactions []*models.PolyTransaction, dstTransactions []*models.DstTransaction) bool {
+	quorumHandle, ok := ccl.handle.(QuorumHandle)
+	if !ok || !quorumHandle.QuorumEnabled() {
+		return true
+	}
+	chainId := ccl.handle.GetChainId()
+	quorumHash, quorumWrapperTransactions, quorumSrcTransactions, quorumPolyTransactions, quorumDstTransactions, err := quorumHandle.HandleQuorumBlocks(start, end)
+	if err != nil {
+		logs.Error("HandleQuorumBlocks %d-%d err: %v", start, end, err)
+		alert.Fire(&alert.Alert{
+			Key:      fmt.Sprintf("chain_quorum_node_%d", chainId),
+			Severity: alert.SEVERITY_WARNING,
+			Source:   "crosschainlisten",
+			Title:    fmt.Sprintf("cannot handle chain %s blocks on quorum nodes", ccl.handle.GetChainName()),
+			Message:  fmt.Sprintf("%v", err),
+		})
+		return false
+	}
+	alert.Resolve(fmt.Sprintf("chain_quorum_node_%d", chainId), fmt.Sprintf("chain %s quorum nodes handled blocks %d-%d", ccl.handle.GetChainName(), start, end))
+	if hash == "" && quorumHash != "" {
+		if reorgHandle, ok := ccl.handle.(ReorgHandle); ok {
+			hash, _, err = reorgHandle.GetBlockHash(end)
+			if err != nil {
+				logs.Error("GetBlockHash %d err: %v", end, err)
+				return false
+			}
+		}
+	}
+	mismatch := quorumDiff(quorumDigest(wrapperTransactions, srcTransactions, polyTransactions, dstTransactions),
+		quorumDigest(quorumWrapperTransactions, quorumSrcTransactions, quorumPolyTransactions, quorumDstTransactions))
+	if hash != "" && quorumHash != "" && hash != quorumHash {
+		mismatch = fmt.Sprintf("block %d hash %s, quorum hash %s", end, hash, quorumHash)
+	}
+	key := fmt.Sprintf("chain_quorum_%d", chainId)
+	if mismatch != "" {
+		logs.Error("ListenChain - chain %s quorum nodes disagree on blocks %d-%d: %s", ccl.handle.GetChainName(), start, end, mismatch)
+		metrics.QuorumMismatched(chainId)
+		alert.Fire(&alert.Alert{
+			Key:      key,
+			Severity: alert.SEVERITY_CRITICAL,
+			Source:   "crosschainlisten",
+			Title:    fmt.Sprintf("chain %s nodes disagree on blocks %d-%d", ccl.handle.GetChainName(), start, end),
+			Message:  mismatch,
+		})
+		return false
+	}
+	alert.Resolve(key, fmt.Sprintf("chain %s nodes agree on blocks %d-%d", ccl.handle.GetChainName(), start, end))
+	return true
+}
+
+// quorumDigest lists what is stored of the events, the fees and times which nodes may report differently
+// are left out.
+func quorumDigest(wrapperTransactions []*models.WrapperTransaction, srcTransactions []*models.SrcTransaction, polyTransactions []*models.PolyTransaction, dstTransactions []*models.DstTransaction) []string {
+	digest := make([]string, 0)
+	for _, wrapper := range wrapperTransactions {
+		digest = append(digest, fmt.Sprintf("wrapper %s user %s to chain %d user %s fee %s %s", wrapper.Hash, wrapper.User,
+			wrapper.DstChainId, wrapper.DstUser, wrapper.FeeTokenHash, quorumAmount(wrapper.FeeAmount)))
+	}
+	for _, src := range srcTransactions {
+		item := fmt.Sprintf("src %s key %s contract %s to chain %d", src.Hash, src.Key, src.Contract, src.DstChainId)
+		if src.SrcTransfer != nil {
+			item += fmt.Sprintf(" transfer %s %s to %s %s", src.SrcTransfer.Asset, quorumAmount(src.SrcTransfer.Amount),
+				src.SrcTransfer.DstAsset, src.SrcTransfer.DstUser)
+		}
+		digest = append(digest, item)
+	}
+	for _, poly := range polyTransactions {
+		digest = append(digest, fmt.Sprintf("poly %s src %s from chain %d to chain %d", poly.Hash, poly.SrcHash, poly.SrcChainId, poly.DstChainId))
+	}
+	for _, dst := range dstTransactions {
+		item := fmt.Sprintf("dst %s poly %s from chain %d contract %s", dst.Hash, dst.PolyHash, dst.SrcChainId, dst.Contract)
+		if dst.DstTransfer != nil {
+			item += fmt.Sprintf(" transfer %s %s to %s", dst.DstTransfer.Asset, quorumAmount(dst.DstTransfer.Amount), dst.DstTransfer.To)
+		}
+		digest = append(digest, item)
+	}
+	return digest
+}
+
+func quorumAmount(amount *models.BigInt) string {
+	if amount == nil {
+		return ""
+	}
+	return amount.String()
+}
+
+// quorumDiff describes the first difference of the digests, it is empty when they are the same
+func quorumDiff(digest []string, quorumDigest []string) string {
+	for i := 0; i < len(digest) || i < len(quorumDigest); i++ {
+		if i >= len(digest) {
+			return fmt.Sprintf("missing %s", quorumDigest[i])
+		}
+		if i >= len(quorumDigest) {
+			return fmt.Sprintf("unexpected %s", digest[i])
+		}
+		if digest[i] != quorumDigest[i] {
+			return fmt.Sprintf("%s, quorum %s", digest[i], quorumDigest[i])
+		}
+	}
+	return ""
+}
@@ -21,24 +21,25 @@ import (
 	"context"
 	"fmt"
 	"math/big"
-	"sync"
+	"strings"
 	"time"
 
+	"poly-bridge/admin"
 	"poly-bridge/basedef"
 	"poly-bridge/common"
 	"poly-bridge/conf"
 	"poly-bridge/crosschaindao/bridgedao"
 	"poly-bridge/models"
+	"poly-bridge/supervisor"
 
 	"github.com/astaxie/beego/logs"
 )
 
 type Stats struct {
-	context.Context
-	cancel context.CancelFunc
-	cfg    *conf.StatsConfig
-	dao    *bridgedao.BridgeDao
-	wg     sync.WaitGroup
+	admin.Switch
+	cfg        *conf.StatsConfig
+	dao        *bridgedao.BridgeDao
+	supervisor *supervisor.Supervisor
 }
 
 var ccs *Stats
@@ -53,44 +54,52 @@ func StartCrossChainStats(server string, cfg *conf.StatsConfig, dbCfg *conf.DBCo
 	}
 
 	dao := bridgedao.NewBridgeDao(dbCfg, false)
-	ctx, cancel := context.WithCancel(context.Background())
-	ccs = &Stats{dao: dao, cfg: cfg, Context: ctx, cancel: cancel}
+	ccs = &Stats{dao: dao, cfg: cfg, supervisor: supervisor.NewSupervisor("stats")}
 	ccs.Start()
+	admin.Register("stats", ccs)
 }
 
 // Stop
 func StopCrossChainStats() {
 	if ccs != nil {
+		admin.Unregister("stats")
 		ccs.Stop()
+		ccs = nil
 	}
 }
 
-func (this *Stats) run(interval int64, f func() error) {
-	this.wg.Add(1)
+func (this *Stats) run(ctx context.Context, interval int64, f func() error) error {
 	ticker := time.NewTicker(time.Second * time.Duration(interval))
+	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
+			if this.Paused() {
+				continue
+			}
 			err := f()
+			this.Ran(err)
 			if err != nil {
 				logs.Error("stats run error%s", err)
 			}
-		case <-this.Done():
-			break
+		case <-ctx.Done():
+			return nil
 		}
 	}
-	this.wg.Done()
 }
 
 func (this *Stats) Start() {
-	go this.run(this.cfg.TokenBasicStatsInterval, this.computeStats)
-	go this.run(this.cfg.TokenStatsInterval, this.computeTokensStats)
+	this.supervisor.Go(func(ctx context.Context) error {
+		return this.run(ctx, this.cfg.TokenBasicStatsInterval, this.computeStats)
+	})
+	this.supervisor.Go(func(ctx context.Context) error {
+		return this.run(ctx, this.cfg.TokenStatsInterval, this.computeTokensStats)
+	})
 }
 
 func (this *Stats) Stop() {
 	logs.Info("Stopping stats server")
-	this.cancel()
-	this.wg.Wait()
+	this.supervisor.Stop()
 }
 
 func (this *Stats) computeStats() (err error) {
@@ -140,15 +149,25 @@ func (this *Stats) computeTokensStats() (err error) {
 	if err != nil {
 		return fmt.Errorf("Failed to fetch token basic list %w", err)
 	}
+	now := time.Now().Unix()
 	for _, t := range tokens {
-		amount, err := common.GetBalance(t.ChainId, t.Hash)
-		if err != nil || amount == nil {
-			logs.Error("Failed to fetch token available amount for token %s %v %s", t.Hash, t.ChainId, err)
-			continue
-		}
-		err = this.dao.UpdateTokenAvailableAmount(t.Hash, t.ChainId, amount)
-		if err != nil {
-			logs.Error("Failed to update token available amount for token %s %v %s", t.Hash, t.ChainId, err)
+		for _, proxy := range common.GetProxyContracts(t.ChainId) {
+			amount, err := common.GetProxyBalance(t.ChainId, t.Hash, proxy.Address)
+			if err != nil || amount == nil {
+				logs.Error("Failed to fetch token available amount for token %s %v proxy %s %s", t.Hash, t.ChainId, proxy.Address, err)
+				continue
+			}
+			err = this.dao.UpdateTokenProxyAmount(t.Hash, t.ChainId, strings.ToLower(proxy.Address), proxy.Label, amount, now)
+			if err != nil {
+				logs.Error("Failed to update token proxy amount for token %s %v proxy %s %s", t.Hash, t.ChainId, proxy.Address, err)
+			}
+			if proxy.Label != basedef.PROXY_LABEL_POLY {
+				continue
+			}
+			err = this.dao.UpdateTokenAvailableAmount(t.Hash, t.ChainId, amount)
+			if err != nil {
+				logs.Error("Failed to update token available amount for token %s %v %s", t.Hash, t.ChainId, err)
+			}
 		}
 	}
 	return
@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"poly-bridge/common"
 	"poly-bridge/conf"
+	"poly-bridge/metrics"
 	_ "poly-bridge/routers"
 
 	"github.com/astaxie/beego"
@@ -58,5 +59,7 @@ func main() {
 		AllowHeaders:     []string{"Origin", "Authorization", "Access-Control-Allow-Origin", "Access-Control-Allow-Headers", "Content-Type"},
 		ExposeHeaders:    []string{"Content-Length", "Access-Control-Allow-Origin", "Access-Control-Allow-Headers", "Content-Type"},
 		AllowCredentials: true}))
+	metrics.InstrumentBeego("bridge_http")
+	metrics.StartMetrics(&conf.MetricsConfig{Port: beego.AppConfig.DefaultInt("metricsport", 0)})
 	beego.Run()
 }
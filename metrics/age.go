@@ -0,0 +1,58 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ageVec exports the seconds elapsed since the last update of each label set, computed at scrape time.
+type ageVec struct {
+	desc    *prometheus.Desc
+	updates map[string]time.Time
+	lock    sync.Mutex
+}
+
+func newAgeVec(desc *prometheus.Desc) *ageVec {
+	return &ageVec{
+		desc:    desc,
+		updates: make(map[string]time.Time),
+	}
+}
+
+func (age *ageVec) Set(updateTime time.Time, labelValues ...string) {
+	age.lock.Lock()
+	defer age.lock.Unlock()
+	age.updates[strings.Join(labelValues, "\xff")] = updateTime
+}
+
+func (age *ageVec) Describe(ch chan<- *prometheus.Desc) {
+	ch <- age.desc
+}
+
+func (age *ageVec) Collect(ch chan<- prometheus.Metric) {
+	age.lock.Lock()
+	defer age.lock.Unlock()
+	now := time.Now()
+	for labels, updateTime := range age.updates {
+		ch <- prometheus.MustNewConstMetric(age.desc, prometheus.GaugeValue, now.Sub(updateTime).Seconds(), strings.Split(labels, "\xff")...)
+	}
+}
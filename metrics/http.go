@@ -0,0 +1,57 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package metrics
+
+import (
+	"fmt"
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/context"
+	"strconv"
+	"time"
+)
+
+const requestStartKey = "metrics_request_start"
+
+// InstrumentBeego counts the requests and latencies of every route of the beego app. The metrics are not
+// served on the public port of the app but by StartMetrics.
+func InstrumentBeego(server string) {
+	beego.InsertFilter("*", beego.BeforeStatic, func(ctx *context.Context) {
+		ctx.Input.SetData(requestStartKey, time.Now())
+	}, false)
+	beego.InsertFilter("*", beego.FinishRouter, func(ctx *context.Context) {
+		observeRequest(server, ctx)
+	}, false)
+}
+
+func observeRequest(server string, ctx *context.Context) {
+	start, ok := ctx.Input.GetData(requestStartKey).(time.Time)
+	if !ok {
+		return
+	}
+	route := "unknown"
+	if pattern := ctx.Input.GetData("RouterPattern"); pattern != nil {
+		route = fmt.Sprintf("%v", pattern)
+	}
+	code := ctx.ResponseWriter.Status
+	if code == 0 {
+		code = 200
+	}
+	method := ctx.Input.Method()
+	httpRequests.WithLabelValues(server, route, method, strconv.Itoa(code)).Inc()
+	httpRequestDuration.WithLabelValues(server, route, method).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,304 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package metrics
+
+import (
+	"fmt"
+	"github.com/astaxie/beego/logs"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"poly-bridge/basedef"
+	"poly-bridge/conf"
+	"strconv"
+	"time"
+)
+
+const namespace = "poly_bridge"
+
+var (
+	listenHeight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "chain_listen_height",
+		Help:      "Latest block height handled by the cross chain listen.",
+	}, []string{"chain"})
+	nodeHeight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "chain_node_height",
+		Help:      "Latest block height of the selected chain node.",
+	}, []string{"chain"})
+	extendNodeHeight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "chain_extend_node_height",
+		Help:      "Latest block height reported by the extend nodes.",
+	}, []string{"chain"})
+	listenLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "chain_listen_lag_blocks",
+		Help:      "Blocks between the chain node height and the listen height.",
+	}, []string{"chain"})
+	handleBlockDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "handle_block_duration_seconds",
+		Help:      "Time spent by the chain handle on a block or a range of blocks.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"chain", "mode"})
+	handleBlockErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "handle_block_errors_total",
+		Help:      "Failures of the chain handle on a block or a range of blocks.",
+	}, []string{"chain", "mode"})
+	updateEventsDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "update_events_duration_seconds",
+		Help:      "Time spent writing the events of a block or a range of blocks to the database.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"chain"})
+	updateEventsErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "update_events_errors_total",
+		Help:      "Failures writing the events to the database.",
+	}, []string{"chain"})
+	priceQueries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "coin_price_queries_total",
+		Help:      "Coin price queries by market and result.",
+	}, []string{"market", "result"})
+	priceUpdates = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "coin_price_updates_total",
+		Help:      "Coin price updates by token and result.",
+	}, []string{"token", "result"})
+	priceAge = newAgeVec(prometheus.NewDesc(namespace+"_coin_price_age_seconds",
+		"Seconds since the price of the token was last updated.", []string{"token"}, nil))
+	feeUpdates = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "chain_fee_updates_total",
+		Help:      "Chain fee updates by chain and result.",
+	}, []string{"chain", "result"})
+	feeAge = newAgeVec(prometheus.NewDesc(namespace+"_chain_fee_age_seconds",
+		"Seconds since the fee of the chain was last updated.", []string{"chain"}, nil))
+	unfinishedWrapperTransactions = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "unfinished_wrapper_transactions",
+		Help:      "Wrapper transactions which are not finished, by status.",
+	}, []string{"status"})
+	nodeSwitches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "node_switches_total",
+		Help:      "Times the sdk switched to another node of the chain.",
+	}, []string{"chain"})
+	nodeLatency = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_latency_seconds",
+		Help:      "Average latency of the chain node observed by the sdk.",
+	}, []string{"chain", "node"})
+	nodeErrorRate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_error_rate",
+		Help:      "Average error rate of the requests to the chain node.",
+	}, []string{"chain", "node"})
+	nodeCircuitOpen = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_circuit_open",
+		Help:      "Whether the circuit breaker of the chain node is open.",
+	}, []string{"chain", "node"})
+	quorumMismatches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "quorum_mismatches_total",
+		Help:      "Times the quorum nodes of the chain disagreed with the listen nodes.",
+	}, []string{"chain"})
+	rpcCacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_cache_requests_total",
+		Help:      "Lookups of the rpc cache of the chain sdk by kind of data and whether they hit.",
+	}, []string{"chain", "kind", "result"})
+	rpcBatches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_batch_calls_total",
+		Help:      "Json rpc calls sent to the chain nodes in batch requests.",
+	}, []string{"chain"})
+	serviceRestarts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "service_restarts_total",
+		Help:      "Times the loop of the service stopped unexpectedly and was restarted.",
+	}, []string{"service"})
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by server, route, method and status code.",
+	}, []string{"server", "route", "method", "code"})
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latencies by server, route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"server", "route", "method"})
+)
+
+var statusNames = map[uint64]string{
+	basedef.STATE_PENDDING:         "pending",
+	basedef.STATE_SOURCE_DONE:      "source_done",
+	basedef.STATE_SOURCE_CONFIRMED: "source_confirmed",
+	basedef.STATE_POLY_CONFIRMED:   "poly_confirmed",
+	basedef.STATE_DESTINATION_DONE: "destination_done",
+}
+
+func init() {
+	prometheus.MustRegister(listenHeight, nodeHeight, extendNodeHeight, listenLag,
+		handleBlockDuration, handleBlockErrors, updateEventsDuration, updateEventsErrors,
+		priceQueries, priceUpdates, priceAge, feeUpdates, feeAge,
+		unfinishedWrapperTransactions, nodeSwitches, nodeLatency, nodeErrorRate, nodeCircuitOpen, quorumMismatches, rpcCacheRequests, rpcBatches, serviceRestarts, httpRequests, httpRequestDuration)
+}
+
+var server *http.Server
+
+// StartMetrics serves the metrics of the process on the configured port, it does nothing without a port.
+func StartMetrics(cfg *conf.MetricsConfig) {
+	if cfg == nil || cfg.Port == 0 {
+		return
+	}
+	path := cfg.Path
+	if path == "" {
+		path = "/metrics"
+	}
+	mux := http.NewServeMux()
+	mux.Handle(path, promhttp.Handler())
+	server = &http.Server{Addr: fmt.Sprintf(":%d", cfg.Port), Handler: mux}
+	go func(server *http.Server) {
+		logs.Info("start metrics server at %s%s", server.Addr, path)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logs.Error("metrics server err: %v", err)
+		}
+	}(server)
+}
+
+func StopMetrics() {
+	if server != nil {
+		server.Close()
+		server = nil
+		logs.Info("stop metrics server.")
+	}
+}
+
+func chainLabel(chainId uint64) string {
+	return strconv.FormatUint(chainId, 10)
+}
+
+func resultLabel(ok bool) string {
+	if ok {
+		return "success"
+	}
+	return "failure"
+}
+
+func SetListenHeight(chainId uint64, height uint64, latestHeight uint64) {
+	chain := chainLabel(chainId)
+	listenHeight.WithLabelValues(chain).Set(float64(height))
+	nodeHeight.WithLabelValues(chain).Set(float64(latestHeight))
+	lag := float64(0)
+	if latestHeight > height {
+		lag = float64(latestHeight - height)
+	}
+	listenLag.WithLabelValues(chain).Set(lag)
+}
+
+func SetExtendNodeHeight(chainId uint64, height uint64) {
+	extendNodeHeight.WithLabelValues(chainLabel(chainId)).Set(float64(height))
+}
+
+// ObserveHandleBlock records a HandleNewBlock call, mode is "block" for a single block and "range" for HandleNewBlocks.
+func ObserveHandleBlock(chainId uint64, mode string, start time.Time, err error) {
+	chain := chainLabel(chainId)
+	handleBlockDuration.WithLabelValues(chain, mode).Observe(time.Since(start).Seconds())
+	if err != nil {
+		handleBlockErrors.WithLabelValues(chain, mode).Inc()
+	}
+}
+
+func ObserveUpdateEvents(chainId uint64, start time.Time, err error) {
+	chain := chainLabel(chainId)
+	updateEventsDuration.WithLabelValues(chain).Observe(time.Since(start).Seconds())
+	if err != nil {
+		updateEventsErrors.WithLabelValues(chain).Inc()
+	}
+}
+
+func ObservePriceQuery(market string, err error) {
+	priceQueries.WithLabelValues(market, resultLabel(err == nil)).Inc()
+}
+
+// SetPriceUpdated records whether the price of the token was updated, updateTime is the time of its current price.
+func SetPriceUpdated(token string, updated bool, updateTime int64) {
+	priceUpdates.WithLabelValues(token, resultLabel(updated)).Inc()
+	if updateTime > 0 {
+		priceAge.Set(time.Unix(updateTime, 0), token)
+	}
+}
+
+// SetFeeUpdated records whether the fee of the chain was updated, updateTime is the time of its current fee.
+func SetFeeUpdated(chainId uint64, updated bool, updateTime int64) {
+	chain := chainLabel(chainId)
+	feeUpdates.WithLabelValues(chain, resultLabel(updated)).Inc()
+	if updateTime > 0 {
+		feeAge.Set(time.Unix(updateTime, 0), chain)
+	}
+}
+
+// SetUnfinishedWrapperTransactions replaces the counts of the unfinished wrapper transactions by status.
+func SetUnfinishedWrapperTransactions(counters map[uint64]int64) {
+	for status, name := range statusNames {
+		unfinishedWrapperTransactions.WithLabelValues(name).Set(float64(counters[status]))
+	}
+}
+
+func NodeSwitched(chainId uint64) {
+	nodeSwitches.WithLabelValues(chainLabel(chainId)).Inc()
+}
+
+// SetNodeStats sets the stats of the node, the node is the host of its url so that no keys in the path are exposed
+func SetNodeStats(chainId uint64, node string, latency time.Duration, errorRate float64, open bool) {
+	chain := chainLabel(chainId)
+	nodeLatency.WithLabelValues(chain, node).Set(latency.Seconds())
+	nodeErrorRate.WithLabelValues(chain, node).Set(errorRate)
+	circuitOpen := float64(0)
+	if open {
+		circuitOpen = 1
+	}
+	nodeCircuitOpen.WithLabelValues(chain, node).Set(circuitOpen)
+}
+
+func QuorumMismatched(chainId uint64) {
+	quorumMismatches.WithLabelValues(chainLabel(chainId)).Inc()
+}
+
+func RpcCacheRequested(chainId uint64, kind string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	rpcCacheRequests.WithLabelValues(chainLabel(chainId), kind, result).Inc()
+}
+
+func RpcBatchCalled(chainId uint64, calls int) {
+	rpcBatches.WithLabelValues(chainLabel(chainId)).Add(float64(calls))
+}
+
+func ServiceRestarted(service string) {
+	serviceRestarts.WithLabelValues(service).Inc()
+}
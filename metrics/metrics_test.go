@@ -0,0 +1,58 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"poly-bridge/basedef"
+	"testing"
+	"time"
+)
+
+func TestSetListenHeight(t *testing.T) {
+	SetListenHeight(2, 100, 120)
+	assert.Equal(t, float64(100), testutil.ToFloat64(listenHeight.WithLabelValues("2")))
+	assert.Equal(t, float64(120), testutil.ToFloat64(nodeHeight.WithLabelValues("2")))
+	assert.Equal(t, float64(20), testutil.ToFloat64(listenLag.WithLabelValues("2")))
+	SetListenHeight(2, 130, 120)
+	assert.Equal(t, float64(0), testutil.ToFloat64(listenLag.WithLabelValues("2")))
+}
+
+func TestObserveHandleBlock(t *testing.T) {
+	ObserveHandleBlock(6, "range", time.Now(), nil)
+	ObserveHandleBlock(6, "range", time.Now(), assert.AnError)
+	assert.Equal(t, float64(1), testutil.ToFloat64(handleBlockErrors.WithLabelValues("6", "range")))
+}
+
+func TestSetUnfinishedWrapperTransactions(t *testing.T) {
+	SetUnfinishedWrapperTransactions(map[uint64]int64{basedef.STATE_SOURCE_DONE: 3, basedef.STATE_POLY_CONFIRMED: 1})
+	assert.Equal(t, float64(3), testutil.ToFloat64(unfinishedWrapperTransactions.WithLabelValues("source_done")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(unfinishedWrapperTransactions.WithLabelValues("poly_confirmed")))
+	SetUnfinishedWrapperTransactions(map[uint64]int64{basedef.STATE_POLY_CONFIRMED: 2})
+	assert.Equal(t, float64(0), testutil.ToFloat64(unfinishedWrapperTransactions.WithLabelValues("source_done")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(unfinishedWrapperTransactions.WithLabelValues("poly_confirmed")))
+}
+
+func TestAgeVec(t *testing.T) {
+	age := newAgeVec(prometheus.NewDesc("test_age_seconds", "test", []string{"token"}, nil))
+	age.Set(time.Now().Add(-time.Minute), "ETH")
+	value := testutil.ToFloat64(age)
+	assert.True(t, value >= 60 && value < 70)
+}
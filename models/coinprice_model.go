@@ -68,17 +68,78 @@ type ChainFee struct {
 	Time           int64       `gorm:"type:bigint(20);not null"`
 }
 
+// ChainFeeHistory is a fee of the chain saved by every fee update, with the price of the fee token at that moment
+type ChainFeeHistory struct {
+	ChainId        uint64      `gorm:"primaryKey;type:bigint(20);not null"`
+	Time           int64       `gorm:"primaryKey;type:bigint(20);not null"`
+	TokenBasicName string      `gorm:"size:64;not null"`
+	MaxFee         *BigInt     `gorm:"type:varchar(64);not null"`
+	MinFee         *BigInt     `gorm:"type:varchar(64);not null"`
+	ProxyFee       *BigInt     `gorm:"type:varchar(64);not null"`
+	TokenPrice     int64       `gorm:"type:bigint(20);not null"`
+	TokenBasic     *TokenBasic `gorm:"foreignKey:TokenBasicName;references:Name"`
+}
+
+// AssetGasLimit is the gas limit of the transfers of the asset on the destination chain, which is learned from
+// the indexed destination transactions or estimated by simulating the transfer
+type AssetGasLimit struct {
+	ChainId       uint64 `gorm:"primaryKey;type:bigint(20);not null"`
+	Hash          string `gorm:"primaryKey;size:66;not null"`
+	GasLimit      uint64 `gorm:"type:bigint(20);not null"`
+	ChainGasLimit uint64 `gorm:"type:bigint(20);not null"` // the static gas limit of the chain the fees of the chain are computed with
+	Samples       uint64 `gorm:"type:bigint(20);not null"` // the number of transactions learned, 0 if estimated
+	Time          int64  `gorm:"type:bigint(20);not null"`
+}
+
+// ScaleFee scales the fee of the chain by the gas limit of the asset
+func (limit *AssetGasLimit) ScaleFee(fee *BigInt) *BigInt {
+	if fee == nil || limit.ChainGasLimit == 0 || limit.GasLimit == 0 {
+		return fee
+	}
+	scaled := new(big.Int).Mul(&fee.Int, new(big.Int).SetUint64(limit.GasLimit))
+	scaled.Div(scaled, new(big.Int).SetUint64(limit.ChainGasLimit))
+	return NewBigInt(scaled)
+}
+
+// AssetGasLimitHistory is a snapshot of the gas limit of the asset, saved on every update of the gas limits so
+// that the fees quoted for the asset in the past can be reconstructed
+type AssetGasLimitHistory struct {
+	ChainId       uint64 `gorm:"primaryKey;type:bigint(20);not null"`
+	Hash          string `gorm:"primaryKey;size:66;not null"`
+	Time          int64  `gorm:"primaryKey;type:bigint(20);not null"`
+	GasLimit      uint64 `gorm:"type:bigint(20);not null"`
+	ChainGasLimit uint64 `gorm:"type:bigint(20);not null"`
+	Samples       uint64 `gorm:"type:bigint(20);not null"`
+}
+
+// ScaleFee scales the fee of the chain by the gas limit of the asset at the time of the snapshot
+func (history *AssetGasLimitHistory) ScaleFee(fee *BigInt) *BigInt {
+	limit := &AssetGasLimit{GasLimit: history.GasLimit, ChainGasLimit: history.ChainGasLimit}
+	return limit.ScaleFee(fee)
+}
+
 type Token struct {
-	Hash            string      `gorm:"primaryKey;size:66;not null"`
-	ChainId         uint64      `gorm:"primaryKey;type:bigint(20);not null"`
-	Name            string      `gorm:"size:64;not null"`
-	Precision       uint64      `gorm:"type:bigint(20);not null"`
-	TokenBasicName  string      `gorm:"size:64;not null"`
-	Property        int64       `gorm:"type:bigint(20);not null"`
-	Standard        uint8       `gorm:"type:int(8);not null"`
-	AvailableAmount *BigInt     `gorm:"type:varchar(64)"`
-	TokenBasic      *TokenBasic `gorm:"foreignKey:TokenBasicName;references:Name"`
-	TokenMaps       []*TokenMap `gorm:"foreignKey:SrcTokenHash,SrcChainId;references:Hash,ChainId"`
+	Hash            string              `gorm:"primaryKey;size:66;not null"`
+	ChainId         uint64              `gorm:"primaryKey;type:bigint(20);not null"`
+	Name            string              `gorm:"size:64;not null"`
+	Precision       uint64              `gorm:"type:bigint(20);not null"`
+	TokenBasicName  string              `gorm:"size:64;not null"`
+	Property        int64               `gorm:"type:bigint(20);not null"`
+	Standard        uint8               `gorm:"type:int(8);not null"`
+	AvailableAmount *BigInt             `gorm:"type:varchar(64)"`
+	TokenBasic      *TokenBasic         `gorm:"foreignKey:TokenBasicName;references:Name"`
+	TokenMaps       []*TokenMap         `gorm:"foreignKey:SrcTokenHash,SrcChainId;references:Hash,ChainId"`
+	ProxyAmounts    []*TokenProxyAmount `gorm:"foreignKey:Hash,ChainId;references:Hash,ChainId"`
+}
+
+// TokenProxyAmount is the available amount of a token in one of the lock proxies of its chain
+type TokenProxyAmount struct {
+	Hash            string  `gorm:"primaryKey;size:66;not null"`
+	ChainId         uint64  `gorm:"primaryKey;type:bigint(20);not null"`
+	Proxy           string  `gorm:"primaryKey;size:66;not null"`
+	Label           string  `gorm:"size:64;not null"`
+	AvailableAmount *BigInt `gorm:"type:varchar(64)"`
+	Time            int64   `gorm:"type:bigint(20);not null"`
 }
 
 type TokenMap struct {
@@ -113,6 +174,7 @@ type CheckFee struct {
 	PayState    int
 	Amount      *big.Float
 	MinProxyFee *big.Float
+	QuoteId     string // the quote the fee is paid by
 }
 
 type TimeStatistic struct {
@@ -17,20 +17,36 @@
 
 package models
 
+import (
+	"fmt"
+	"strings"
+)
+
 type Chain struct {
 	ChainId             *uint64 `gorm:"primaryKey;type:bigint(20);not null"`
 	Height              uint64  `gorm:"type:bigint(20);not null"`
 	HeightSwap          uint64  `gorm:"type:bigint(20);not null"`
 	BackwardBlockNumber uint64  `gorm:"type:bigint(20);not null"`
+	BlockHeight         uint64  `gorm:"type:bigint(20);not null;default:0"`   // height of BlockHash
+	BlockHash           string  `gorm:"type:varchar(66);not null;default:''"` // hash of the last handled block whose hash is tracked
 }
 
+// the type of a cross chain message, transfers are sent by the lock proxies and calls by other contracts through the eccm
+const (
+	MessageTypeTransfer uint8 = iota
+	MessageTypeCall
+)
+
 type SrcTransaction struct {
 	Hash        string       `gorm:"primaryKey;size:66;not null"`
 	ChainId     uint64       `gorm:"type:bigint(20);not null"`
 	Standard    uint8        `gorm:"type:int(8);not null"`
+	MessageType uint8        `gorm:"type:int(8);not null"`
 	State       uint64       `gorm:"type:bigint(20);not null"`
 	Time        uint64       `gorm:"type:bigint(20);not null"`
 	Fee         *BigInt      `gorm:"type:varchar(64);not null"`
+	GasUsed     uint64       `gorm:"type:bigint(20);not null"`  // units of gas used, Neo charges the GAS consumed directly so a unit is 1 fixed8 GAS there
+	GasPrice    *BigInt      `gorm:"type:varchar(64);not null"` // price of a unit of gas, GasUsed * GasPrice is the execution cost in the smallest unit of the native coin
 	Height      uint64       `gorm:"type:bigint(20);not null"`
 	User        string       `gorm:"type:varchar(66);not null"`
 	DstChainId  uint64       `gorm:"type:bigint(20);not null"`
@@ -38,6 +54,7 @@ type SrcTransaction struct {
 	Key         string       `gorm:"type:varchar(8192);not null"`
 	Param       string       `gorm:"type:varchar(8192);not null"`
 	SrcTransfer *SrcTransfer `gorm:"foreignKey:TxHash;references:Hash"`
+	MakeTxParam *MakeTxParam `gorm:"foreignKey:TxHash;references:Hash"`
 }
 
 type SrcTransfer struct {
@@ -52,19 +69,38 @@ type SrcTransfer struct {
 	DstChainId uint64  `gorm:"type:bigint(20);not null"`
 	DstAsset   string  `gorm:"type:varchar(66);not null"`
 	DstUser    string  `gorm:"type:varchar(66);not null"`
+	Proxy      string  `gorm:"type:varchar(66);not null"`
+}
+
+// MakeTxParam is the cross chain call of a source transaction decoded from its param
+type MakeTxParam struct {
+	TxHash       string  `gorm:"primaryKey;size:66;not null"`
+	TxId         string  `gorm:"type:varchar(66);not null"`
+	CrossChainId string  `gorm:"type:varchar(66);not null"`
+	FromContract string  `gorm:"type:varchar(66);not null"`
+	ToChainId    uint64  `gorm:"type:bigint(20);not null"`
+	ToContract   string  `gorm:"type:varchar(66);not null"`
+	Method       string  `gorm:"type:varchar(64);not null"`
+	Args         string  `gorm:"type:varchar(8192);not null"`
+	ToAsset      string  `gorm:"type:varchar(66);not null"`
+	ToAddress    string  `gorm:"type:varchar(66);not null"`
+	Amount       *BigInt `gorm:"type:varchar(64)"`
+	TokenId      *BigInt `gorm:"type:varchar(80)"`
 }
 
+
 type PolyTransaction struct {
-	Hash       string  `gorm:"primaryKey;size:66;not null"`
-	ChainId    uint64  `gorm:"type:bigint(20);not null"`
-	State      uint64  `gorm:"type:bigint(20);not null"`
-	Time       uint64  `gorm:"type:bigint(20);not null"`
-	Fee        *BigInt `gorm:"type:varchar(64);not null"`
-	Height     uint64  `gorm:"type:bigint(20);not null"`
-	SrcChainId uint64  `gorm:"type:bigint(20);not null"`
-	SrcHash    string  `gorm:"index;size:66;not null"`
-	DstChainId uint64  `gorm:"type:bigint(20);not null"`
-	Key        string  `gorm:"type:varchar(8192);not null"`
+	Hash                  string                  `gorm:"primaryKey;size:66;not null"`
+	ChainId               uint64                  `gorm:"type:bigint(20);not null"`
+	State                 uint64                  `gorm:"type:bigint(20);not null"`
+	Time                  uint64                  `gorm:"type:bigint(20);not null"`
+	Fee                   *BigInt                 `gorm:"type:varchar(64);not null"`
+	Height                uint64                  `gorm:"type:bigint(20);not null"`
+	SrcChainId            uint64                  `gorm:"type:bigint(20);not null"`
+	SrcHash               string                  `gorm:"index;size:66;not null"`
+	DstChainId            uint64                  `gorm:"type:bigint(20);not null"`
+	Key                   string                  `gorm:"type:varchar(8192);not null"`
+	FailedDstTransactions []*FailedDstTransaction `gorm:"foreignKey:PolyHash;references:Hash;constraint:-"`
 }
 
 type PolySrcRelation struct {
@@ -78,9 +114,12 @@ type DstTransaction struct {
 	Hash        string       `gorm:"primaryKey;size:66;not null"`
 	ChainId     uint64       `gorm:"type:bigint(20);not null"`
 	Standard    uint8        `gorm:"type:int(8);not null"`
+	MessageType uint8        `gorm:"type:int(8);not null"`
 	State       uint64       `gorm:"type:bigint(20);not null"`
 	Time        uint64       `gorm:"type:bigint(20);not null"`
 	Fee         *BigInt      `gorm:"type:varchar(64);not null"`
+	GasUsed     uint64       `gorm:"type:bigint(20);not null"`  // units of gas used, Neo charges the GAS consumed directly so a unit is 1 fixed8 GAS there
+	GasPrice    *BigInt      `gorm:"type:varchar(64);not null"` // price of a unit of gas, GasUsed * GasPrice is the execution cost in the smallest unit of the native coin
 	Height      uint64       `gorm:"type:bigint(20);not null"`
 	SrcChainId  uint64       `gorm:"type:bigint(20);not null"`
 	Contract    string       `gorm:"type:varchar(66);not null"`
@@ -97,6 +136,23 @@ type DstTransfer struct {
 	From     string  `gorm:"type:varchar(66);not null"`
 	To       string  `gorm:"type:varchar(66);not null"`
 	Amount   *BigInt `gorm:"type:varchar(64);not null"`
+	Proxy    string  `gorm:"type:varchar(66);not null"`
+}
+
+// FailedDstTransaction is a reverted attempt to execute a poly transaction on the destination chain,
+// it may be found before its poly transaction so the relation to PolyTransaction has no constraint
+type FailedDstTransaction struct {
+	Hash       string  `gorm:"primaryKey;size:66;not null"`
+	ChainId    uint64  `gorm:"type:bigint(20);not null"`
+	Time       uint64  `gorm:"type:bigint(20);not null"`
+	Height     uint64  `gorm:"type:bigint(20);not null"`
+	SrcChainId uint64  `gorm:"type:bigint(20);not null"`
+	PolyHash   string  `gorm:"index;size:66;not null"`
+	Submitter  string  `gorm:"type:varchar(66);not null"`
+	Reason     string  `gorm:"type:varchar(1024);not null"`
+	GasUsed    uint64  `gorm:"type:bigint(20);not null"`
+	GasPrice   *BigInt `gorm:"type:varchar(64);not null"`
+	Fee        *BigInt `gorm:"type:varchar(64);not null"`
 }
 
 type WrapperTransaction struct {
@@ -114,6 +170,42 @@ type WrapperTransaction struct {
 	Status       uint64  `gorm:"type:bigint(20);not null"`
 }
 
+// the wrapper transactions listed by WrapperTransactionHashes at most
+const _wrapper_hashes_max = 20
+
+// WrapperTransactionHashes lists the hashes of the wrapper transactions one per line for the alert messages
+func WrapperTransactionHashes(wrapperTransactions []*WrapperTransaction) string {
+	hashes := make([]string, 0)
+	for i, wrapperTransaction := range wrapperTransactions {
+		if i >= _wrapper_hashes_max {
+			hashes = append(hashes, fmt.Sprintf("... and %d more", len(wrapperTransactions)-i))
+			break
+		}
+		hashes = append(hashes, wrapperTransaction.Hash)
+	}
+	return strings.Join(hashes, "\n")
+}
+
+// IsSpeedUp tells the wrapper transaction is a PolyWrapperSpeedUp, which adds the fee of a locked transaction and
+// carries no destination
+func (wrapperTransaction *WrapperTransaction) IsSpeedUp() bool {
+	return wrapperTransaction.DstChainId == 0
+}
+
+// SplitWrapperSpeedUps separates the speed ups from the locks of the wrapper transactions, a speed up only updates
+// the fee of the stored lock
+func SplitWrapperSpeedUps(wrapperTransactions []*WrapperTransaction) ([]*WrapperTransaction, []*WrapperTransaction) {
+	locks, speedUps := make([]*WrapperTransaction, 0), make([]*WrapperTransaction, 0)
+	for _, wrapperTransaction := range wrapperTransactions {
+		if wrapperTransaction.IsSpeedUp() {
+			speedUps = append(speedUps, wrapperTransaction)
+		} else {
+			locks = append(locks, wrapperTransaction)
+		}
+	}
+	return locks, speedUps
+}
+
 type SrcPolyDstRelation struct {
 	SrcHash            string
 	WrapperTransaction *WrapperTransaction `gorm:"foreignKey:SrcHash;references:Hash"`
@@ -128,3 +220,8 @@ type SrcPolyDstRelation struct {
 	Token              *Token          `gorm:"foreignKey:TokenHash,ChainId;references:Hash,ChainId"`
 	FeeToken              *Token          `gorm:"foreignKey:FeeTokenHash,ChainId;references:Hash,ChainId"`
 }
+
+type WrapperStatusCounter struct {
+	Status  uint64
+	Counter int64
+}
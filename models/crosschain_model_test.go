@@ -0,0 +1,39 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package models
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapperTransactionHashes(t *testing.T) {
+	wrapperTransactions := make([]*WrapperTransaction, 0)
+	assert.Equal(t, "", WrapperTransactionHashes(wrapperTransactions))
+	for i := 0; i < 25; i++ {
+		wrapperTransactions = append(wrapperTransactions, &WrapperTransaction{Hash: fmt.Sprintf("%064x", i)})
+	}
+	assert.Equal(t, fmt.Sprintf("%064x\n%064x", 0, 1), WrapperTransactionHashes(wrapperTransactions[:2]))
+	hashes := strings.Split(WrapperTransactionHashes(wrapperTransactions), "\n")
+	assert.Equal(t, 21, len(hashes))
+	assert.Equal(t, fmt.Sprintf("%064x", 19), hashes[19])
+	assert.Equal(t, "... and 5 more", hashes[20])
+}
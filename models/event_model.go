@@ -28,7 +28,9 @@ type ECCMLockEvent struct {
 	Contract string
 	Height   uint64
 	Value    []byte
-	Fee      uint64
+	GasUsed  uint64
+	GasPrice *big.Int
+	Fee      *big.Int
 }
 type ECCMUnlockEvent struct {
 	Method   string
@@ -37,7 +39,9 @@ type ECCMUnlockEvent struct {
 	FChainId uint32
 	Contract string
 	Height   uint64
-	Fee      uint64
+	GasUsed  uint64
+	GasPrice *big.Int
+	Fee      *big.Int
 }
 type ProxyLockEvent struct {
 	Method        string
@@ -48,6 +52,7 @@ type ProxyLockEvent struct {
 	ToAssetHash   string
 	ToAddress     string
 	Amount        *big.Int
+	Proxy         string
 }
 type ProxyUnlockEvent struct {
 	Method      string
@@ -55,4 +60,5 @@ type ProxyUnlockEvent struct {
 	ToAssetHash string
 	ToAddress   string
 	Amount      *big.Int
+	Proxy       string
 }
@@ -0,0 +1,132 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package models
+
+import (
+	"math/big"
+	"poly-bridge/basedef"
+)
+
+// FeePolicy adjusts the fees of the routes it matches, a zero SrcChainId, DstChainId or ServerId and an empty
+// TokenBasicName match any. TokenBasicName is the token the fee is paid with. Only the most specific policy
+// working at the time is applied, the later policy is applied if two are equally specific.
+type FeePolicy struct {
+	Id             int64  `gorm:"primaryKey;autoIncrement"`
+	SrcChainId     uint64 `gorm:"type:bigint(20);not null"`
+	DstChainId     uint64 `gorm:"type:bigint(20);not null"`
+	TokenBasicName string `gorm:"size:64;not null"`
+	ServerId       uint64 `gorm:"type:bigint(20);not null"`
+	Multiplier     int64  `gorm:"type:bigint(20);not null"` // percent of the fee, 0 keeps the fee
+	Discount       int64  `gorm:"type:bigint(20);not null"` // percent off the fee for the integrator
+	MinUsdt        int64  `gorm:"type:bigint(20);not null"` // floor of the fee in usdt with PRICE_PRECISION, 0 without floor
+	MaxUsdt        int64  `gorm:"type:bigint(20);not null"` // cap of the fee in usdt with PRICE_PRECISION, 0 without cap
+	Free           bool   `gorm:"not null"`                 // no fee is charged while the policy works
+	StartTime      int64  `gorm:"type:bigint(20);not null"` // the policy works from the time, 0 since ever
+	EndTime        int64  `gorm:"type:bigint(20);not null"` // the policy works before the time, 0 for ever
+}
+
+func (policy *FeePolicy) Matches(srcChainId uint64, dstChainId uint64, tokenBasicName string, serverId uint64, now int64) bool {
+	if policy.SrcChainId != 0 && policy.SrcChainId != srcChainId {
+		return false
+	}
+	if policy.DstChainId != 0 && policy.DstChainId != dstChainId {
+		return false
+	}
+	if policy.TokenBasicName != "" && policy.TokenBasicName != tokenBasicName {
+		return false
+	}
+	if policy.ServerId != 0 && policy.ServerId != serverId {
+		return false
+	}
+	if policy.StartTime != 0 && now < policy.StartTime {
+		return false
+	}
+	if policy.EndTime != 0 && now >= policy.EndTime {
+		return false
+	}
+	return true
+}
+
+func (policy *FeePolicy) specificity() int {
+	specificity := 0
+	if policy.SrcChainId != 0 {
+		specificity++
+	}
+	if policy.DstChainId != 0 {
+		specificity++
+	}
+	if policy.TokenBasicName != "" {
+		specificity++
+	}
+	if policy.ServerId != 0 {
+		specificity++
+	}
+	return specificity
+}
+
+// Apply applies the policy to the fee in usdt, the fee is kept by a nil policy. The fee quoted and the minimum
+// fee checked are applied with the same policy, the order of them is kept.
+func (policy *FeePolicy) Apply(usdt *big.Float) *big.Float {
+	if policy == nil {
+		return usdt
+	}
+	if policy.Free {
+		return new(big.Float).SetInt64(0)
+	}
+	fee := new(big.Float).Set(usdt)
+	if policy.Multiplier > 0 {
+		fee = new(big.Float).Mul(fee, new(big.Float).SetInt64(policy.Multiplier))
+		fee = new(big.Float).Quo(fee, new(big.Float).SetInt64(100))
+	}
+	if policy.Discount > 0 {
+		discount := policy.Discount
+		if discount > 100 {
+			discount = 100
+		}
+		fee = new(big.Float).Mul(fee, new(big.Float).SetInt64(100-discount))
+		fee = new(big.Float).Quo(fee, new(big.Float).SetInt64(100))
+	}
+	if policy.MinUsdt > 0 {
+		floor := new(big.Float).Quo(new(big.Float).SetInt64(policy.MinUsdt), new(big.Float).SetInt64(basedef.PRICE_PRECISION))
+		if fee.Cmp(floor) < 0 {
+			fee = floor
+		}
+	}
+	if policy.MaxUsdt > 0 {
+		ceiling := new(big.Float).Quo(new(big.Float).SetInt64(policy.MaxUsdt), new(big.Float).SetInt64(basedef.PRICE_PRECISION))
+		if fee.Cmp(ceiling) > 0 {
+			fee = ceiling
+		}
+	}
+	return fee
+}
+
+// MatchFeePolicy returns the policy applied to the route at the time, nil if no policy matches
+func MatchFeePolicy(policies []*FeePolicy, srcChainId uint64, dstChainId uint64, tokenBasicName string, serverId uint64, now int64) *FeePolicy {
+	var matched *FeePolicy
+	for _, policy := range policies {
+		if !policy.Matches(srcChainId, dstChainId, tokenBasicName, serverId, now) {
+			continue
+		}
+		if matched == nil || policy.specificity() > matched.specificity() ||
+			(policy.specificity() == matched.specificity() && policy.Id > matched.Id) {
+			matched = policy
+		}
+	}
+	return matched
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package models
+
+import (
+	"math/big"
+	"poly-bridge/basedef"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func usdt(value float64) *big.Float {
+	return new(big.Float).SetFloat64(value)
+}
+
+func TestMatchFeePolicy(t *testing.T) {
+	policies := []*FeePolicy{
+		{Id: 1, Multiplier: 150},
+		{Id: 2, DstChainId: basedef.ETHEREUM_CROSSCHAIN_ID, Multiplier: 110},
+		{Id: 3, DstChainId: basedef.ETHEREUM_CROSSCHAIN_ID, ServerId: 7, Discount: 50},
+		{Id: 4, SrcChainId: basedef.BSC_CROSSCHAIN_ID, TokenBasicName: "BNB", Free: true, StartTime: 1000, EndTime: 2000},
+		{Id: 5, DstChainId: basedef.ETHEREUM_CROSSCHAIN_ID, Multiplier: 120},
+	}
+	bsc, eth, heco := uint64(basedef.BSC_CROSSCHAIN_ID), uint64(basedef.ETHEREUM_CROSSCHAIN_ID), uint64(basedef.HECO_CROSSCHAIN_ID)
+	assert.Equal(t, int64(1), MatchFeePolicy(policies, bsc, heco, "USDT", 0, 0).Id)
+	// the later of the equally specific policies
+	assert.Equal(t, int64(5), MatchFeePolicy(policies, bsc, eth, "USDT", 0, 0).Id)
+	assert.Equal(t, int64(3), MatchFeePolicy(policies, bsc, eth, "USDT", 7, 0).Id)
+	// the promotion works in its window only
+	assert.Equal(t, int64(5), MatchFeePolicy(policies, bsc, eth, "BNB", 0, 999).Id)
+	assert.Equal(t, int64(4), MatchFeePolicy(policies, bsc, eth, "BNB", 0, 1000).Id)
+	assert.Equal(t, int64(5), MatchFeePolicy(policies, bsc, eth, "BNB", 0, 2000).Id)
+	assert.Nil(t, MatchFeePolicy(policies[1:2], bsc, heco, "USDT", 0, 0))
+}
+
+func TestFeePolicy_Apply(t *testing.T) {
+	var policy *FeePolicy
+	assert.Equal(t, "10", policy.Apply(usdt(10)).String())
+
+	policy = &FeePolicy{Multiplier: 150, Discount: 20}
+	assert.Equal(t, "12", policy.Apply(usdt(10)).String())
+
+	policy = &FeePolicy{MinUsdt: 5 * basedef.PRICE_PRECISION, MaxUsdt: 20 * basedef.PRICE_PRECISION}
+	assert.Equal(t, "5", policy.Apply(usdt(1)).String())
+	assert.Equal(t, "10", policy.Apply(usdt(10)).String())
+	assert.Equal(t, "20", policy.Apply(usdt(30)).String())
+
+	policy = &FeePolicy{Free: true, MinUsdt: 5 * basedef.PRICE_PRECISION}
+	assert.Equal(t, "0", policy.Apply(usdt(10)).String())
+
+	// a paid quote passes the check of the same policy
+	policy = &FeePolicy{Multiplier: 80, Discount: 10, MinUsdt: 3 * basedef.PRICE_PRECISION, MaxUsdt: 4 * basedef.PRICE_PRECISION}
+	for _, fee := range [][2]float64{{1, 6}, {2, 12}, {0.5, 3}} {
+		minFee, proxyFee := policy.Apply(usdt(fee[0])), policy.Apply(usdt(fee[1]))
+		assert.True(t, proxyFee.Cmp(minFee) >= 0)
+	}
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package models
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// FeeQuote is a fee quoted by GetFee and signed by the server, a wrapper transaction of the route sent by the user
+// transferring the asset and paying the amount of the fee token before the expiry has paid the fee whatever the
+// fees and prices are when it is checked
+type FeeQuote struct {
+	Id         string  `gorm:"primaryKey;size:66;not null"`
+	SrcChainId uint64  `gorm:"index:idx_fee_quotes_route;type:bigint(20);not null"`
+	Hash       string  `gorm:"index:idx_fee_quotes_route;size:66;not null"` // the fee token on the source chain
+	Asset      string  `gorm:"size:66;not null"`                            // the asset transferred on the source chain
+	DstChainId uint64  `gorm:"index:idx_fee_quotes_route;type:bigint(20);not null"`
+	ServerId   uint64  `gorm:"type:bigint(20);not null"`
+	User       string  `gorm:"size:66;not null"`          // the sender of the wrapper transaction, as wrapper_transactions records it
+	Amount     *BigInt `gorm:"type:varchar(64);not null"` // amount of the fee token with precision
+	Time       int64   `gorm:"type:bigint(20);not null"`
+	Expiry     int64   `gorm:"index;type:bigint(20);not null"`
+	Signature  string  `gorm:"type:varchar(132);not null"`
+}
+
+// FeeQuoteDigest is the keccak256 hash of the message of a fee quote which the server signs, bridgesdk verifies
+// the quotes returned by getfee with it so the layout of the message is kept in one place
+func FeeQuoteDigest(srcChainId uint64, hash string, asset string, dstChainId uint64, serverId uint64, user string, amount string, time int64, expiry int64) []byte {
+	message := fmt.Sprintf("poly-bridge fee quote\nSrcChainId:%d\nHash:%s\nAsset:%s\nDstChainId:%d\nServerId:%d\nUser:%s\nAmount:%s\nTime:%d\nExpiry:%d",
+		srcChainId, hash, asset, dstChainId, serverId, user, amount, time, expiry)
+	return crypto.Keccak256([]byte(message))
+}
+
+// Digest is the keccak256 hash of the quote signed by the server, it is the id of the quote
+func (quote *FeeQuote) Digest() []byte {
+	return FeeQuoteDigest(quote.SrcChainId, quote.Hash, quote.Asset, quote.DstChainId, quote.ServerId, quote.User, bigIntString(quote.Amount), quote.Time, quote.Expiry)
+}
+
+func (quote *FeeQuote) Sign(key *ecdsa.PrivateKey) error {
+	digest := quote.Digest()
+	signature, err := crypto.Sign(digest, key)
+	if err != nil {
+		return err
+	}
+	quote.Id = hex.EncodeToString(digest)
+	quote.Signature = hex.EncodeToString(signature)
+	return nil
+}
+
+// Signer recovers the address which signed the quote
+func (quote *FeeQuote) Signer() (common.Address, error) {
+	digest := quote.Digest()
+	if quote.Id != hex.EncodeToString(digest) {
+		return common.Address{}, fmt.Errorf("quote id: %s does not match the quote", quote.Id)
+	}
+	signature, err := hex.DecodeString(quote.Signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	publicKey, err := crypto.SigToPub(digest, signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*publicKey), nil
+}
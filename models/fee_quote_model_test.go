@@ -0,0 +1,67 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package models
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeeQuote_Sign(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	quote := &FeeQuote{
+		SrcChainId: 6,
+		Hash:       "0000000000000000000000000000000000000000",
+		Asset:      "55d398326f99059ff775485246999027b3197955",
+		DstChainId: 2,
+		ServerId:   1,
+		User:       "2c7536e3605d9c16a7a3d7b1898e529396a65c23",
+		Amount:     NewBigInt(big.NewInt(12000000000000000)),
+		Time:       1640995200,
+		Expiry:     1640995800,
+	}
+	assert.NoError(t, quote.Sign(key))
+	assert.Equal(t, 64, len(quote.Id))
+	signer, err := quote.Signer()
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), signer)
+
+	// a quote changed after signed is rejected
+	quote.Amount = NewBigInt(big.NewInt(1))
+	_, err = quote.Signer()
+	assert.Error(t, err)
+	quote.Id = ""
+	assert.NoError(t, quote.Sign(key))
+	quote.Expiry += 600
+	_, err = quote.Signer()
+	assert.Error(t, err)
+	// the quote of an asset is not the quote of another
+	quote.Expiry -= 600
+	quote.Asset = "0000000000000000000000000000000000000000"
+	_, err = quote.Signer()
+	assert.Error(t, err)
+	// the quote of a user is not the quote of another
+	quote.Asset = "55d398326f99059ff775485246999027b3197955"
+	quote.User = "0000000000000000000000000000000000000001"
+	_, err = quote.Signer()
+	assert.Error(t, err)
+}
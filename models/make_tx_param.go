@@ -0,0 +1,131 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package models
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"poly-bridge/basedef"
+
+	"github.com/polynetwork/poly/common"
+)
+
+// the method of the lock proxy and the nft lock proxy on the target chain
+const MAKE_TX_METHOD_UNLOCK = "unlock"
+
+// DecodeMakeTxParam decodes the hex param of a source transaction, which is the poly MakeTxParam encoding
+// of the cross chain call. The args of the unlock method are decoded as lock proxy args, or as nft lock proxy
+// args for erc721 transactions.
+func DecodeMakeTxParam(hash string, param string, standard uint8) (*MakeTxParam, error) {
+	data, err := hex.DecodeString(param)
+	if err != nil {
+		return nil, fmt.Errorf("param is not hex: %v", err)
+	}
+	source := common.NewZeroCopySource(data)
+	txId, eof := source.NextVarBytes()
+	if eof {
+		return nil, fmt.Errorf("decode tx id error")
+	}
+	crossChainId, eof := source.NextVarBytes()
+	if eof {
+		return nil, fmt.Errorf("decode cross chain id error")
+	}
+	fromContract, eof := source.NextVarBytes()
+	if eof {
+		return nil, fmt.Errorf("decode from contract error")
+	}
+	toChainId, eof := source.NextUint64()
+	if eof {
+		return nil, fmt.Errorf("decode to chain id error")
+	}
+	toContract, eof := source.NextVarBytes()
+	if eof {
+		return nil, fmt.Errorf("decode to contract error")
+	}
+	method, eof := source.NextString()
+	if eof {
+		return nil, fmt.Errorf("decode method error")
+	}
+	args, eof := source.NextVarBytes()
+	if eof {
+		return nil, fmt.Errorf("decode args error")
+	}
+	makeTxParam := &MakeTxParam{
+		TxHash:       hash,
+		TxId:         limitHex(txId),
+		CrossChainId: limitHex(crossChainId),
+		FromContract: limitHex(fromContract),
+		ToChainId:    toChainId,
+		ToContract:   limitHex(toContract),
+		Args:         hex.EncodeToString(args),
+	}
+	if len(method) <= 64 {
+		makeTxParam.Method = method
+	}
+	if method == MAKE_TX_METHOD_UNLOCK {
+		decodeUnlockArgs(makeTxParam, args, standard)
+	}
+	return makeTxParam, nil
+}
+
+// decodeUnlockArgs decodes to asset, to address and the amount, or the token id of an nft. The amount is
+// 32 bytes in little endian, some old proxies encode it as var bytes.
+func decodeUnlockArgs(makeTxParam *MakeTxParam, args []byte, standard uint8) {
+	source := common.NewZeroCopySource(args)
+	toAsset, eof := source.NextVarBytes()
+	if eof {
+		return
+	}
+	toAddress, eof := source.NextVarBytes()
+	if eof {
+		return
+	}
+	makeTxParam.ToAsset = limitHex(toAsset)
+	makeTxParam.ToAddress = limitHex(toAddress)
+	value, eof := source.NextBytes(32)
+	if eof {
+		value, eof = source.NextVarBytes()
+		if eof {
+			return
+		}
+	}
+	amount := new(big.Int).SetBytes(reverseBytes(value))
+	if standard == TokenTypeErc721 {
+		makeTxParam.TokenId = NewBigInt(amount)
+	} else {
+		makeTxParam.Amount = NewBigInt(amount)
+	}
+}
+
+// limitHex drops the values which are longer than the columns, the same as the addresses of the transfers
+func limitHex(data []byte) string {
+	value := hex.EncodeToString(data)
+	if len(value) > basedef.ADDRESS_LENGTH {
+		return ""
+	}
+	return value
+}
+
+func reverseBytes(data []byte) []byte {
+	reversed := make([]byte, len(data))
+	for i, b := range data {
+		reversed[len(data)-1-i] = b
+	}
+	return reversed
+}
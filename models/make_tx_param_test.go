@@ -0,0 +1,86 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package models
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/polynetwork/poly/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeTxParamHex(method string, args []byte) string {
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarBytes([]byte{0x01, 0x02})
+	sink.WriteVarBytes([]byte{0x0a})
+	sink.WriteVarBytes(make([]byte, 20))
+	sink.WriteUint64(6)
+	sink.WriteVarBytes([]byte{0xff, 0xee})
+	sink.WriteVarBytes([]byte(method))
+	sink.WriteVarBytes(args)
+	return hex.EncodeToString(sink.Bytes())
+}
+
+func TestDecodeMakeTxParam(t *testing.T) {
+	value := new(big.Int).SetUint64(1000000)
+	amount := make([]byte, 32)
+	copy(amount, reverseBytes(value.Bytes()))
+	sink := common.NewZeroCopySink(nil)
+	sink.WriteVarBytes([]byte{0x11, 0x22})
+	sink.WriteVarBytes([]byte{0x33, 0x44})
+	sink.WriteBytes(amount)
+	args := sink.Bytes()
+
+	makeTxParam, err := DecodeMakeTxParam("hash", makeTxParamHex(MAKE_TX_METHOD_UNLOCK, args), TokenTypeErc20)
+	assert.Nil(t, err)
+	assert.Equal(t, "hash", makeTxParam.TxHash)
+	assert.Equal(t, "0102", makeTxParam.TxId)
+	assert.Equal(t, "0a", makeTxParam.CrossChainId)
+	assert.Equal(t, "0000000000000000000000000000000000000000", makeTxParam.FromContract)
+	assert.Equal(t, uint64(6), makeTxParam.ToChainId)
+	assert.Equal(t, "ffee", makeTxParam.ToContract)
+	assert.Equal(t, MAKE_TX_METHOD_UNLOCK, makeTxParam.Method)
+	assert.Equal(t, hex.EncodeToString(args), makeTxParam.Args)
+	assert.Equal(t, "1122", makeTxParam.ToAsset)
+	assert.Equal(t, "3344", makeTxParam.ToAddress)
+	assert.Equal(t, "1000000", makeTxParam.Amount.String())
+	assert.Nil(t, makeTxParam.TokenId)
+
+	makeTxParam, err = DecodeMakeTxParam("hash", makeTxParamHex(MAKE_TX_METHOD_UNLOCK, args), TokenTypeErc721)
+	assert.Nil(t, err)
+	assert.Nil(t, makeTxParam.Amount)
+	assert.Equal(t, "1000000", makeTxParam.TokenId.String())
+}
+
+func TestDecodeMakeTxParam_OtherMethod(t *testing.T) {
+	makeTxParam, err := DecodeMakeTxParam("hash", makeTxParamHex("add", []byte{0x01}), TokenTypeErc20)
+	assert.Nil(t, err)
+	assert.Equal(t, "add", makeTxParam.Method)
+	assert.Equal(t, "01", makeTxParam.Args)
+	assert.Equal(t, "", makeTxParam.ToAsset)
+	assert.Nil(t, makeTxParam.Amount)
+}
+
+func TestDecodeMakeTxParam_Invalid(t *testing.T) {
+	_, err := DecodeMakeTxParam("hash", "zz", TokenTypeErc20)
+	assert.NotNil(t, err)
+	_, err = DecodeMakeTxParam("hash", "0201", TokenTypeErc20)
+	assert.NotNil(t, err)
+}
@@ -176,6 +176,7 @@ type TokenRsp struct {
 	AvailableAmount string
 	TokenBasic      *TokenBasicRsp
 	TokenMaps       []*TokenMapRsp
+	ProxyAmounts    []*TokenProxyAmountRsp
 }
 
 func MakeTokenRsp(token *Token) *TokenRsp {
@@ -200,9 +201,31 @@ func MakeTokenRsp(token *Token) *TokenRsp {
 			}
 		}
 	}
+	for _, proxyAmount := range token.ProxyAmounts {
+		tokenRsp.ProxyAmounts = append(tokenRsp.ProxyAmounts, MakeTokenProxyAmountRsp(proxyAmount))
+	}
 	return tokenRsp
 }
 
+type TokenProxyAmountRsp struct {
+	Proxy           string
+	Label           string
+	AvailableAmount string
+	Time            int64
+}
+
+func MakeTokenProxyAmountRsp(proxyAmount *TokenProxyAmount) *TokenProxyAmountRsp {
+	proxyAmountRsp := &TokenProxyAmountRsp{
+		Proxy: proxyAmount.Proxy,
+		Label: proxyAmount.Label,
+		Time:  proxyAmount.Time,
+	}
+	if proxyAmount.AvailableAmount != nil {
+		proxyAmountRsp.AvailableAmount = proxyAmount.AvailableAmount.String()
+	}
+	return proxyAmountRsp
+}
+
 type PriceMarketRsp struct {
 	TokenBasicName string
 	MarketName     string
@@ -303,6 +326,9 @@ type GetFeeReq struct {
 	Hash          string
 	DstChainId    uint64
 	SwapTokenHash string
+	ServerId      uint64 // the integrator whose fee policies are applied
+	Quote         bool   // a signed fee quote is made and returned
+	User          string // the sender of the wrapper transaction on the source chain, the quote is made for the user only
 }
 
 type GetFeeRsp struct {
@@ -315,6 +341,40 @@ type GetFeeRsp struct {
 	SwapTokenHash            string
 	Balance                  string
 	BalanceWithPrecision     string
+	Quote                    *FeeQuoteRsp `json:",omitempty"`
+}
+
+type FeeQuoteRsp struct {
+	Id         string
+	SrcChainId uint64
+	Hash       string
+	Asset      string
+	DstChainId uint64
+	ServerId   uint64
+	User       string
+	Amount     string
+	Time       int64
+	Expiry     int64
+	Signature  string
+}
+
+func MakeFeeQuoteRsp(quote *FeeQuote) *FeeQuoteRsp {
+	if quote == nil {
+		return nil
+	}
+	return &FeeQuoteRsp{
+		Id:         quote.Id,
+		SrcChainId: quote.SrcChainId,
+		Hash:       quote.Hash,
+		Asset:      quote.Asset,
+		DstChainId: quote.DstChainId,
+		ServerId:   quote.ServerId,
+		User:       quote.User,
+		Amount:     bigIntString(quote.Amount),
+		Time:       quote.Time,
+		Expiry:     quote.Expiry,
+		Signature:  quote.Signature,
+	}
 }
 
 func MakeGetFeeRsp(srcChainId uint64, hash string, dstChainId uint64, usdtAmount *big.Float, tokenAmount *big.Float, tokenAmountWithPrecision *big.Float,
@@ -361,6 +421,89 @@ func MakeGetFeeRsp(srcChainId uint64, hash string, dstChainId uint64, usdtAmount
 	return getFeeRsp
 }
 
+type FeeHistoryReq struct {
+	ChainId    uint64
+	StartTime  int64
+	EndTime    int64
+	Resolution int64  // seconds of a period, the latest fee update of every period is returned
+	Hash       string // the asset on the chain, the fees are scaled by the gas limit of the asset at the time if set
+}
+
+type FeeHistoryItemRsp struct {
+	Time           int64
+	UpdateTime     int64
+	Samples        uint64
+	TokenBasicName string
+	TokenPrice     string
+	MinFee         string
+	MaxFee         string
+	ProxyFee       string
+	UsdtAmount     string
+	GasLimit       uint64
+	ChainGasLimit  uint64
+}
+
+type FeeHistoryRsp struct {
+	ChainId    uint64
+	Resolution int64
+	TotalCount uint64
+	Fees       []*FeeHistoryItemRsp
+}
+
+// MakeFeeHistoryRsp groups the fee histories ordered by time into the periods of the resolution, the fees are
+// scaled by the latest of the asset gas limits ordered by time which is not after the fee update
+func MakeFeeHistoryRsp(chainId uint64, resolution int64, histories []*ChainFeeHistory, limits []*AssetGasLimitHistory) *FeeHistoryRsp {
+	feeHistoryRsp := &FeeHistoryRsp{
+		ChainId:    chainId,
+		Resolution: resolution,
+		Fees:       make([]*FeeHistoryItemRsp, 0),
+	}
+	var item *FeeHistoryItemRsp
+	var limit *AssetGasLimitHistory
+	for _, history := range histories {
+		for len(limits) > 0 && limits[0].Time <= history.Time {
+			limit, limits = limits[0], limits[1:]
+		}
+		minFee, maxFee, proxyFee := history.MinFee, history.MaxFee, history.ProxyFee
+		if limit != nil {
+			minFee, maxFee, proxyFee = limit.ScaleFee(minFee), limit.ScaleFee(maxFee), limit.ScaleFee(proxyFee)
+		}
+		period := history.Time - history.Time%resolution
+		if item == nil || item.Time != period {
+			item = &FeeHistoryItemRsp{Time: period}
+			feeHistoryRsp.Fees = append(feeHistoryRsp.Fees, item)
+		}
+		item.Samples++
+		item.UpdateTime = history.Time
+		item.TokenBasicName = history.TokenBasicName
+		item.TokenPrice = decimal.New(history.TokenPrice, 0).Div(decimal.New(basedef.PRICE_PRECISION, 0)).String()
+		precision := int32(0)
+		if history.TokenBasic != nil {
+			precision = int32(history.TokenBasic.Precision)
+		}
+		item.MinFee = feeAmount(minFee, precision).String()
+		item.MaxFee = feeAmount(maxFee, precision).String()
+		proxyAmount := feeAmount(proxyFee, precision)
+		item.ProxyFee = proxyAmount.String()
+		item.UsdtAmount = proxyAmount.Mul(decimal.New(history.TokenPrice, 0)).Div(decimal.New(basedef.PRICE_PRECISION, 0)).String()
+		item.GasLimit, item.ChainGasLimit = 0, 0
+		if limit != nil {
+			item.GasLimit, item.ChainGasLimit = limit.GasLimit, limit.ChainGasLimit
+		}
+	}
+	feeHistoryRsp.TotalCount = uint64(len(feeHistoryRsp.Fees))
+	return feeHistoryRsp
+}
+
+// feeAmount is the fee in the fee token without the fee precision
+func feeAmount(fee *BigInt, precision int32) decimal.Decimal {
+	if fee == nil {
+		return decimal.Zero
+	}
+	amount := decimal.NewFromBigInt(&fee.Int, -precision)
+	return amount.Div(decimal.New(basedef.FEE_PRECISION, 0))
+}
+
 type CheckFeeReq struct {
 	Hash    string
 	ChainId uint64
@@ -372,6 +515,7 @@ type CheckFeeRsp struct {
 	PayState    int
 	Amount      string
 	MinProxyFee string
+	QuoteId     string `json:",omitempty"`
 }
 
 type CheckFeesReq struct {
@@ -400,6 +544,7 @@ func MakeCheckFeeRsp(checkFee *CheckFee) *CheckFeeRsp {
 		PayState:    checkFee.PayState,
 		Amount:      checkFee.Amount.String(),
 		MinProxyFee: checkFee.MinProxyFee.String(),
+		QuoteId:     checkFee.QuoteId,
 	}
 	{
 		aaa, _ := checkFee.Amount.Float64()
@@ -493,6 +638,9 @@ type TransactionStateRsp struct {
 	Blocks     uint64
 	NeedBlocks uint64
 	Time       uint64
+	GasUsed    uint64
+	GasPrice   string
+	Fee        string
 }
 
 type TransactionRsp struct {
@@ -508,9 +656,77 @@ type TransactionRsp struct {
 	DstUser          string
 	ServerId         uint64
 	State            uint64
+	MessageType      uint8
 	Token            *TokenRsp
 	FeeToken         *TokenRsp
 	TransactionState []*TransactionStateRsp
+	MakeTxParam      *MakeTxParamRsp
+	DstFailures      []*FailedDstTransactionRsp
+}
+
+type FailedDstTransactionRsp struct {
+	Hash       string
+	ChainId    uint64
+	Time       uint64
+	Height     uint64
+	SrcChainId uint64
+	PolyHash   string
+	Submitter  string
+	Reason     string
+	GasUsed    uint64
+	GasPrice   string
+	Fee        string
+}
+
+func MakeFailedDstTransactionRsp(transaction *FailedDstTransaction) *FailedDstTransactionRsp {
+	return &FailedDstTransactionRsp{
+		Hash:       transaction.Hash,
+		ChainId:    transaction.ChainId,
+		Time:       transaction.Time,
+		Height:     transaction.Height,
+		SrcChainId: transaction.SrcChainId,
+		PolyHash:   transaction.PolyHash,
+		Submitter:  transaction.Submitter,
+		Reason:     transaction.Reason,
+		GasUsed:    transaction.GasUsed,
+		GasPrice:   bigIntString(transaction.GasPrice),
+		Fee:        bigIntString(transaction.Fee),
+	}
+}
+
+type MakeTxParamRsp struct {
+	TxId         string
+	CrossChainId string
+	FromContract string
+	ToChainId    uint64
+	ToContract   string
+	Method       string
+	Args         string
+	ToAsset      string
+	ToAddress    string
+	Amount       string
+	TokenId      string
+}
+
+func MakeMakeTxParamRsp(makeTxParam *MakeTxParam) *MakeTxParamRsp {
+	makeTxParamRsp := &MakeTxParamRsp{
+		TxId:         makeTxParam.TxId,
+		CrossChainId: makeTxParam.CrossChainId,
+		FromContract: makeTxParam.FromContract,
+		ToChainId:    makeTxParam.ToChainId,
+		ToContract:   makeTxParam.ToContract,
+		Method:       makeTxParam.Method,
+		Args:         makeTxParam.Args,
+		ToAsset:      makeTxParam.ToAsset,
+		ToAddress:    makeTxParam.ToAddress,
+	}
+	if makeTxParam.Amount != nil {
+		makeTxParamRsp.Amount = makeTxParam.Amount.String()
+	}
+	if makeTxParam.TokenId != nil {
+		makeTxParamRsp.TokenId = makeTxParam.TokenId.String()
+	}
+	return makeTxParamRsp
 }
 
 func MakeTransactionRsp(transaction *SrcPolyDstRelation, chainsMap map[uint64]*Chain) *TransactionRsp {
@@ -520,22 +736,42 @@ func MakeTransactionRsp(transaction *SrcPolyDstRelation, chainsMap map[uint64]*C
 		feeAmount = aaa.String()
 	}
 	transferAmount := ""
+	dstUser := ""
 	if transaction.SrcTransaction.SrcTransfer != nil {
 		aaa := new(big.Int).Set(&transaction.SrcTransaction.SrcTransfer.Amount.Int)
 		transferAmount = aaa.String()
+		dstUser = transaction.SrcTransaction.SrcTransfer.DstUser
 	}
 	transactionRsp := &TransactionRsp{
-		Hash:           transaction.WrapperTransaction.Hash,
-		User:           transaction.WrapperTransaction.User,
-		SrcChainId:     transaction.WrapperTransaction.SrcChainId,
-		BlockHeight:    transaction.WrapperTransaction.BlockHeight,
-		Time:           transaction.WrapperTransaction.Time,
-		DstChainId:     transaction.WrapperTransaction.DstChainId,
-		ServerId:       transaction.WrapperTransaction.ServerId,
 		FeeAmount:      feeAmount,
 		TransferAmount: transferAmount,
-		DstUser:        transaction.SrcTransaction.SrcTransfer.DstUser,
-		State:          transaction.WrapperTransaction.Status,
+		DstUser:        dstUser,
+		MessageType:    transaction.SrcTransaction.MessageType,
+	}
+	if transaction.WrapperTransaction != nil {
+		transactionRsp.Hash = transaction.WrapperTransaction.Hash
+		transactionRsp.User = transaction.WrapperTransaction.User
+		transactionRsp.SrcChainId = transaction.WrapperTransaction.SrcChainId
+		transactionRsp.BlockHeight = transaction.WrapperTransaction.BlockHeight
+		transactionRsp.Time = transaction.WrapperTransaction.Time
+		transactionRsp.DstChainId = transaction.WrapperTransaction.DstChainId
+		transactionRsp.ServerId = transaction.WrapperTransaction.ServerId
+		transactionRsp.State = transaction.WrapperTransaction.Status
+	} else {
+		// the cross chain calls are not sent through the wrapper
+		transactionRsp.Hash = transaction.SrcTransaction.Hash
+		transactionRsp.User = transaction.SrcTransaction.User
+		transactionRsp.SrcChainId = transaction.SrcTransaction.ChainId
+		transactionRsp.BlockHeight = transaction.SrcTransaction.Height
+		transactionRsp.Time = transaction.SrcTransaction.Time
+		transactionRsp.DstChainId = transaction.SrcTransaction.DstChainId
+		if transaction.DstTransaction != nil {
+			transactionRsp.State = basedef.STATE_FINISHED
+		} else if transaction.PolyTransaction != nil {
+			transactionRsp.State = basedef.STATE_POLY_CONFIRMED
+		} else {
+			transactionRsp.State = basedef.STATE_SOURCE_DONE
+		}
 	}
 	if transaction.Token != nil {
 		transactionRsp.Token = MakeTokenRsp(transaction.Token)
@@ -555,17 +791,32 @@ func MakeTransactionRsp(transaction *SrcPolyDstRelation, chainsMap map[uint64]*C
 			transactionRsp.FeeAmount = feeAmount.String()
 		}
 	}
+	if transaction.SrcTransaction != nil && transaction.SrcTransaction.MakeTxParam != nil {
+		transactionRsp.MakeTxParam = MakeMakeTxParamRsp(transaction.SrcTransaction.MakeTxParam)
+	}
+	if transaction.PolyTransaction != nil {
+		for _, failed := range transaction.PolyTransaction.FailedDstTransactions {
+			transactionRsp.DstFailures = append(transactionRsp.DstFailures, MakeFailedDstTransactionRsp(failed))
+		}
+		// relayed to the destination chain but every execution reverted
+		if transaction.DstTransaction == nil && len(transactionRsp.DstFailures) > 0 {
+			transactionRsp.State = basedef.STATE_DESTINATION_REVERTED
+		}
+	}
 	if transaction.SrcTransaction != nil {
 		transactionRsp.TransactionState = append(transactionRsp.TransactionState, &TransactionStateRsp{
-			Hash:    transaction.SrcTransaction.Hash,
-			ChainId: transaction.SrcTransaction.ChainId,
-			Blocks:  transaction.SrcTransaction.Height,
-			Time:    transaction.SrcTransaction.Time,
+			Hash:     transaction.SrcTransaction.Hash,
+			ChainId:  transaction.SrcTransaction.ChainId,
+			Blocks:   transaction.SrcTransaction.Height,
+			Time:     transaction.SrcTransaction.Time,
+			GasUsed:  transaction.SrcTransaction.GasUsed,
+			GasPrice: bigIntString(transaction.SrcTransaction.GasPrice),
+			Fee:      bigIntString(transaction.SrcTransaction.Fee),
 		})
 	} else {
 		transactionRsp.TransactionState = append(transactionRsp.TransactionState, &TransactionStateRsp{
 			Hash:    "",
-			ChainId: transaction.WrapperTransaction.SrcChainId,
+			ChainId: transactionRsp.SrcChainId,
 			Blocks:  0,
 			Time:    0,
 		})
@@ -587,15 +838,18 @@ func MakeTransactionRsp(transaction *SrcPolyDstRelation, chainsMap map[uint64]*C
 	}
 	if transaction.DstTransaction != nil {
 		transactionRsp.TransactionState = append(transactionRsp.TransactionState, &TransactionStateRsp{
-			Hash:    transaction.DstTransaction.Hash,
-			ChainId: transaction.DstTransaction.ChainId,
-			Blocks:  transaction.DstTransaction.Height,
-			Time:    transaction.DstTransaction.Time,
+			Hash:     transaction.DstTransaction.Hash,
+			ChainId:  transaction.DstTransaction.ChainId,
+			Blocks:   transaction.DstTransaction.Height,
+			Time:     transaction.DstTransaction.Time,
+			GasUsed:  transaction.DstTransaction.GasUsed,
+			GasPrice: bigIntString(transaction.DstTransaction.GasPrice),
+			Fee:      bigIntString(transaction.DstTransaction.Fee),
 		})
 	} else {
 		transactionRsp.TransactionState = append(transactionRsp.TransactionState, &TransactionStateRsp{
 			Hash:    "",
-			ChainId: transaction.WrapperTransaction.DstChainId,
+			ChainId: transactionRsp.DstChainId,
 			Blocks:  0,
 			Time:    0,
 		})
@@ -603,7 +857,7 @@ func MakeTransactionRsp(transaction *SrcPolyDstRelation, chainsMap map[uint64]*C
 	for _, state := range transactionRsp.TransactionState {
 		chain, ok := chainsMap[state.ChainId]
 		if ok {
-			if state.ChainId == transaction.WrapperTransaction.DstChainId {
+			if state.ChainId == transactionRsp.DstChainId {
 				state.NeedBlocks = 1
 			} else {
 				state.NeedBlocks = chain.BackwardBlockNumber
@@ -807,6 +1061,13 @@ type TransactionsOfAssetReq struct {
 	PageNo   int
 }
 
+type TransactionsOfTypeReq struct {
+	MessageType *uint8
+	ChainId     uint64
+	PageSize    int
+	PageNo      int
+}
+
 type CrossChainTransactionRsp struct {
 	WrapperTransaction *WrapperTransactionRsp
 	SrcTransaction     *SrcTransactionRsp
@@ -827,11 +1088,16 @@ type SrcTransactionRsp struct {
 	Hash        string
 	ChainId     uint64
 	Standard    uint8
+	MessageType uint8
 	State       uint64
 	Time        uint64
 	Height      uint64
 	DstChainId  uint64
+	GasUsed     uint64
+	GasPrice    string
+	Fee         string
 	SrcTransfer *SrcTransferRsp
+	MakeTxParam *MakeTxParamRsp
 }
 
 type SrcTransferRsp struct {
@@ -843,6 +1109,7 @@ type SrcTransferRsp struct {
 	Amount     string
 	DstChainId uint64
 	DstAsset   string
+	Proxy      string
 }
 
 func MakeSrcTransferRsp(transaction *SrcTransfer) *SrcTransferRsp {
@@ -854,23 +1121,31 @@ func MakeSrcTransferRsp(transaction *SrcTransfer) *SrcTransferRsp {
 		DstChainId: transaction.DstChainId,
 		Asset:      transaction.Asset,
 		DstAsset:   transaction.DstAsset,
+		Proxy:      transaction.Proxy,
 	}
 	return transactionRsp
 }
 
 func MakeSrcTransactionRsp(transaction *SrcTransaction) *SrcTransactionRsp {
 	transactionRsp := &SrcTransactionRsp{
-		Hash:       transaction.Hash,
-		ChainId:    transaction.ChainId,
-		Standard:   transaction.Standard,
-		State:      transaction.State,
-		Time:       transaction.Time,
-		DstChainId: transaction.DstChainId,
-		Height:     transaction.Height,
+		Hash:        transaction.Hash,
+		ChainId:     transaction.ChainId,
+		Standard:    transaction.Standard,
+		MessageType: transaction.MessageType,
+		State:       transaction.State,
+		Time:        transaction.Time,
+		DstChainId:  transaction.DstChainId,
+		Height:      transaction.Height,
+		GasUsed:     transaction.GasUsed,
+		GasPrice:    bigIntString(transaction.GasPrice),
+		Fee:         bigIntString(transaction.Fee),
 	}
 	if transaction.SrcTransfer != nil {
 		transactionRsp.SrcTransfer = MakeSrcTransferRsp(transaction.SrcTransfer)
 	}
+	if transaction.MakeTxParam != nil {
+		transactionRsp.MakeTxParam = MakeMakeTxParamRsp(transaction.MakeTxParam)
+	}
 	return transactionRsp
 }
 
@@ -878,10 +1153,14 @@ type DstTransactionRsp struct {
 	Hash        string
 	ChainId     uint64
 	Standard    uint8
+	MessageType uint8
 	State       uint64
 	Time        uint64
 	Height      uint64
 	SrcChainId  uint64
+	GasUsed     uint64
+	GasPrice    string
+	Fee         string
 	DstTransfer *DstTransferRsp
 }
 
@@ -892,6 +1171,7 @@ type DstTransferRsp struct {
 	Time     uint64
 	Asset    string
 	Amount   string
+	Proxy    string
 }
 
 func MakeDstTransferRsp(transaction *DstTransfer) *DstTransferRsp {
@@ -901,19 +1181,24 @@ func MakeDstTransferRsp(transaction *DstTransfer) *DstTransferRsp {
 		Standard: transaction.Standard,
 		Time:     transaction.Time,
 		Asset:    transaction.Asset,
+		Proxy:    transaction.Proxy,
 	}
 	return transactionRsp
 }
 
 func MakeDstTransactionRsp(transaction *DstTransaction) *DstTransactionRsp {
 	transactionRsp := &DstTransactionRsp{
-		Hash:       transaction.Hash,
-		ChainId:    transaction.ChainId,
-		Standard:   transaction.Standard,
-		State:      transaction.State,
-		Time:       transaction.Time,
-		SrcChainId: transaction.SrcChainId,
-		Height:     transaction.Height,
+		Hash:        transaction.Hash,
+		ChainId:     transaction.ChainId,
+		Standard:    transaction.Standard,
+		MessageType: transaction.MessageType,
+		State:       transaction.State,
+		Time:        transaction.Time,
+		SrcChainId:  transaction.SrcChainId,
+		Height:      transaction.Height,
+		GasUsed:     transaction.GasUsed,
+		GasPrice:    bigIntString(transaction.GasPrice),
+		Fee:         bigIntString(transaction.Fee),
 	}
 	if transaction.DstTransfer != nil {
 		transactionRsp.DstTransfer = MakeDstTransferRsp(transaction.DstTransfer)
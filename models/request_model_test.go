@@ -0,0 +1,135 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package models
+
+import (
+	"math/big"
+	"poly-bridge/basedef"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeTransactionRsp_Call(t *testing.T) {
+	chainId := basedef.BSC_CROSSCHAIN_ID
+	relation := &SrcPolyDstRelation{
+		SrcTransaction: &SrcTransaction{
+			Hash:        "src",
+			ChainId:     basedef.ETHEREUM_CROSSCHAIN_ID,
+			MessageType: MessageTypeCall,
+			Time:        100,
+			Height:      10,
+			User:        "user",
+			DstChainId:  basedef.BSC_CROSSCHAIN_ID,
+			MakeTxParam: &MakeTxParam{TxHash: "src", Method: "receiveMessage"},
+		},
+		PolyTransaction: &PolyTransaction{Hash: "poly", Height: 20},
+	}
+	chainsMap := map[uint64]*Chain{chainId: {ChainId: &chainId, Height: 100, BackwardBlockNumber: 15}}
+	transactionRsp := MakeTransactionRsp(relation, chainsMap)
+	assert.Equal(t, "src", transactionRsp.Hash)
+	assert.Equal(t, "user", transactionRsp.User)
+	assert.Equal(t, basedef.ETHEREUM_CROSSCHAIN_ID, transactionRsp.SrcChainId)
+	assert.Equal(t, basedef.BSC_CROSSCHAIN_ID, transactionRsp.DstChainId)
+	assert.Equal(t, MessageTypeCall, transactionRsp.MessageType)
+	assert.Equal(t, uint64(basedef.STATE_POLY_CONFIRMED), transactionRsp.State)
+	assert.Equal(t, "", transactionRsp.TransferAmount)
+	assert.Equal(t, "", transactionRsp.DstUser)
+	assert.Equal(t, "receiveMessage", transactionRsp.MakeTxParam.Method)
+	assert.Equal(t, 3, len(transactionRsp.TransactionState))
+	assert.Equal(t, basedef.BSC_CROSSCHAIN_ID, transactionRsp.TransactionState[2].ChainId)
+	assert.Equal(t, uint64(1), transactionRsp.TransactionState[2].NeedBlocks)
+}
+
+func TestMakeTransactionRsp_DstReverted(t *testing.T) {
+	chainId := basedef.BSC_CROSSCHAIN_ID
+	failed := &FailedDstTransaction{
+		Hash:       "dst",
+		ChainId:    basedef.BSC_CROSSCHAIN_ID,
+		SrcChainId: basedef.ETHEREUM_CROSSCHAIN_ID,
+		PolyHash:   "poly",
+		Reason:     "EthCrossChain: the transaction has been executed!",
+		GasUsed:    98213,
+		GasPrice:   NewBigInt(big.NewInt(70000000000)),
+		Fee:        NewBigInt(big.NewInt(6874910000000000)),
+	}
+	relation := &SrcPolyDstRelation{
+		SrcTransaction: &SrcTransaction{
+			Hash:        "src",
+			ChainId:     basedef.ETHEREUM_CROSSCHAIN_ID,
+			MessageType: MessageTypeCall,
+			DstChainId:  basedef.BSC_CROSSCHAIN_ID,
+		},
+		PolyTransaction: &PolyTransaction{Hash: "poly", Height: 20, FailedDstTransactions: []*FailedDstTransaction{failed}},
+	}
+	chainsMap := map[uint64]*Chain{chainId: {ChainId: &chainId, Height: 100, BackwardBlockNumber: 15}}
+	transactionRsp := MakeTransactionRsp(relation, chainsMap)
+	assert.Equal(t, uint64(basedef.STATE_DESTINATION_REVERTED), transactionRsp.State)
+	assert.Equal(t, 1, len(transactionRsp.DstFailures))
+	assert.Equal(t, "dst", transactionRsp.DstFailures[0].Hash)
+	assert.Equal(t, failed.Reason, transactionRsp.DstFailures[0].Reason)
+	assert.Equal(t, "6874910000000000", transactionRsp.DstFailures[0].Fee)
+
+	relation.DstTransaction = &DstTransaction{Hash: "dst2", ChainId: basedef.BSC_CROSSCHAIN_ID, Height: 90}
+	transactionRsp = MakeTransactionRsp(relation, chainsMap)
+	assert.Equal(t, uint64(basedef.STATE_FINISHED), transactionRsp.State)
+	assert.Equal(t, 1, len(transactionRsp.DstFailures))
+}
+
+func TestMakeFeeHistoryRsp(t *testing.T) {
+	tokenBasic := &TokenBasic{Name: "Ethereum", Precision: 18}
+	fee := func(amount string) *BigInt {
+		value, _ := new(big.Int).SetString(amount, 10)
+		return NewBigInt(value)
+	}
+	histories := []*ChainFeeHistory{
+		{ChainId: basedef.ETHEREUM_CROSSCHAIN_ID, Time: 3600, TokenBasicName: "Ethereum", TokenBasic: tokenBasic,
+			MinFee: fee("100000000000000000000000"), MaxFee: fee("500000000000000000000000"), ProxyFee: fee("600000000000000000000000"), TokenPrice: 190000000000},
+		{ChainId: basedef.ETHEREUM_CROSSCHAIN_ID, Time: 5400, TokenBasicName: "Ethereum", TokenBasic: tokenBasic,
+			MinFee: fee("200000000000000000000000"), MaxFee: fee("1000000000000000000000000"), ProxyFee: fee("1200000000000000000000000"), TokenPrice: 200000000000},
+		{ChainId: basedef.ETHEREUM_CROSSCHAIN_ID, Time: 10800, TokenBasicName: "Ethereum", TokenBasic: tokenBasic,
+			MinFee: fee("0"), MaxFee: fee("0"), ProxyFee: fee("0"), TokenPrice: 200000000000},
+	}
+	// the latest fee update of every hour
+	feeHistoryRsp := MakeFeeHistoryRsp(basedef.ETHEREUM_CROSSCHAIN_ID, 3600, histories, nil)
+	assert.Equal(t, uint64(2), feeHistoryRsp.TotalCount)
+	item := feeHistoryRsp.Fees[0]
+	assert.Equal(t, int64(3600), item.Time)
+	assert.Equal(t, int64(5400), item.UpdateTime)
+	assert.Equal(t, uint64(2), item.Samples)
+	assert.Equal(t, "0.002", item.MinFee)
+	assert.Equal(t, "0.01", item.MaxFee)
+	assert.Equal(t, "0.012", item.ProxyFee)
+	assert.Equal(t, "2000", item.TokenPrice)
+	assert.Equal(t, "24", item.UsdtAmount)
+	assert.Equal(t, int64(10800), feeHistoryRsp.Fees[1].Time)
+	assert.Equal(t, "0", feeHistoryRsp.Fees[1].ProxyFee)
+
+	// the fees of the asset are scaled by its gas limit at the time of the fee update
+	limits := []*AssetGasLimitHistory{
+		{ChainId: basedef.ETHEREUM_CROSSCHAIN_ID, Hash: "aa", Time: 3000, GasLimit: 150000, ChainGasLimit: 300000},
+		{ChainId: basedef.ETHEREUM_CROSSCHAIN_ID, Hash: "aa", Time: 5000, GasLimit: 75000, ChainGasLimit: 300000},
+	}
+	feeHistoryRsp = MakeFeeHistoryRsp(basedef.ETHEREUM_CROSSCHAIN_ID, 1800, histories[:2], limits)
+	assert.Equal(t, uint64(2), feeHistoryRsp.TotalCount)
+	assert.Equal(t, "0.003", feeHistoryRsp.Fees[0].ProxyFee)
+	assert.Equal(t, uint64(150000), feeHistoryRsp.Fees[0].GasLimit)
+	assert.Equal(t, "0.003", feeHistoryRsp.Fees[1].ProxyFee)
+	assert.Equal(t, uint64(75000), feeHistoryRsp.Fees[1].GasLimit)
+	assert.Equal(t, uint64(300000), feeHistoryRsp.Fees[1].ChainGasLimit)
+}
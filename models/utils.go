@@ -36,6 +36,13 @@ func NewBigInt(value *big.Int) *BigInt {
 	return &BigInt{Int: *value}
 }
 
+func bigIntString(value *BigInt) string {
+	if value == nil {
+		return ""
+	}
+	return value.String()
+}
+
 func (bigInt *BigInt) Value() (driver.Value, error) {
 	if bigInt == nil {
 		return "null", nil
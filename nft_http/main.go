@@ -25,6 +25,7 @@ import (
 	"github.com/astaxie/beego/logs"
 	"github.com/astaxie/beego/plugins/cors"
 	"poly-bridge/conf"
+	"poly-bridge/metrics"
 	"poly-bridge/nft_http/controllers"
 )
 
@@ -52,6 +53,8 @@ func main() {
 		AllowHeaders:     []string{"Origin", "Authorization", "Access-Control-Allow-Origin", "Access-Control-Allow-Headers", "Content-Type"},
 		ExposeHeaders:    []string{"Content-Length", "Access-Control-Allow-Origin", "Access-Control-Allow-Headers", "Content-Type"},
 		AllowCredentials: true}))
+	metrics.InstrumentBeego("nft_http")
+	metrics.StartMetrics(&conf.MetricsConfig{Port: beego.AppConfig.DefaultInt("metricsport", 0)})
 	beego.Run()
 }
 
@@ -0,0 +1,185 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"poly-bridge/alert"
+	"poly-bridge/metrics"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/astaxie/beego/logs"
+)
+
+const (
+	DEFAULT_MIN_BACKOFF    = time.Second * 5
+	DEFAULT_MAX_BACKOFF    = time.Minute * 5
+	DEFAULT_STABLE_RUN     = time.Minute * 10
+	DEFAULT_RESTART_BUDGET = 10
+	DEFAULT_BUDGET_WINDOW  = time.Hour
+	DEFAULT_STOP_TIMEOUT   = time.Second * 30
+)
+
+// Supervisor runs the loops of a service until it is stopped. A loop which panics or returns before the
+// stop is restarted after a delay, which doubles on every restart and is reset once the loop runs stable.
+// The loop is given up when it is restarted more than the budget in the window.
+type Supervisor struct {
+	MinBackoff    time.Duration
+	MaxBackoff    time.Duration
+	StableRun     time.Duration
+	RestartBudget int
+	BudgetWindow  time.Duration
+	StopTimeout   time.Duration
+	name          string
+	ctx           context.Context
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+}
+
+func NewSupervisor(name string) *Supervisor {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Supervisor{
+		MinBackoff:    DEFAULT_MIN_BACKOFF,
+		MaxBackoff:    DEFAULT_MAX_BACKOFF,
+		StableRun:     DEFAULT_STABLE_RUN,
+		RestartBudget: DEFAULT_RESTART_BUDGET,
+		BudgetWindow:  DEFAULT_BUDGET_WINDOW,
+		StopTimeout:   DEFAULT_STOP_TIMEOUT,
+		name:          name,
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+}
+
+func (s *Supervisor) Name() string {
+	return s.name
+}
+
+// Context is cancelled when the supervisor is stopped
+func (s *Supervisor) Context() context.Context {
+	return s.ctx
+}
+
+func (s *Supervisor) Stopping() bool {
+	return s.ctx.Err() != nil
+}
+
+// Go runs the loop until the supervisor is stopped, the loop should return once the context is done.
+func (s *Supervisor) Go(loop func(ctx context.Context) error) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.supervise(loop)
+	}()
+}
+
+func (s *Supervisor) supervise(loop func(ctx context.Context) error) {
+	key := fmt.Sprintf("service_crash_%s", s.name)
+	backoff := s.MinBackoff
+	restarts := make([]time.Time, 0)
+	for {
+		start := time.Now()
+		resolve := time.AfterFunc(s.StableRun, func() {
+			alert.Resolve(key, fmt.Sprintf("service %s is running", s.name))
+		})
+		err := s.run(loop)
+		resolve.Stop()
+		if s.Stopping() {
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("loop returned")
+		}
+		if time.Since(start) >= s.StableRun {
+			backoff = s.MinBackoff
+		}
+		now := time.Now()
+		for len(restarts) > 0 && now.Sub(restarts[0]) > s.BudgetWindow {
+			restarts = restarts[1:]
+		}
+		restarts = append(restarts, now)
+		if len(restarts) > s.RestartBudget {
+			logs.Error("service %s stopped %d times in %s, give up: %v", s.name, len(restarts), s.BudgetWindow, err)
+			alert.Fire(&alert.Alert{
+				Key:      key,
+				Severity: alert.SEVERITY_CRITICAL,
+				Source:   "supervisor",
+				Title:    fmt.Sprintf("service %s is given up", s.name),
+				Message:  err.Error(),
+				Fields:   map[string]string{"restarts": fmt.Sprintf("%d in %s", len(restarts)-1, s.BudgetWindow)},
+			})
+			return
+		}
+		logs.Error("service %s stopped, restart in %s: %v", s.name, backoff, err)
+		metrics.ServiceRestarted(s.name)
+		alert.Fire(&alert.Alert{
+			Key:      key,
+			Severity: alert.SEVERITY_WARNING,
+			Source:   "supervisor",
+			Title:    fmt.Sprintf("service %s stopped and is restarted", s.name),
+			Message:  err.Error(),
+		})
+		timer := time.NewTimer(backoff)
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > s.MaxBackoff {
+			backoff = s.MaxBackoff
+		}
+	}
+}
+
+func (s *Supervisor) run(loop func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, string(debug.Stack()))
+		}
+	}()
+	return loop(s.ctx)
+}
+
+// Wait blocks until all loops exit
+func (s *Supervisor) Wait() {
+	s.wg.Wait()
+}
+
+// Stop cancels the loops and waits for them at most StopTimeout, it returns false if they did not exit in time.
+func (s *Supervisor) Stop() bool {
+	s.cancel()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(s.StopTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		logs.Error("service %s does not stop in %s", s.name, s.StopTimeout)
+		return false
+	}
+}
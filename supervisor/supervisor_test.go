@@ -0,0 +1,109 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSupervisor() *Supervisor {
+	s := NewSupervisor("test")
+	s.MinBackoff = time.Millisecond * 10
+	s.MaxBackoff = time.Millisecond * 40
+	s.StopTimeout = time.Second
+	return s
+}
+
+func TestSupervisor_Restart(t *testing.T) {
+	s := newTestSupervisor()
+	runs := int32(0)
+	running := make(chan struct{})
+	s.Go(func(ctx context.Context) error {
+		switch atomic.AddInt32(&runs, 1) {
+		case 1:
+			panic("crash")
+		case 2:
+			return fmt.Errorf("failed")
+		case 3:
+			return nil
+		}
+		close(running)
+		<-ctx.Done()
+		return nil
+	})
+	select {
+	case <-running:
+	case <-time.After(time.Second * 5):
+		t.Fatal("loop is not restarted")
+	}
+	assert.True(t, s.Stop())
+	assert.Equal(t, int32(4), atomic.LoadInt32(&runs))
+}
+
+func TestSupervisor_Budget(t *testing.T) {
+	s := newTestSupervisor()
+	s.RestartBudget = 2
+	runs := int32(0)
+	s.Go(func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return fmt.Errorf("failed")
+	})
+	done := make(chan struct{})
+	go func() {
+		s.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("loop is not given up")
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&runs))
+	assert.False(t, s.Stopping())
+}
+
+func TestSupervisor_StopInBackoff(t *testing.T) {
+	s := newTestSupervisor()
+	s.MinBackoff = time.Hour
+	failed := make(chan struct{})
+	s.Go(func(ctx context.Context) error {
+		close(failed)
+		return fmt.Errorf("failed")
+	})
+	<-failed
+	start := time.Now()
+	assert.True(t, s.Stop())
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestSupervisor_StopTimeout(t *testing.T) {
+	s := newTestSupervisor()
+	s.StopTimeout = time.Millisecond * 50
+	release := make(chan struct{})
+	defer close(release)
+	s.Go(func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	assert.False(t, s.Stop())
+}
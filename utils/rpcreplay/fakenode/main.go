@@ -0,0 +1,622 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+// fakenode serves the constructed blocks from which the offline fixtures of the chain listens are recorded:
+// ethereum on 127.0.0.1:18545, poly on 127.0.0.1:18336, ontology on 127.0.0.1:20336 and neo on
+// 127.0.0.1:10332. The blocks are not chain data, see doc/offline_test.md.
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	neomodels "github.com/joeqian10/neo-gogogo/rpc/models"
+	ontcommon "github.com/ontio/ontology/common"
+	ontpayload "github.com/ontio/ontology/core/payload"
+	onttypes "github.com/ontio/ontology/core/types"
+	polycommon "github.com/polynetwork/poly/common"
+	polytypes "github.com/polynetwork/poly/core/types"
+	"poly-bridge/go_abi/eccm_abi"
+	"poly-bridge/go_abi/lock_proxy_abi"
+	"poly-bridge/go_abi/wrapper_abi"
+)
+
+type rpcRequest struct {
+	Id     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type methods map[string]func(params []json.RawMessage) interface{}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data,omitempty"`
+}
+
+func serve(addr string, ont bool, ms methods) {
+	handle := func(req *rpcRequest) map[string]interface{} {
+		fn, ok := ms[req.Method]
+		if !ok {
+			panic("unknown method " + req.Method)
+		}
+		result := fn(req.Params)
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.Id, "result": result}
+		if e, ok := result.(*rpcError); ok {
+			resp = map[string]interface{}{"jsonrpc": "2.0", "id": req.Id, "error": e}
+		}
+		if ont {
+			resp["error"] = 0
+			resp["desc"] = "SUCCESS"
+		}
+		return resp
+	}
+	go http.ListenAndServe(addr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		if len(body) > 0 && body[0] == '[' {
+			reqs := make([]*rpcRequest, 0)
+			json.Unmarshal(body, &reqs)
+			resps := make([]map[string]interface{}, 0)
+			for _, req := range reqs {
+				resps = append(resps, handle(req))
+			}
+			json.NewEncoder(w).Encode(resps)
+			return
+		}
+		req := &rpcRequest{}
+		json.Unmarshal(body, req)
+		json.NewEncoder(w).Encode(handle(req))
+	}))
+}
+
+func le(n *big.Int) string {
+	b := n.Bytes()
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return hex.EncodeToString(b)
+}
+
+func reverse(s string) string {
+	b, _ := hex.DecodeString(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return hex.EncodeToString(b)
+}
+
+func makeTxParam(txId []byte, crossChainId []byte, fromContract []byte, toChainId uint64, toContract []byte, method string, args []byte) string {
+	sink := polycommon.NewZeroCopySink(nil)
+	sink.WriteVarBytes(txId)
+	sink.WriteVarBytes(crossChainId)
+	sink.WriteVarBytes(fromContract)
+	sink.WriteUint64(toChainId)
+	sink.WriteVarBytes(toContract)
+	sink.WriteVarBytes([]byte(method))
+	sink.WriteVarBytes(args)
+	return hex.EncodeToString(sink.Bytes())
+}
+
+func unlockArgs(toAsset []byte, toAddress []byte, value *big.Int) []byte {
+	sink := polycommon.NewZeroCopySink(nil)
+	sink.WriteVarBytes(toAsset)
+	sink.WriteVarBytes(toAddress)
+	v := make([]byte, 32)
+	b := value.Bytes()
+	for i := range b {
+		v[i] = b[len(b)-1-i]
+	}
+	sink.WriteBytes(v)
+	return sink.Bytes()
+}
+
+func hexs(s string) string { return hex.EncodeToString([]byte(s)) }
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func amount(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 10)
+	return n
+}
+
+// ----- evm -----
+
+func evmLog(abiJson string, name string, address common.Address, values map[string]interface{}) *types.Log {
+	a, err := abi.JSON(strings.NewReader(abiJson))
+	if err != nil {
+		panic(err)
+	}
+	event := a.Events[name]
+	topics := []common.Hash{event.ID}
+	data := make([]interface{}, 0)
+	for _, input := range event.Inputs {
+		value, ok := values[input.Name]
+		if !ok {
+			panic("missing " + input.Name)
+		}
+		if input.Indexed {
+			switch v := value.(type) {
+			case common.Address:
+				topics = append(topics, common.BytesToHash(v.Bytes()))
+			default:
+				panic("indexed type")
+			}
+		} else {
+			data = append(data, value)
+		}
+	}
+	packed, err := event.Inputs.NonIndexed().Pack(data...)
+	if err != nil {
+		panic(err)
+	}
+	return &types.Log{Address: address, Topics: topics, Data: packed}
+}
+
+func evm() {
+	height := uint64(12000000)
+	key, _ := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	user := crypto.PubkeyToAddress(key.PublicKey)
+	eccm := common.HexToAddress("838bf9e95cb12dd76a54c9f9d2e3082eaf928270")
+	proxy := common.HexToAddress("250e76987d838a75310c34bf422ea9f1AC4Cc906")
+	wrapper := common.HexToAddress("2aA63cd0b28FB4C31fA8e4E95Ec11815Be07b9Ac")
+	bscProxy := mustHex("2f7ac9436ba4b548f9582af91ca1ef02cd2f1f03")
+	signer := types.NewEIP155Signer(big.NewInt(1))
+	lockTx, _ := types.SignTx(types.NewTransaction(17, wrapper, amount("1000000000000000000"), 300000, amount("100000000000"), mustHex("60806040")), signer, key)
+	unlockTx, _ := types.SignTx(types.NewTransaction(18, eccm, big.NewInt(0), 400000, amount("80000000000"), mustHex("d450e04c")), signer, key)
+	dapp := common.HexToAddress("7d79d1d6e5b3c4a2918f0e7d6c5b4a3928170f6e")
+	callTx, _ := types.SignTx(types.NewTransaction(19, dapp, big.NewInt(0), 200000, amount("90000000000"), mustHex("a9059cbb")), signer, key)
+	executeTx, _ := types.SignTx(types.NewTransaction(20, eccm, big.NewInt(0), 300000, amount("90000000000"), mustHex("d450e04c")), signer, key)
+	eccmAbi, _ := abi.JSON(strings.NewReader(eccm_abi.EthCrossChainManagerABI))
+	merkle := polycommon.NewZeroCopySink(nil)
+	merkle.WriteVarBytes(mustHex("7b6a5f4e3d2c1b0a9f8e7d6c5b4a39281706f5e4d3c2b1a0f9e8d7c6b5a49382"))
+	merkle.WriteUint64(6)
+	merkle.WriteVarBytes(mustHex(makeTxParam(mustHex("0000000000000000000000000000000000000000000000000000000000000b2e"), mustHex("5e4d3c2b1a0f9e8d7c6b5a49382716f5e4d3c2b1a0f9e8d7c6b5a4938271605f"), bscProxy, 2, proxy.Bytes(), "unlock", unlockArgs(common.HexToAddress("dac17f958d2ee523a2206206994597c13d831ec7").Bytes(), user.Bytes(), amount("2500000000")))))
+	proof := polycommon.NewZeroCopySink(nil)
+	proof.WriteVarBytes(merkle.Bytes())
+	proof.WriteBytes(mustHex("00a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90"))
+	revertInput, err := eccmAbi.Pack("verifyHeaderAndExecuteTx", proof.Bytes(), []byte{}, []byte{}, []byte{}, []byte{})
+	if err != nil {
+		panic(err)
+	}
+	revertTx, _ := types.SignTx(types.NewTransaction(21, eccm, big.NewInt(0), 400000, amount("70000000000"), revertInput), signer, key)
+	revertData, _ := abi.NewType("string", "", nil)
+	revertReason, _ := abi.Arguments{{Type: revertData}}.Pack("EthCrossChain: the transaction has been executed!")
+
+	header := &types.Header{
+		ParentHash:  common.HexToHash("0x5cc8bbaf0fdea8bd3f8ba4af17b0c0c4c6ab7ed2d8b8a9cd5d2e5e9d1b6f1a01"),
+		UncleHash:   types.EmptyUncleHash,
+		Coinbase:    common.HexToAddress("ea674fdde714fd979de3edf0f56aa9716b898ec8"),
+		Root:        common.HexToHash("0x1b8b0f0a5a3f5e2f3b1e8a0a3c1d5e2c6f8a2b4d6e8f0a1b3c5d7e9f1a3b5c7d"),
+		TxHash:      types.EmptyRootHash,
+		ReceiptHash: types.EmptyRootHash,
+		Difficulty:  amount("6470138376254741"),
+		Number:      new(big.Int).SetUint64(height),
+		GasLimit:    12481481,
+		GasUsed:     12467384,
+		Time:        1615970497,
+		Extra:       mustHex("6574682d70726f2d687a662d74303032"),
+	}
+	blockHash := header.Hash()
+
+	txId := mustHex("0000000000000000000000000000000000000000000000000000000000021c3f")
+	net := amount("998000000000000000")
+	fee := amount("2000000000000000")
+	lockLogs := []*types.Log{
+		evmLog(lock_proxy_abi.LockProxyABI, "LockEvent", proxy, map[string]interface{}{
+			"fromAssetHash": common.Address{},
+			"fromAddress":   wrapper,
+			"toChainId":     uint64(6),
+			"toAssetHash":   mustHex("2170ed0880ac9a755fd29b2688956bd959f933f8"),
+			"toAddress":     user.Bytes(),
+			"amount":        net,
+		}),
+		evmLog(eccm_abi.EthCrossChainManagerABI, "CrossChainEvent", eccm, map[string]interface{}{
+			"sender":               user,
+			"txId":                 txId,
+			"proxyOrAssetContract": proxy,
+			"toChainId":            uint64(6),
+			"toContract":           bscProxy,
+			"rawdata":              mustHex(makeTxParam(txId, mustHex("8a1d2c6b12e7b2fc5a0f0c8e4ed6b2a0f6e1b7c3d9a5e4f2c8b6a4d2e0f1c3b5"), proxy.Bytes(), 6, bscProxy, "unlock", unlockArgs(mustHex("2170ed0880ac9a755fd29b2688956bd959f933f8"), user.Bytes(), net))),
+		}),
+		evmLog(wrapper_abi.IPolyWrapperABI, "PolyWrapperLock", wrapper, map[string]interface{}{
+			"fromAsset": common.Address{},
+			"sender":    user,
+			"toChainId": uint64(6),
+			"toAddress": user.Bytes(),
+			"net":       net,
+			"fee":       fee,
+			"id":        big.NewInt(0),
+		}),
+	}
+	unlockLogs := []*types.Log{
+		evmLog(lock_proxy_abi.LockProxyABI, "UnlockEvent", proxy, map[string]interface{}{
+			"toAssetHash": common.HexToAddress("dac17f958d2ee523a2206206994597c13d831ec7"),
+			"toAddress":   common.HexToAddress("5a51e2ebf8d136926b9ca7b59b60464e7c44d2eb"),
+			"amount":      amount("2500000000"),
+		}),
+		evmLog(eccm_abi.EthCrossChainManagerABI, "VerifyHeaderAndExecuteTxEvent", eccm, map[string]interface{}{
+			"fromChainID":      uint64(6),
+			"toContract":       proxy.Bytes(),
+			"crossChainTxHash": mustHex("3a1b0f7c2e9d8a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b"),
+			"fromChainTxHash":  mustHex("9f4e3d2c1b0a9f8e7d6c5b4a3928170f6e5d4c3b2a19081726354453627180a9"),
+		}),
+	}
+	callLogs := []*types.Log{
+		evmLog(eccm_abi.EthCrossChainManagerABI, "CrossChainEvent", eccm, map[string]interface{}{
+			"sender":               user,
+			"txId":                 mustHex("0000000000000000000000000000000000000000000000000000000000021c40"),
+			"proxyOrAssetContract": dapp,
+			"toChainId":            uint64(6),
+			"toContract":           mustHex("3b8e1a6c5d4f2e9b0a7c6d5e4f3a2b1c0d9e8f7a"),
+			"rawdata":              mustHex(makeTxParam(mustHex("0000000000000000000000000000000000000000000000000000000000021c40"), mustHex("6b1f0e9d8c7b6a5f4e3d2c1b0a9f8e7d6c5b4a3f2e1d0c9b8a7f6e5d4c3b2a19"), dapp.Bytes(), 6, mustHex("3b8e1a6c5d4f2e9b0a7c6d5e4f3a2b1c0d9e8f7a"), "receiveMessage", []byte("hello poly"))),
+		}),
+	}
+	executeLogs := []*types.Log{
+		evmLog(eccm_abi.EthCrossChainManagerABI, "VerifyHeaderAndExecuteTxEvent", eccm, map[string]interface{}{
+			"fromChainID":      uint64(6),
+			"toContract":       dapp.Bytes(),
+			"crossChainTxHash": mustHex("4c2d1e0f9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d"),
+			"fromChainTxHash":  mustHex("8e3d2c1b0a9f8e7d6c5b4a3928170f6e5d4c3b2a19081726354453627180a9b0"),
+		}),
+	}
+	allLogs := make([]*types.Log, 0)
+	receipts := map[common.Hash]*types.Receipt{}
+	txs := map[common.Hash]*types.Transaction{}
+	for i, item := range []struct {
+		tx   *types.Transaction
+		logs []*types.Log
+		gas  uint64
+	}{{lockTx, lockLogs, 186513}, {unlockTx, unlockLogs, 231074}, {callTx, callLogs, 61022}, {executeTx, executeLogs, 152340}, {revertTx, []*types.Log{}, 98213}} {
+		for j, log := range item.logs {
+			log.BlockNumber = height
+			log.BlockHash = blockHash
+			log.TxHash = item.tx.Hash()
+			log.TxIndex = uint(41 + i)
+			log.Index = uint(j + 3*i)
+		}
+		status := uint64(1)
+		if item.tx == revertTx {
+			status = 0
+		}
+		receipt := &types.Receipt{Status: status, CumulativeGasUsed: 8000000 + item.gas, Logs: item.logs, TxHash: item.tx.Hash(), GasUsed: item.gas, BlockHash: blockHash, BlockNumber: header.Number, TransactionIndex: uint(41 + i)}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		receipts[item.tx.Hash()] = receipt
+		txs[item.tx.Hash()] = item.tx
+		allLogs = append(allLogs, item.logs...)
+	}
+	serve("127.0.0.1:18545", false, methods{
+		"eth_blockNumber": func(params []json.RawMessage) interface{} {
+			return hexutil.Uint64(height + 15)
+		},
+		"eth_getBlockByNumber": func(params []json.RawMessage) interface{} {
+			if len(params) < 2 || string(params[1]) != "true" {
+				return header
+			}
+			data, _ := json.Marshal(header)
+			fields := map[string]interface{}{}
+			json.Unmarshal(data, &fields)
+			list := make([]interface{}, 0)
+			var number hexutil.Uint64
+			json.Unmarshal(params[0], &number)
+			if uint64(number) != height {
+				// the other blocks of a range have no transactions
+				fields["number"] = number
+				fields["transactions"] = list
+				return fields
+			}
+			for _, tx := range []*types.Transaction{lockTx, unlockTx, callTx, executeTx, revertTx} {
+				data, _ := json.Marshal(tx)
+				item := map[string]interface{}{}
+				json.Unmarshal(data, &item)
+				item["from"] = user
+				item["blockHash"] = blockHash
+				item["blockNumber"] = hexutil.Uint64(height)
+				list = append(list, item)
+			}
+			fields["transactions"] = list
+			return fields
+		},
+		"eth_call": func(params []json.RawMessage) interface{} {
+			call := struct {
+				To   common.Address
+				Data hexutil.Bytes
+			}{}
+			json.Unmarshal(params[0], &call)
+			if call.To != eccm || !strings.HasPrefix(hexutil.Encode(call.Data), hexutil.Encode(revertInput[:4])) {
+				return "0x"
+			}
+			return &rpcError{Code: 3, Message: "execution reverted: EthCrossChain: the transaction has been executed!", Data: hexutil.Encode(append(mustHex("08c379a0"), revertReason...))}
+		},
+		"eth_getLogs": func(params []json.RawMessage) interface{} {
+			filter := struct {
+				Address []common.Address
+				Topics  [][]common.Hash
+			}{}
+			if err := json.Unmarshal(params[0], &filter); err != nil {
+				panic(err)
+			}
+			result := make([]*types.Log, 0)
+			for _, log := range allLogs {
+				matched := false
+				for _, address := range filter.Address {
+					matched = matched || address == log.Address
+				}
+				if !matched {
+					continue
+				}
+				if len(filter.Topics) > 0 && len(filter.Topics[0]) > 0 {
+					matched = false
+					for _, topic := range filter.Topics[0] {
+						matched = matched || topic == log.Topics[0]
+					}
+					if !matched {
+						continue
+					}
+				}
+				result = append(result, log)
+			}
+			return result
+		},
+		"eth_getTransactionByHash": func(params []json.RawMessage) interface{} {
+			var hash common.Hash
+			json.Unmarshal(params[0], &hash)
+			tx := txs[hash]
+			data, _ := json.Marshal(tx)
+			fields := map[string]interface{}{}
+			json.Unmarshal(data, &fields)
+			fields["blockHash"] = blockHash
+			fields["blockNumber"] = hexutil.Uint64(height)
+			fields["from"] = user
+			fields["transactionIndex"] = hexutil.Uint64(receipts[hash].TransactionIndex)
+			return fields
+		},
+		"eth_getTransactionReceipt": func(params []json.RawMessage) interface{} {
+			var hash common.Hash
+			json.Unmarshal(params[0], &hash)
+			if hash != unlockTx.Hash() {
+				return receipts[hash]
+			}
+			// the unlock is priced by the london effective gas price rather than its gas price
+			data, _ := json.Marshal(receipts[hash])
+			fields := map[string]interface{}{}
+			json.Unmarshal(data, &fields)
+			fields["effectiveGasPrice"] = (*hexutil.Big)(amount("61000000000"))
+			return fields
+		},
+	})
+	fmt.Println("evm lock", lockTx.Hash().Hex(), "unlock", unlockTx.Hash().Hex(), "call", callTx.Hash().Hex(), "execute", executeTx.Hash().Hex(), "revert", revertTx.Hash().Hex(), "user", user.Hex())
+}
+
+// ----- poly -----
+
+func poly() {
+	height := uint32(15000000)
+	block := &polytypes.Block{
+		Header: &polytypes.Header{
+			Version:          0,
+			ChainID:          0,
+			PrevBlockHash:    polycommon.Uint256{0x1f, 0x2e, 0x3d},
+			TransactionsRoot: polycommon.Uint256{},
+			CrossStateRoot:   polycommon.Uint256{0x6a, 0x79},
+			BlockRoot:        polycommon.Uint256{0x88, 0x97},
+			Timestamp:        1615970620,
+			Height:           height,
+			ConsensusData:    4728120949582334861,
+			NextBookkeeper:   polycommon.Address{},
+		},
+		Transactions: []*polytypes.Transaction{},
+	}
+	sink := polycommon.NewZeroCopySink(nil)
+	if err := block.Serialization(sink); err != nil {
+		panic(err)
+	}
+	events := []map[string]interface{}{
+		{
+			"TxHash": "c7a1e0b2d3f4a5968778695a4b3c2d1e0f1a2b3c4d5e6f708192a3b4c5d6e7f8", "State": 1, "GasConsumed": 0,
+			"Notify": []map[string]interface{}{
+				{"ContractAddress": "0300000000000000000000000000000000000000", "States": []interface{}{"makeProof", 2, 6, "a4b8d6c3e1f2091827364554637281900a1b2c3d4e5f60718293a4b5c6d7e8f9", 12000000, "2b3a4f5e"}},
+			},
+		},
+		{
+			"TxHash": "0e1f2a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f7", "State": 1, "GasConsumed": 0,
+			"Notify": []map[string]interface{}{
+				{"ContractAddress": "0300000000000000000000000000000000000000", "States": []interface{}{"makeProof", 4, 2, "f9e8d7c6b5a4938271605f4e3d2c1b0a00918273645546372819f0e1d2c3b4a5", 7000000, "9c8b7a6f"}},
+			},
+		},
+		{
+			"TxHash": "5d4c3b2a19081726354453627180a9b8c7d6e5f40312a1b0c9d8e7f6a5b4c3d2", "State": 1, "GasConsumed": 0,
+			"Notify": []map[string]interface{}{
+				{"ContractAddress": "0300000000000000000000000000000000000000", "States": []interface{}{"btcTxToRelay", 1, 2, "0a1b2c3d4e5f60718293a4b5c6d7e8f9a4b8d6c3e1f2091827364554637281900", "00"}},
+				{"ContractAddress": "0700000000000000000000000000000000000000", "States": []interface{}{"syncHeader", 1, 668000}},
+			},
+		},
+	}
+	serve("127.0.0.1:18336", true, methods{
+		"getblockcount": func(params []json.RawMessage) interface{} { return height + 10 },
+		"getblock":      func(params []json.RawMessage) interface{} { return hex.EncodeToString(sink.Bytes()) },
+		"getsmartcodeevent": func(params []json.RawMessage) interface{} {
+			return events
+		},
+	})
+}
+
+// ----- ontology -----
+
+func ontTx(nonce uint32, gasPrice uint64) *onttypes.Transaction {
+	mutable := &onttypes.MutableTransaction{
+		TxType:   onttypes.InvokeNeo,
+		Nonce:    nonce,
+		GasPrice: gasPrice,
+		GasLimit: 20000,
+		Payload:  &ontpayload.InvokeCode{Code: []byte{0x00, 0xc1, byte(nonce)}},
+		Sigs:     []onttypes.Sig{},
+	}
+	tx, err := mutable.IntoImmutable()
+	if err != nil {
+		panic(err)
+	}
+	return tx
+}
+
+func ontology() {
+	height := uint32(13000000)
+	block := &onttypes.Block{
+		Header: &onttypes.Header{
+			Version:          0,
+			PrevBlockHash:    ontcommon.Uint256{0x2a, 0x3b, 0x4c},
+			TransactionsRoot: ontcommon.Uint256{},
+			BlockRoot:        ontcommon.Uint256{0x7f, 0x80},
+			Timestamp:        1615970701,
+			Height:           height,
+			ConsensusData:    8215069312946177401,
+			NextBookkeeper:   ontcommon.Address{},
+		},
+		Transactions: []*onttypes.Transaction{ontTx(1, 2500), ontTx(2, 500)},
+	}
+	block.RebuildMerkleRoot()
+	sink := ontcommon.NewZeroCopySink(nil)
+	block.Serialization(sink)
+	lockTx := block.Transactions[0].Hash()
+	unlockTx := block.Transactions[1].Hash()
+	lockHash, unlockHash := lockTx.ToHexString(), unlockTx.ToHexString()
+	wrapper := "c93837e82178d406af8c84e1841c6960af251cb5"
+	proxy := "86b4ab5d99037113867247a1e68f70e348c07597"
+	ccm := "0900000000000000000000000000000000000000"
+	user := "AQf4Mzu1YJrhz9f3aRkkwSm9n3qhXGSh4p"
+	ethUser := "2b5d9a6d8e4f3c1b0a7e6f5d4c3b2a1908f7e6d5"
+	events := []map[string]interface{}{
+		{
+			"TxHash": lockHash, "State": 1, "GasConsumed": 10000000,
+			"Notify": []map[string]interface{}{
+				{"ContractAddress": "0200000000000000000000000000000000000000", "States": []interface{}{"transfer", user, "AFmseVrdL9f9oyCzZefL9tG6UbviEH9ugK", 10000000}},
+				{"ContractAddress": proxy, "States": []interface{}{hexs("lock"), reverse("0100000000000000000000000000000000000000"), user, le(big.NewInt(2)), "f37c3f9e4ab2bf43a5d8dd49a3d2c9c1a2dfbf0c", ethUser, le(big.NewInt(150))}},
+				{"ContractAddress": ccm, "States": []interface{}{"makeFromOntProof", "1e4b2c", 2, "", "e3ab07a1", proxy, makeTxParam(mustHex("e3ab07a1"), mustHex(lockHash), mustHex(proxy), 2, mustHex("f37c3f9e4ab2bf43a5d8dd49a3d2c9c1a2dfbf0c"), "unlock", unlockArgs(mustHex("e5ce0e4bc0c8fd68f4b0da4bb1ad0a0ffb6bfd2e"), mustHex(ethUser), big.NewInt(150)))}},
+				{"ContractAddress": wrapper, "States": []interface{}{hexs("PolyWrapperLock"), reverse("0200000000000000000000000000000000000000"), user, le(big.NewInt(2)), ethUser, le(big.NewInt(150)), le(big.NewInt(50000000)), le(big.NewInt(3))}},
+			},
+		},
+		{
+			"TxHash": unlockHash, "State": 1, "GasConsumed": 20000000,
+			"Notify": []map[string]interface{}{
+				{"ContractAddress": ccm, "States": []interface{}{"verifyToOntProof", reverse("ac7d9d6d7bf5b2a1c0e8f7d6c5b4a39281706f5e4d3c2b1a0918f7e6d5c4b3a2"), "", 2, "", proxy}},
+				{"ContractAddress": proxy, "States": []interface{}{hexs("unlock"), reverse("0100000000000000000000000000000000000000"), "ARGK44mXXZfU6vcdSfFKMzjaabWxyog1qb", le(big.NewInt(75))}},
+			},
+		},
+	}
+	serve("127.0.0.1:20336", true, methods{
+		"getblockcount": func(params []json.RawMessage) interface{} { return height + 10 },
+		"getblock":      func(params []json.RawMessage) interface{} { return hex.EncodeToString(sink.Bytes()) },
+		"getsmartcodeevent": func(params []json.RawMessage) interface{} {
+			return events
+		},
+	})
+}
+
+// ----- neo -----
+
+func param(t string, v string) neomodels.RpcContractParameter {
+	return neomodels.RpcContractParameter{Type: t, Value: v}
+}
+
+func neo() {
+	height := 7000000
+	wrapper := "0x125c83403763670c215f9c7c815ef759b258a41b"
+	proxy := "0xe7fb2e1d937e71dbbb512e6375746181127282e7"
+	ccm := "0x82a3401fb9a60db42c6fa2ea2b6d62e872d6257f"
+	user := "d2c0f6c2f3a6e4d1b3a29281706f5e4d3c2b1a09"
+	ethUser := "5a51e2ebf8d136926b9ca7b59b60464e7c44d2eb"
+	asset := "17da3881ab2d050fea414c80b3fa8324d756f60e"
+	lockTx := "0x4fe8d6b2a1c0f9e8d7c6b5a49382716050f4e3d2c1b0a9f8e7d6c5b4a3928170"
+	unlockTx := "0x0b1c2d3e4f5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0"
+	block := &neomodels.RpcBlock{
+		RpcBlockHeader: neomodels.RpcBlockHeader{
+			Hash:              "0x7b2a6f3e9c1d4b8a5e0f2c7d9b3a6e1f4c8d2b5a9e3f7c1d6b4a8e2f5c9d3b7a",
+			Size:              1812,
+			Version:           0,
+			PreviousBlockHash: "0x3e9c1d4b8a5e0f2c7d9b3a6e1f4c8d2b5a9e3f7c1d6b4a8e2f5c9d3b7a7b2a6f",
+			MerkleRoot:        "0x9b3a6e1f4c8d2b5a9e3f7c1d6b4a8e2f5c9d3b7a7b2a6f3e9c1d4b8a5e0f2c7d",
+			Time:              1615970812,
+			Index:             height,
+			Nonce:             "5c3b8f0e2a1d7e94",
+			NextConsensus:     "AZ81H31DMWzbSnFDLFkzh9vHwaDLayV7fU",
+			Confirmations:     10,
+		},
+		Tx: []neomodels.RpcTransaction{
+			{Txid: "0x2c7d9b3a6e1f4c8d2b5a9e3f7c1d6b4a8e2f5c9d3b7a7b2a6f3e9c1d4b8a5e0f", Size: 10, Type: "MinerTransaction", Nonce: 1583947412},
+			{Txid: lockTx, Size: 702, Type: "InvocationTransaction", SysFee: "0", NetFee: "0.001"},
+			{Txid: unlockTx, Size: 1100, Type: "InvocationTransaction", SysFee: "1", NetFee: "0.0025"},
+		},
+	}
+	logs := map[string]*neomodels.RpcApplicationLog{
+		lockTx: {
+			TxId: lockTx,
+			Executions: []neomodels.RpcExecution{{
+				Trigger: "Application", Contract: "0x3b6e1f4c8d2b5a9e3f7c1d6b4a8e2f5c9d3b7a7b", VMState: "HALT", GasConsumed: "3.862",
+				Notifications: []neomodels.RpcNotification{
+					{Contract: "0x" + asset, State: neomodels.RpcState{Type: "Array", Value: []neomodels.RpcContractParameter{param("ByteArray", hexs("transfer")), param("ByteArray", user), param("ByteArray", reverse(proxy[2:])), param("Integer", "2000000000")}}},
+					{Contract: proxy, State: neomodels.RpcState{Type: "Array", Value: []neomodels.RpcContractParameter{param("ByteArray", hexs("Lock")), param("ByteArray", reverse(asset)), param("ByteArray", user), param("Integer", "2"), param("ByteArray", "b7b1af3a8b26f5f8a5c81d9d1b35d2e5f1c6e7a0"), param("ByteArray", ethUser), param("Integer", "2000000000")}}},
+					{Contract: ccm, State: neomodels.RpcState{Type: "Array", Value: []neomodels.RpcContractParameter{param("ByteArray", hexs("CrossChainLockEvent")), param("ByteArray", user), param("ByteArray", reverse(proxy[2:])), param("Integer", "2"), param("ByteArray", "0e8a"), param("ByteArray", makeTxParam(mustHex("0e8a"), mustHex("c7a1e0b2d3f4a5968778695a4b3c2d1e0f1a2b3c4d5e6f708192a3b4c5d6e7f8"), mustHex(reverse(proxy[2:])), 2, mustHex("b7b1af3a8b26f5f8a5c81d9d1b35d2e5f1c6e7a0"), "unlock", unlockArgs(mustHex("d6c5b4a39281706f5e4d3c2b1a0918f7e6d5c4b3"), mustHex(ethUser), big.NewInt(2000000000))))}}},
+					{Contract: wrapper, State: neomodels.RpcState{Type: "Array", Value: []neomodels.RpcContractParameter{param("ByteArray", hexs("PolyWrapperLock")), param("ByteArray", reverse(asset)), param("ByteArray", user), param("Integer", "2"), param("ByteArray", ethUser), param("Integer", "1990000000"), param("Integer", "10000000"), param("Integer", "5")}}},
+				},
+			}},
+		},
+		unlockTx: {
+			TxId: unlockTx,
+			Executions: []neomodels.RpcExecution{{
+				Trigger: "Application", Contract: "0x6e1f4c8d2b5a9e3f7c1d6b4a8e2f5c9d3b7a7b3b", VMState: "HALT", GasConsumed: "5.417",
+				Notifications: []neomodels.RpcNotification{
+					{Contract: ccm, State: neomodels.RpcState{Type: "Array", Value: []neomodels.RpcContractParameter{param("ByteArray", hexs("CrossChainUnlockEvent")), param("Integer", "2"), param("ByteArray", reverse(proxy[2:])), param("ByteArray", reverse("5e0f2c7d9b3a6e1f4c8d2b5a9e3f7c1d6b4a8e2f5c9d3b7a7b2a6f3e9c1d4b8a"))}}},
+					{Contract: proxy, State: neomodels.RpcState{Type: "Array", Value: []neomodels.RpcContractParameter{param("ByteArray", hexs("UnlockEvent")), param("ByteArray", reverse(asset)), param("ByteArray", user), param("Integer", "1500000000")}}},
+					{Contract: "0x" + asset, State: neomodels.RpcState{Type: "Array", Value: []neomodels.RpcContractParameter{param("ByteArray", hexs("transfer")), param("ByteArray", reverse(proxy[2:])), param("ByteArray", user), param("Integer", "1500000000")}}},
+				},
+			}},
+		},
+	}
+	serve("127.0.0.1:10332", false, methods{
+		"getblockcount": func(params []json.RawMessage) interface{} { return height + 10 },
+		"getblock":      func(params []json.RawMessage) interface{} { return block },
+		"getapplicationlog": func(params []json.RawMessage) interface{} {
+			var txid string
+			json.Unmarshal(params[0], &txid)
+			return logs[txid]
+		},
+	})
+}
+
+func main() {
+	evm()
+	poly()
+	ontology()
+	neo()
+	select {}
+}
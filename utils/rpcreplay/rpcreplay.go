@@ -0,0 +1,268 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package rpcreplay records the json rpc calls of the chain sdks to a fixture file and replays them offline.
+package rpcreplay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+)
+
+const (
+	MODE_RECORD = "record"
+	MODE_REPLAY = "replay"
+)
+
+const (
+	// ENV_RECORD makes NewTransport record from the real nodes instead of replaying.
+	ENV_RECORD = "RPC_RECORD"
+	// ENV_NODE is the node to record from, it overrides the node url of the tests.
+	ENV_NODE = "RPC_NODE"
+)
+
+// Exchange is one json rpc request and the response of the node.
+type Exchange struct {
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response"`
+}
+
+// Transport is a http.RoundTripper which records json rpc exchanges or replays recorded ones.
+// Requests are matched by their content without the json rpc id, the id of a replayed response is
+// rewritten to the id of the request. When a request is repeated, the recorded responses are served
+// in order and the last one is served again afterwards.
+type Transport struct {
+	mode      string
+	path      string
+	base      http.RoundTripper
+	exchanges []*Exchange
+	replays   map[string][]*Exchange
+	served    map[string]int
+	lock      sync.Mutex
+}
+
+// NewTransport replays the fixture file, or records it through the real nodes when RPC_RECORD is set.
+func NewTransport(path string) (*Transport, error) {
+	if os.Getenv(ENV_RECORD) != "" {
+		return NewRecorder(path), nil
+	}
+	return NewReplayer(path)
+}
+
+// NodeUrl returns the node set by RPC_NODE, or url when it is not set.
+func NodeUrl(url string) string {
+	if node := os.Getenv(ENV_NODE); node != "" {
+		return node
+	}
+	return url
+}
+
+func NewRecorder(path string) *Transport {
+	return &Transport{
+		mode:      MODE_RECORD,
+		path:      path,
+		base:      http.DefaultTransport,
+		exchanges: make([]*Exchange, 0),
+	}
+}
+
+func NewReplayer(path string) (*Transport, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	exchanges := make([]*Exchange, 0)
+	if err := json.Unmarshal(data, &exchanges); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %v", path, err)
+	}
+	transport := &Transport{
+		mode:      MODE_REPLAY,
+		path:      path,
+		exchanges: exchanges,
+		replays:   make(map[string][]*Exchange),
+		served:    make(map[string]int),
+	}
+	for _, exchange := range exchanges {
+		key, err := requestKey(exchange.Request)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request in fixture %s: %v", path, err)
+		}
+		transport.replays[key] = append(transport.replays[key], exchange)
+	}
+	return transport, nil
+}
+
+func (transport *Transport) Mode() string {
+	return transport.mode
+}
+
+// Close writes the recorded exchanges to the fixture file, it does nothing when replaying.
+func (transport *Transport) Close() error {
+	if transport.mode != MODE_RECORD {
+		return nil
+	}
+	transport.lock.Lock()
+	defer transport.lock.Unlock()
+	data, err := json.MarshalIndent(transport.exchanges, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(transport.path, append(data, '\n'), 0644)
+}
+
+func (transport *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := []byte{}
+	if req.Body != nil {
+		data, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = data
+	}
+	if transport.mode == MODE_RECORD {
+		return transport.record(req, body)
+	}
+	return transport.replay(req, body)
+}
+
+func (transport *Transport) record(req *http.Request, body []byte) (*http.Response, error) {
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	resp, err := transport.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+	if resp.StatusCode == http.StatusOK && json.Valid(body) && json.Valid(data) {
+		transport.lock.Lock()
+		transport.exchanges = append(transport.exchanges, &Exchange{Request: body, Response: data})
+		transport.lock.Unlock()
+	}
+	return resp, nil
+}
+
+func (transport *Transport) replay(req *http.Request, body []byte) (*http.Response, error) {
+	key, err := requestKey(body)
+	if err != nil {
+		return nil, fmt.Errorf("rpcreplay: invalid request %s: %v", body, err)
+	}
+	transport.lock.Lock()
+	exchanges := transport.replays[key]
+	if len(exchanges) == 0 {
+		transport.lock.Unlock()
+		return nil, fmt.Errorf("rpcreplay: no response of %s in %s", body, transport.path)
+	}
+	index := transport.served[key]
+	if index >= len(exchanges) {
+		index = len(exchanges) - 1
+	}
+	transport.served[key]++
+	transport.lock.Unlock()
+	data, err := rewriteIds(exchanges[index].Request, body, exchanges[index].Response)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}, nil
+}
+
+// requestKey is the canonical json of the request without the json rpc ids.
+func requestKey(body []byte) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var request interface{}
+	if err := decoder.Decode(&request); err != nil {
+		return "", err
+	}
+	switch value := request.(type) {
+	case map[string]interface{}:
+		delete(value, "id")
+	case []interface{}:
+		for _, item := range value {
+			if call, ok := item.(map[string]interface{}); ok {
+				delete(call, "id")
+			}
+		}
+	}
+	key, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	return string(key), nil
+}
+
+// rewriteIds replaces the ids of the recorded request in the response with the ids of the new request.
+func rewriteIds(recorded []byte, request []byte, response []byte) ([]byte, error) {
+	ids := make(map[string]json.RawMessage)
+	if recorded := decodeCalls(recorded); recorded != nil {
+		calls := decodeCalls(request)
+		for i, call := range recorded {
+			if i < len(calls) {
+				ids[string(call["id"])] = calls[i]["id"]
+			}
+		}
+	}
+	results := decodeCalls(response)
+	if results == nil {
+		return response, nil
+	}
+	for _, result := range results {
+		if id, ok := ids[string(result["id"])]; ok {
+			result["id"] = id
+		}
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(response), []byte("[")) {
+		return json.Marshal(results)
+	}
+	return json.Marshal(results[0])
+}
+
+// decodeCalls decodes a json rpc object or batch, it returns nil for other content.
+func decodeCalls(data []byte) []map[string]json.RawMessage {
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("[")) {
+		calls := make([]map[string]json.RawMessage, 0)
+		if err := json.Unmarshal(data, &calls); err != nil {
+			return nil
+		}
+		return calls
+	}
+	call := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &call); err != nil {
+		return nil
+	}
+	return []map[string]json.RawMessage{call}
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (C) 2020 The poly network Authors
+ * This file is part of The poly network library.
+ *
+ * The  poly network  is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The  poly network  is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU Lesser General Public License for more details.
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with The poly network .  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package rpcreplay
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func post(t *testing.T, client *http.Client, url string, body string) string {
+	resp, err := client.Post(url, "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestTransport_RecordReplay(t *testing.T) {
+	height := 100
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := make(map[string]json.RawMessage)
+		json.NewDecoder(r.Body).Decode(&request)
+		height++
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": request["id"], "result": height})
+	}))
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "rpcreplay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fixture := filepath.Join(dir, "fixture.json")
+
+	recorder := NewRecorder(fixture)
+	client := &http.Client{Transport: recorder}
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":1,"result":101}`, post(t, client, server.URL, `{"jsonrpc":"2.0","id":1,"method":"getblockcount","params":[]}`))
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":2,"result":102}`, post(t, client, server.URL, `{"jsonrpc":"2.0","id":2,"method":"getblockcount","params":[]}`))
+	assert.Nil(t, recorder.Close())
+
+	replayer, err := NewReplayer(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = &http.Client{Transport: replayer}
+	// the responses are served in order with the id of the request, the last one is repeated
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":7,"result":101}`, post(t, client, "http://127.0.0.1:1", `{"params":[],"method":"getblockcount","id":7,"jsonrpc":"2.0"}`))
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":"8","result":102}`, post(t, client, "http://127.0.0.1:1", `{"jsonrpc":"2.0","id":"8","method":"getblockcount","params":[]}`))
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":9,"result":102}`, post(t, client, "http://127.0.0.1:1", `{"jsonrpc":"2.0","id":9,"method":"getblockcount","params":[]}`))
+	_, err = client.Post("http://127.0.0.1:1", "application/json", bytes.NewBufferString(`{"jsonrpc":"2.0","id":1,"method":"getblock","params":[1]}`))
+	assert.NotNil(t, err)
+}
+
+func TestRewriteIds(t *testing.T) {
+	recorded := []byte(`[{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber"},{"jsonrpc":"2.0","id":2,"method":"eth_chainId"}]`)
+	request := []byte(`[{"jsonrpc":"2.0","id":11,"method":"eth_blockNumber"},{"jsonrpc":"2.0","id":12,"method":"eth_chainId"}]`)
+	response := []byte(`[{"jsonrpc":"2.0","id":2,"result":"0x1"},{"jsonrpc":"2.0","id":1,"result":"0x10"}]`)
+	data, err := rewriteIds(recorded, request, response)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `[{"jsonrpc":"2.0","id":12,"result":"0x1"},{"jsonrpc":"2.0","id":11,"result":"0x10"}]`, string(data))
+
+	key1, err := requestKey(recorded)
+	assert.Nil(t, err)
+	key2, err := requestKey(request)
+	assert.Nil(t, err)
+	assert.Equal(t, key1, key2)
+}
@@ -37,6 +37,26 @@ func LoadEthAccount(storage *leveldb.LevelDBImpl, keystore string, address strin
 	return key.PrivateKey, nil
 }
 
+// LoadEthKey loads the key from a hex private key file or a keystore file without asking for the password
+func LoadEthKey(file string, pwd string) (*ecdsa.PrivateKey, error) {
+	enc, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if hexKey := strings.TrimPrefix(strings.TrimSpace(string(enc)), "0x"); len(hexKey) <= 64 {
+		bz, err := hex.DecodeString(hexKey)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.ToECDSA(bz)
+	}
+	key, err := keystore.DecryptKey(enc, pwd)
+	if err != nil {
+		return nil, err
+	}
+	return key.PrivateKey, nil
+}
+
 func repeatDecrypt(storage *leveldb.LevelDBImpl, enc []byte, address string, pwd string) (key *keystore.Key, err error) {
 	if existPwd, err := getEthPwdSession(storage, address); err == nil {
 		return keystore.DecryptKey(enc, existPwd)
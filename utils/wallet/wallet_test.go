@@ -2,6 +2,8 @@ package wallet
 
 import (
 	"encoding/hex"
+	"io/ioutil"
+	"os"
 	"poly-bridge/utils/leveldb"
 	"testing"
 
@@ -32,3 +34,22 @@ func TestDecryptKey(t *testing.T) {
 	addr := crypto.PubkeyToAddress(ec.PublicKey)
 	t.Log(addr.Hex())
 }
+
+func TestLoadEthKey(t *testing.T) {
+	data := "12f66113274159e261c2361f076dce335f917cd9244437129dfdbb640a80a171"
+	file, err := ioutil.TempFile("", "eth_key")
+	assert.NoError(t, err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString("0x" + data + "\n")
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	key, err := LoadEthKey(file.Name(), "")
+	assert.NoError(t, err)
+	bz, _ := hex.DecodeString(data)
+	expected, _ := crypto.ToECDSA(bz)
+	assert.Equal(t, crypto.PubkeyToAddress(expected.PublicKey), crypto.PubkeyToAddress(key.PublicKey))
+
+	_, err = LoadEthKey(file.Name()+".missing", "")
+	assert.Error(t, err)
+}